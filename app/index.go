@@ -8,8 +8,10 @@ import (
 	"onpaper-api-go/logger"
 	"onpaper-api-go/router"
 	"onpaper-api-go/settings"
+	"onpaper-api-go/utils/agreement"
 	"onpaper-api-go/utils/jwt"
 	"onpaper-api-go/utils/snowflake"
+	"onpaper-api-go/utils/watermark"
 
 	"github.com/gin-gonic/gin"
 
@@ -69,5 +71,21 @@ func Init() (r *gin.Engine) {
 		zap.L().Error("jwt init fail", zap.Error(err))
 	}
 	zap.L().Info("jwt init success...")
+
+	//8.加载约稿协议字体
+	// 字体不可用时只关闭约稿协议的生成 不影响其他功能
+	if err = agreement.Init(); err != nil {
+		zap.L().Error("agreement font init fail, agreement disabled", zap.Error(err))
+	} else {
+		zap.L().Info("agreement font init success...")
+	}
+
+	//9.加载下载水印字体
+	// 字体不可用时只关闭需要水印的下载 不影响其他功能
+	if err = watermark.Init(); err != nil {
+		zap.L().Error("watermark font init fail, watermark download disabled", zap.Error(err))
+	} else {
+		zap.L().Info("watermark font init success...")
+	}
 	return
 }
@@ -9,6 +9,7 @@ import (
 	"onpaper-api-go/dao/mysql"
 	"onpaper-api-go/logger"
 	m "onpaper-api-go/models"
+	"strings"
 	"time"
 )
 
@@ -224,6 +225,59 @@ func SendCompressQueue(uid string, mid int64, mType string) (err error) {
 	return
 }
 
+// CreateAnalyzeGroup 在压缩流上创建计算图片哈希的消费组 和图片服务器互不影响 已经存在时不报错
+func CreateAnalyzeGroup() (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = Rdb.XGroupCreateMkStream(ctx, CompressStreamName, AnalyzeGroupName, "$").Err()
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		err = nil
+	}
+	if err != nil {
+		err = errors.Wrap(err, "CreateAnalyzeGroup fail")
+	}
+	return
+}
+
+// ReadAnalyzeQueue 读取压缩流中的消息 start 为 ">" 时读取新消息 为 "0" 时读取已读取没有确认的消息
+// 没有消息时等待 block 返回空
+func ReadAnalyzeQueue(consumer, start string, block time.Duration) (msgs []redis.XMessage, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), block+5*time.Second)
+	defer cancel()
+
+	streams, err := Rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    AnalyzeGroupName,
+		Consumer: consumer,
+		Streams:  []string{CompressStreamName, start},
+		Count:    10,
+		Block:    block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		err = errors.Wrap(err, "ReadAnalyzeQueue fail")
+		return
+	}
+	for _, stream := range streams {
+		msgs = append(msgs, stream.Messages...)
+	}
+	return
+}
+
+// AckAnalyzeQueue 确认已经处理的消息
+func AckAnalyzeQueue(ids ...string) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = Rdb.XAck(ctx, CompressStreamName, AnalyzeGroupName, ids...).Err()
+	if err != nil {
+		err = errors.Wrap(err, "AckAnalyzeQueue fail")
+	}
+	return
+}
+
 // GetHotArtwork 获取热门作品数据
 func GetHotArtwork() (artData []m.HotArtworkData, artIds []string, err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -463,3 +517,32 @@ func CheckArtworkCount(artworkId string) (err error) {
 
 	return
 }
+
+// SetDownloadToken 保存下载链接对应的文件 过期后链接失效
+func SetDownloadToken(token string, data m.DownloadToken, expire time.Duration) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	byteData, err := json.Marshal(&data)
+	if err != nil {
+		return
+	}
+	err = Rdb.Set(ctx, fmt.Sprintf(DownloadToken, token), byteData, expire).Err()
+	if err != nil {
+		err = errors.Wrap(err, "SetDownloadToken fail")
+	}
+	return
+}
+
+// UseDownloadToken 取出下载链接对应的文件并删除 链接不存在或已使用时返回 redis.Nil
+func UseDownloadToken(token string) (data m.DownloadToken, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	val, err := Rdb.GetDel(ctx, fmt.Sprintf(DownloadToken, token)).Bytes()
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(val, &data)
+	return
+}
@@ -423,6 +423,7 @@ func DelCommentCache(comment m.Comment) (err error) {
 	var zSetKey string
 	if comment.RootId == 0 {
 		zSetKey = fmt.Sprintf(CommentRootList, comment.OwnId)
+		pipe.ZRem(ctx, fmt.Sprintf(CommentRootHot, comment.OwnId), cId) // 热度快照删除
 	} else {
 		rootId := strconv.FormatInt(comment.RootId, 10)
 		zSetKey = fmt.Sprintf(CommentChildList, rootId)
@@ -451,3 +452,195 @@ func DelCommentCache(comment m.Comment) (err error) {
 	}
 	return
 }
+
+// ResetCommentDetail 评论内容修改后 删除评论详情缓存 下次查询时重新缓存
+func ResetCommentDetail(comment m.Comment) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	keys := []string{fmt.Sprintf(CommentDetail, strconv.FormatInt(comment.CId, 10))}
+	// 子回复可能显示在根回复详情的两条预览里
+	if comment.RootId != 0 {
+		keys = append(keys, fmt.Sprintf(CommentDetail, strconv.FormatInt(comment.RootId, 10)))
+	}
+
+	err = Rdb.Del(ctx, keys...).Err()
+	if err != nil {
+		err = errors.Wrap(err, "ResetCommentDetail fail")
+	}
+	return
+}
+
+// CommentHotSnapshot 根评论热度排序快照保存的条数 超过的到数据库查询
+const CommentHotSnapshot = 300
+
+// SetHotRootComment 设置按热度查询的根评论缓存 ranks 不为 nil 时重新生成热度排序快照
+// 快照在有效期内排序不变 翻页不会因为点赞变化出现重复或遗漏
+func SetHotRootComment(comments []m.ReturnComment, ranks []m.CommentHotRank, ownId string) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pipe := Rdb.Pipeline()
+	for i, comment := range comments {
+		for ci := range comment.ChildComments {
+			comments[i].ChildComments[ci].IsLike = false // 重置是否点赞
+		}
+		cId := strconv.FormatInt(comment.CId, 10)
+
+		//单个评论缓存
+		comment.IsLike = false // 重置是否点赞
+		byteData, jsonErr := json.Marshal(&comment)
+		if jsonErr != nil {
+			return errors.Wrap(jsonErr, "SetHotRootComment Marshal Fail")
+		}
+		pipe.Set(ctx, fmt.Sprintf(CommentDetail, cId), byteData, 24*time.Hour)
+
+		// 设置单个评论的计数
+		countKey := fmt.Sprintf(CommentCount, cId)
+		pipe.HSetNX(ctx, countKey, "Likes", comment.Likes)
+		pipe.Expire(ctx, countKey, 3*24*time.Hour)
+	}
+
+	if ranks != nil {
+		hotKey := fmt.Sprintf(CommentRootHot, ownId)
+		zSetList := make([]redis.Z, 0, len(ranks)+1)
+		for _, rank := range ranks {
+			zSetList = append(zSetList, redis.Z{Member: rank.CId, Score: float64(rank.Hot)})
+		}
+		// 不够快照条数 说明已经包括了所有评论 添加结束标识 热度不会小于 0
+		if len(ranks) < CommentHotSnapshot {
+			zSetList = append(zSetList, redis.Z{Member: "end", Score: -1})
+		}
+		pipe.Del(ctx, hotKey)
+		pipe.ZAdd(ctx, hotKey, zSetList...)
+		pipe.Expire(ctx, hotKey, 10*time.Minute)
+	}
+
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "SetHotRootComment Cache Fail")
+	}
+	return
+}
+
+// GetHotRootCommentId 从热度排序快照中获取 lastCid 之后的根评论缓存 scores 是对应的热度
+func GetHotRootCommentId(ownId, lastCid string, count int) (res []redis.Cmder, scores []float64, isEnd bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	hotKey := fmt.Sprintf(CommentRootHot, ownId)
+	// 按上一页最后一条在快照中的位置翻页 不在快照中交给数据库查询
+	var start int64
+	if lastCid != "0" {
+		rank, rErr := Rdb.ZRevRank(ctx, hotKey, lastCid).Result()
+		if rErr != nil {
+			err = rErr
+			return
+		}
+		start = rank + 1
+	}
+
+	zList, err := Rdb.ZRevRangeWithScores(ctx, hotKey, start, start+int64(count)-1).Result()
+	if err != nil {
+		err = errors.Wrap(err, "GetHotRootCommentId ZRevRange Cache Fail")
+		return
+	}
+	// 快照不存在
+	if len(zList) == 0 && lastCid == "0" {
+		err = redis.Nil
+		return
+	}
+
+	pipe := Rdb.Pipeline()
+	for _, z := range zList {
+		cid := z.Member.(string)
+		if cid == "end" {
+			isEnd = true
+			break
+		}
+		pipe.Get(ctx, fmt.Sprintf(CommentDetail, cid))
+		scores = append(scores, z.Score)
+	}
+	if len(scores) == 0 {
+		return
+	}
+
+	res, err = pipe.Exec(ctx)
+	if err != nil {
+		// 有评论详情的缓存丢失 到数据库查询
+		if errors.Is(err, redis.Nil) {
+			return
+		}
+		err = errors.Wrap(err, "GetHotRootCommentId Get Cache Fail")
+		return
+	}
+	return
+}
+
+// GetTopCommentId 获取置顶的根评论id
+func GetTopCommentId(ownId string) (cid string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cid, err = Rdb.Get(ctx, fmt.Sprintf(CommentTop, ownId)).Result()
+	if err != nil {
+		err = errors.Wrap(err, "GetTopCommentId Cache Fail")
+	}
+	return
+}
+
+// SetTopComment 设置置顶的根评论缓存 comment 为 nil 说明没有置顶
+func SetTopComment(ownId string, comment *m.ReturnComment) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pipe := Rdb.Pipeline()
+	topKey := fmt.Sprintf(CommentTop, ownId)
+	if comment == nil {
+		pipe.Set(ctx, topKey, "0", 24*time.Hour)
+	} else {
+		cId := strconv.FormatInt(comment.CId, 10)
+		pipe.Set(ctx, topKey, cId, 24*time.Hour)
+
+		temp := *comment
+		temp.IsLike = false // 重置是否点赞
+		byteData, jsonErr := json.Marshal(&temp)
+		if jsonErr != nil {
+			return errors.Wrap(jsonErr, "SetTopComment Marshal Fail")
+		}
+		pipe.Set(ctx, fmt.Sprintf(CommentDetail, cId), byteData, 24*time.Hour)
+
+		countKey := fmt.Sprintf(CommentCount, cId)
+		pipe.HSetNX(ctx, countKey, "Likes", comment.Likes)
+		pipe.Expire(ctx, countKey, 3*24*time.Hour)
+	}
+
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "SetTopComment Cache Fail")
+	}
+	return
+}
+
+// ResetCommentList 置顶变化后 删除根评论列表 热度快照 置顶和相关评论详情的缓存
+func ResetCommentList(ownId string, cIds []int64) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	keys := []string{
+		fmt.Sprintf(CommentRootList, ownId),
+		fmt.Sprintf(CommentRootHot, ownId),
+		fmt.Sprintf(CommentTop, ownId),
+	}
+	for _, cid := range cIds {
+		if cid != 0 {
+			keys = append(keys, fmt.Sprintf(CommentDetail, strconv.FormatInt(cid, 10)))
+		}
+	}
+
+	err = Rdb.Del(ctx, keys...).Err()
+	if err != nil {
+		err = errors.Wrap(err, "ResetCommentList fail")
+	}
+	return
+}
@@ -4,8 +4,11 @@ const WxAccessToken = "wx:token" // 微信服务器接口调用凭证
 
 const ServeUserId = "serve:uid"
 const CompressStreamName = "compress" // 图片压缩流的名称
+const AnalyzeGroupName = "analyze"    // 压缩流中计算作品图片哈希的消费组
 
 const NotifyConfig = "notify:config:%s" // 用户通知配置
+const AdultPref = "adult:pref:%s"       // 用户实际生效的敏感内容偏好
+const UserMute = "user:mute:%s"         // 用户feed屏蔽设置
 
 const ActiveDay = "active:day:%s"     // 日活统计
 const ActiveMonth = "active:month:%s" // 月活统计
@@ -31,6 +34,8 @@ const UserLike = "user:like:%s"       // 用户喜欢列表
 const CommentRootList = "comment:rootList:%s"   // 根评论列表
 const CommentChildList = "comment:childList:%s" // 某个根回复里面子回复列表
 const CommentDetail = "comment:detail:%s"       // 评论详情
+const CommentRootHot = "comment:rootHot:%s"     // 根评论热度排序快照
+const CommentTop = "comment:top:%s"             // 置顶的根评论id 没有置顶为 0
 
 const ArtworkCount = "artwork:count:%s" // 作品点赞收藏转发统计
 const TrendCount = "trend:count:%s"     // 动态点赞收藏转发统计
@@ -38,6 +43,9 @@ const UserCount = "user:count:%s"       // 用户数据统计
 const ArtworkHLog = "artwork:HLog:%s"   // 作品浏览量
 const CommentCount = "comment:count:%s" // 评论点赞统计
 
+const TrendPoll = "trend:poll:%s"         // 动态投票统计 选项下标和 Voters
+const TrendPollDirty = "trend:poll:dirty" // 需要落盘的动态投票
+
 const TagRelevant = "tag:relevant:%s"         // tag相关的其他标签
 const TagUser = "tag:user:%s"                 // tag相关用户
 const TagArtworkAndPage = "tag:artwork:%s:%s" // tag 相关作品 按分页缓存
@@ -48,6 +56,7 @@ const HotArtwork = "hot:artwork:%s"     // 单个热门作品资料
 const HotUserAll = "hot:user:all"       // 热门用户列表
 const HotUser = "hot:user:%s"           // 单个热门用户信息
 const HotZone = "hot:zone:%s"           // 分区热门作品
+const HotScoreLock = "hot:score:lock"   // 多个服务同时运行时只有一个计算热门
 
 const RankTag = "rank:tag:%s"         // tag排行榜
 const RankUser = "rank:user:%s"       // 用户排行榜
@@ -59,3 +68,9 @@ const RankTopUseTag = "rank:tag:use"  // 使用最多的tag排行
 
 const AcceptPlan = "plan:accept:%s" //接稿计划详情
 const InvitePlan = "plan:invite:%d" // 邀请计划详情
+
+const RecommendArtwork = "recommend:artwork:%s" // 用户的推荐作品
+const RecommendUser = "recommend:user:%s"       // 用户的推荐用户
+const RecommendLock = "recommend:lock"          // 多个服务同时运行时只有一个计算推荐
+
+const DownloadToken = "download:token:%s" // 原图下载链接 只能使用一次
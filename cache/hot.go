@@ -0,0 +1,118 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/go-redis/redis/v9"
+	"github.com/pkg/errors"
+	m "onpaper-api-go/models"
+	"strconv"
+	"time"
+)
+
+// HotLiveCount 缓存中的实时统计
+type HotLiveCount struct {
+	Count map[string]string // artwork:count:%s 或 trend:count:%s
+	Views int64             // 作品的浏览量 动态没有
+}
+
+// GetHotLiveCount 批量获取作品 动态缓存中的评论 转发数和作品浏览量 按传入顺序返回
+func GetHotLiveCount(candidates []m.HotCandidate) (counts []HotLiveCount, err error) {
+	if len(candidates) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	pipe := Rdb.Pipeline()
+	countCmds := make([]*redis.MapStringStringCmd, len(candidates))
+	viewCmds := make([]*redis.IntCmd, len(candidates))
+	for i, d := range candidates {
+		if d.Type == "aw" {
+			countCmds[i] = pipe.HGetAll(ctx, fmt.Sprintf(ArtworkCount, d.MsgId))
+			viewCmds[i] = pipe.PFCount(ctx, fmt.Sprintf(ArtworkHLog, d.MsgId))
+		} else {
+			countCmds[i] = pipe.HGetAll(ctx, fmt.Sprintf(TrendCount, d.MsgId))
+		}
+	}
+	_, err = pipe.Exec(ctx)
+	if err != nil && !errors.Is(err, redis.Nil) {
+		err = errors.Wrap(err, "GetHotLiveCount fail")
+		return
+	}
+	err = nil
+
+	counts = make([]HotLiveCount, len(candidates))
+	for i := range candidates {
+		counts[i].Count = countCmds[i].Val()
+		if viewCmds[i] != nil {
+			counts[i].Views = viewCmds[i].Val()
+		}
+	}
+	return
+}
+
+// SetHotList 覆盖热门动态 热门作品和分区热门列表 在一个事务中替换 读取时不会读到一半的数据
+// zones 的 key 为分区下标 列表和作品资料使用同样的过期时间 任务停止后旧的热门会过期
+func SetHotList(trendMembers []string, artData []m.HotArtworkData, zones map[int][]string, expire time.Duration) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// 没有数据时写入空列表 redis 的空集合就是删除 key
+	replaceSet := func(pipe redis.Pipeliner, key string, members []string) {
+		pipe.Del(ctx, key)
+		if len(members) == 0 {
+			return
+		}
+		values := make([]interface{}, 0, len(members))
+		for _, member := range members {
+			values = append(values, member)
+		}
+		pipe.SAdd(ctx, key, values...)
+		pipe.Expire(ctx, key, expire)
+	}
+
+	// 先写入作品资料 再替换列表 避免列表中的作品没有资料
+	pipe := Rdb.Pipeline()
+	artIds := make([]string, 0, len(artData))
+	for _, art := range artData {
+		byteData, mErr := json.Marshal(&art)
+		if mErr != nil {
+			return mErr
+		}
+		pipe.Set(ctx, fmt.Sprintf(HotArtwork, art.ArtworkId), string(byteData), expire)
+		artIds = append(artIds, art.ArtworkId)
+	}
+	if len(artIds) != 0 {
+		_, err = pipe.Exec(ctx)
+		if err != nil {
+			err = errors.Wrap(err, "SetHotList set artwork fail")
+			return
+		}
+	}
+
+	tx := Rdb.TxPipeline()
+	replaceSet(tx, HotTrendAll, trendMembers)
+	replaceSet(tx, HotArtworkAll, artIds)
+	for index, ids := range zones {
+		replaceSet(tx, fmt.Sprintf(HotZone, strconv.Itoa(index)), ids)
+	}
+	_, err = tx.Exec(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "SetHotList replace fail")
+	}
+	return
+}
+
+// LockHotScore 获取计算热门的锁 获取失败说明其他服务正在计算
+func LockHotScore(expire time.Duration) (ok bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	ok, err = Rdb.SetNX(ctx, HotScoreLock, 1, expire).Result()
+	if err != nil {
+		err = errors.Wrap(err, "LockHotScore fail")
+	}
+	return
+}
@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	m "onpaper-api-go/models"
+	"strconv"
+	"time"
+)
+
+// SetPollVote 投票计数 没有缓存时先用数据库的票数初始化 返回投票后的统计
+func SetPollVote(trendId string, poll m.TrendPoll, choices []int) (count map[string]string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	key := fmt.Sprintf(TrendPoll, trendId)
+	pipe := Rdb.TxPipeline()
+	// 已经有缓存时 HSetNX 不会覆盖
+	for i, option := range poll.Options {
+		pipe.HSetNX(ctx, key, strconv.Itoa(i), option.Votes)
+	}
+	pipe.HSetNX(ctx, key, "Voters", poll.Voters)
+	for _, choice := range choices {
+		pipe.HIncrBy(ctx, key, strconv.Itoa(choice), 1)
+	}
+	pipe.HIncrBy(ctx, key, "Voters", 1)
+	// 结束后不会再有投票 落盘后缓存可以过期
+	pipe.ExpireAt(ctx, key, poll.EndAt.Add(3*24*time.Hour))
+	pipe.SAdd(ctx, TrendPollDirty, trendId)
+	countCmd := pipe.HGetAll(ctx, key)
+
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "SetPollVote fail")
+		return
+	}
+	return countCmd.Val(), nil
+}
+
+// GetPollCount 批量获取投票统计
+func GetPollCount(trendIds []string) (data map[int64]map[string]string, err error) {
+	if len(trendIds) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(trendIds))
+	for _, id := range trendIds {
+		keys = append(keys, fmt.Sprintf(TrendPoll, id))
+	}
+	data, _, err = BatchGetTypeOfHash(keys)
+	if err != nil {
+		err = errors.Wrap(err, "GetPollCount fail")
+	}
+	return
+}
+
+// PopDirtyPoll 取出需要落盘的投票
+func PopDirtyPoll(count int64) (trendIds []string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	trendIds, err = Rdb.SPopN(ctx, TrendPollDirty, count).Result()
+	if err != nil {
+		err = errors.Wrap(err, "PopDirtyPoll fail")
+	}
+	return
+}
+
+// AddDirtyPoll 落盘失败时放回去 下次再落盘
+func AddDirtyPoll(trendIds []string) (err error) {
+	if len(trendIds) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	members := make([]interface{}, 0, len(trendIds))
+	for _, id := range trendIds {
+		members = append(members, id)
+	}
+	err = Rdb.SAdd(ctx, TrendPollDirty, members...).Err()
+	if err != nil {
+		err = errors.Wrap(err, "AddDirtyPoll fail")
+	}
+	return
+}
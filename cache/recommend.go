@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v9"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// SetRecommendList 批量保存用户的推荐列表 覆盖旧的推荐
+// fmtKey 为 RecommendArtwork 或 RecommendUser
+func SetRecommendList(fmtKey string, data map[string]map[string]float64, expire time.Duration) (err error) {
+	if len(data) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	pipe := Rdb.Pipeline()
+	for userId, list := range data {
+		key := fmt.Sprintf(fmtKey, userId)
+		pipe.Del(ctx, key)
+		if len(list) == 0 {
+			continue
+		}
+		members := make([]redis.Z, 0, len(list))
+		for id, score := range list {
+			members = append(members, redis.Z{Score: score, Member: id})
+		}
+		pipe.ZAdd(ctx, key, members...)
+		pipe.Expire(ctx, key, expire)
+	}
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "SetRecommendList fail")
+	}
+	return
+}
+
+// GetRecommendArtId 按分页获取用户的推荐作品id total 为推荐作品总数
+func GetRecommendArtId(userId string, page, size int64) (artIds []string, total int64, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	key := fmt.Sprintf(RecommendArtwork, userId)
+	pipe := Rdb.Pipeline()
+	idsCmd := pipe.ZRevRange(ctx, key, page*size, (page+1)*size-1)
+	totalCmd := pipe.ZCard(ctx, key)
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "GetRecommendArtId fail")
+		return
+	}
+	return idsCmd.Val(), totalCmd.Val(), nil
+}
+
+// GetRecommendUserId 获取用户的推荐用户id 去掉推荐生成后已经关注的用户
+func GetRecommendUserId(loginId string) (userIds []string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	key := fmt.Sprintf(RecommendUser, loginId)
+	ids, err := Rdb.ZRevRange(ctx, key, 0, -1).Result()
+	if err != nil || len(ids) == 0 {
+		err = errors.Wrap(err, "GetRecommendUserId fail")
+		return
+	}
+
+	members := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		members = append(members, id)
+	}
+	isFocus, err := Rdb.SMIsMember(ctx, fmt.Sprintf(UserFollow, loginId), members...).Result()
+	if err != nil {
+		err = errors.Wrap(err, "GetRecommendUserId SMIsMember fail")
+		return
+	}
+	for i, id := range ids {
+		if !isFocus[i] {
+			userIds = append(userIds, id)
+		}
+	}
+	return
+}
+
+// LockRecommendBuild 获取计算推荐的锁 获取失败说明其他服务正在计算
+func LockRecommendBuild(expire time.Duration) (ok bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	ok, err = Rdb.SetNX(ctx, RecommendLock, 1, expire).Result()
+	if err != nil {
+		err = errors.Wrap(err, "LockRecommendBuild fail")
+	}
+	return
+}
@@ -3,6 +3,7 @@ package cache
 import (
 	"context"
 	"fmt"
+	"github.com/go-redis/redis/v9"
 	"github.com/pkg/errors"
 	m "onpaper-api-go/models"
 	"time"
@@ -81,3 +82,46 @@ func SetTopicDetail(detail m.TopicDetail) (err error) {
 	}
 	return
 }
+
+// DelTagCache 删除标签相关的缓存 合并或修改标签关系后调用
+func DelTagCache(tagIds []string) (err error) {
+	keys := []string{fmt.Sprintf(RankTag, "hours"), RankTopUseTag}
+	for _, tagId := range tagIds {
+		keys = append(keys, fmt.Sprintf(TagRelevant, tagId), fmt.Sprintf(TagUser, tagId))
+		// tag 作品按分页和筛选条件缓存
+		err = DelCacheByPattern(fmt.Sprintf(TagArtworkAndPage, tagId, "*"))
+		if err != nil {
+			err = errors.Wrap(err, "DelTagCache fail")
+			return
+		}
+	}
+	err = BatchDelCache(keys)
+	if err != nil {
+		err = errors.Wrap(err, "DelTagCache fail")
+	}
+	return
+}
+
+// MergeHotTag 把旧标签的热度转到新标签
+func MergeHotTag(fromName, toName string) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	score, err := Rdb.ZScore(ctx, RankTagHot, fromName).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil
+		}
+		err = errors.Wrap(err, "MergeHotTag ZScore fail")
+		return
+	}
+
+	pipe := Rdb.TxPipeline()
+	pipe.ZIncrBy(ctx, RankTagHot, score, toName)
+	pipe.ZRem(ctx, RankTagHot, fromName)
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		err = errors.Wrap(err, "MergeHotTag fail")
+	}
+	return
+}
@@ -67,24 +67,28 @@ func SetTrendCount(trendData []m.TrendShowInfo) (err error) {
 	return
 }
 
-// SetTrendForwards 设置转发数
+// SetTrendForwards 设置转发数 没有统计缓存时先添加缓存 再 + 1
 func SetTrendForwards(info m.ForwardInfo) (err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
 	var key string
 	strId := strconv.FormatInt(info.Id, 10)
 	if info.Type == "aw" {
 		key = fmt.Sprintf(ArtworkCount, strId)
+		err = CheckArtworkCount(strId)
 	} else {
 		key = fmt.Sprintf(TrendCount, strId)
+		err = CheckTrendCount(strId)
 	}
-	isExists, err := Rdb.Exists(ctx, key).Result()
-	// 存在则 + 1
-	if isExists == 1 {
-		_, err = Rdb.HIncrBy(ctx, key, "Forwards", 1).Result()
+	if err != nil {
+		return
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err = Rdb.HIncrBy(ctx, key, "Forwards", 1).Result()
+	if err != nil {
+		err = errors.Wrap(err, "SetTrendForwards HIncrBy fail")
+	}
 	return
 }
 
@@ -311,3 +311,24 @@ func CheckZSortLen(key string, maxSize, removeCount int64) (err error) {
 	}
 	return
 }
+
+// DelCacheByPattern 按匹配规则删除缓存 使用 scan 避免阻塞
+func DelCacheByPattern(pattern string) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var keys []string
+	iter := Rdb.Scan(ctx, 0, pattern, 200).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	err = iter.Err()
+	if err != nil {
+		err = errors.Wrap(err, fmt.Sprintf("DelCacheByPattern scan fail %s", pattern))
+		return
+	}
+	if len(keys) == 0 {
+		return
+	}
+	return BatchDelCache(keys)
+}
@@ -0,0 +1,38 @@
+// artworkpublish 补全作品第一次公开的时间 审核通过只在第一次公开时推送feed 上线时运行一次
+// 在项目根目录运行 读取 ./config.yaml
+//
+//	go run ./cmd/artworkpublish
+package main
+
+import (
+	"fmt"
+	"onpaper-api-go/app"
+	"onpaper-api-go/cache"
+	"onpaper-api-go/dao/mongo"
+	"onpaper-api-go/dao/mysql"
+	"os"
+)
+
+func main() {
+	// 初始化配置和数据库链接
+	if app.Init() == nil {
+		os.Exit(1)
+	}
+	defer mysql.Close()
+	defer cache.Close()
+	defer mongo.Close()
+
+	// 修改过图片的作品 之前已经公开过 现在可能在重新审核
+	revisedIds, err := mongo.GetRevisedArtworkIds()
+	if err != nil {
+		fmt.Printf("GetRevisedArtworkIds fail: %+v\n", err)
+		return
+	}
+
+	count, err := mysql.BackfillArtworkPublish(revisedIds)
+	if err != nil {
+		fmt.Printf("BackfillArtworkPublish fail: %+v\n", err)
+		return
+	}
+	fmt.Printf("BackfillArtworkPublish done: %d\n", count)
+}
@@ -0,0 +1,31 @@
+// commentlikes 按点赞记录重新统计评论的点赞数 上线按热度排序评论时运行一次
+// 在项目根目录运行 读取 ./config.yaml
+//
+//	go run ./cmd/commentlikes
+package main
+
+import (
+	"fmt"
+	"onpaper-api-go/app"
+	"onpaper-api-go/cache"
+	"onpaper-api-go/dao/mongo"
+	"onpaper-api-go/dao/mysql"
+	"os"
+)
+
+func main() {
+	// 初始化配置和数据库链接
+	if app.Init() == nil {
+		os.Exit(1)
+	}
+	defer mysql.Close()
+	defer cache.Close()
+	defer mongo.Close()
+
+	count, err := mongo.BackfillCommentLikes()
+	if err != nil {
+		fmt.Printf("BackfillCommentLikes fail: %+v\n", err)
+		return
+	}
+	fmt.Printf("BackfillCommentLikes done: %d\n", count)
+}
@@ -0,0 +1,37 @@
+// feedbackfill 补全作品和动态的发布记录 切换到推拉结合的feed时运行一次
+// 在项目根目录运行 读取 ./config.yaml
+//
+//	go run ./cmd/feedbackfill           补全发布记录
+//	go run ./cmd/feedbackfill -prune    补全后删除已经推送给大用户粉丝的feed
+package main
+
+import (
+	"flag"
+	"fmt"
+	"onpaper-api-go/app"
+	"onpaper-api-go/cache"
+	ctl "onpaper-api-go/controller"
+	"onpaper-api-go/dao/mongo"
+	"onpaper-api-go/dao/mysql"
+	"os"
+)
+
+func main() {
+	prune := flag.Bool("prune", false, "删除已经推送给大用户粉丝的feed")
+	flag.Parse()
+
+	// 初始化配置和数据库链接
+	if app.Init() == nil {
+		os.Exit(1)
+	}
+	defer mysql.Close()
+	defer cache.Close()
+	defer mongo.Close()
+
+	err := ctl.BackfillFeedOutbox(*prune)
+	if err != nil {
+		fmt.Printf("BackfillFeedOutbox fail: %+v\n", err)
+		return
+	}
+	fmt.Println("BackfillFeedOutbox done")
+}
@@ -0,0 +1,174 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	c "onpaper-api-go/cache"
+	"onpaper-api-go/dao/mysql"
+	"onpaper-api-go/logger"
+	m "onpaper-api-go/models"
+	"time"
+)
+
+// contentViewer 查看作品的用户 决定敏感内容如何返回
+type contentViewer struct {
+	UserId    string
+	AdultPref string
+}
+
+// getContentViewer 获取当前请求的查看者 游客一律隐藏敏感内容
+func getContentViewer(ctx *gin.Context) (viewer contentViewer) {
+	ctxData, _ := ctx.Get("userInfo")
+	userInfo, _ := ctxData.(m.UserTokenPayload)
+
+	viewer.UserId = userInfo.Id
+	viewer.AdultPref = m.AdultPrefHide
+	if userInfo.Id == "" {
+		return
+	}
+
+	pref, err := GetUserAdultPref(userInfo.Id)
+	if err != nil {
+		// 出错按隐藏处理
+		logger.ErrZapLog(err, userInfo.Id)
+		return
+	}
+	viewer.AdultPref = pref
+	return
+}
+
+// GetUserAdultPref 获取用户实际生效的敏感内容偏好 先查缓存
+func GetUserAdultPref(userId string) (pref string, err error) {
+	key := fmt.Sprintf(c.AdultPref, userId)
+	cache, err := c.GetOneStringValue(key, "")
+	if err != nil {
+		logger.ErrZapLog(err, key)
+	}
+	if cache.HaveCache {
+		pref = cache.Val.(string)
+		return
+	}
+
+	setting, err := mysql.GetUserAdultSetting(userId)
+	if err != nil {
+		err = errors.Wrap(err, "GetUserAdultPref fail")
+		return
+	}
+	pref = setting.Effective(time.Now())
+
+	// 缓存一天 生日跨过成年的当天最多晚一天生效
+	err = c.SetOneStringValue(key, pref, 24*time.Hour)
+	if err != nil {
+		logger.ErrZapLog(err, key)
+		err = nil
+	}
+	return
+}
+
+// hideAdultPicture 是否需要对该查看者隐藏作品图片 作者本人不隐藏
+func (v contentViewer) hideAdultPicture(adults bool, authorId string) bool {
+	return adults && v.AdultPref == m.AdultPrefHide && v.UserId != authorId
+}
+
+// maskAdultArt 清空需要隐藏的敏感作品的图片地址 保留 adults 标记给客户端显示占位
+func maskAdultArt(artData []m.BasicArtwork, viewer contentViewer) {
+	for i, art := range artData {
+		if viewer.hideAdultPicture(art.Adults, art.UserId) {
+			artData[i].Cover = ""
+			artData[i].FirstPic = ""
+		}
+	}
+}
+
+// maskAdultRank 返回清空了敏感作品图片的排行副本 原数据需要写入缓存
+func maskAdultRank(rank []m.ArtworkRank, viewer contentViewer) []m.ArtworkRank {
+	res := make([]m.ArtworkRank, len(rank))
+	copy(res, rank)
+	for i, art := range res {
+		if viewer.hideAdultPicture(art.Adults, art.UserId) {
+			res[i].Cover = ""
+		}
+	}
+	return res
+}
+
+// maskAdultOtherArt 返回清空了敏感作品封面的作者其他作品副本
+func maskAdultOtherArt(others []m.AuthorOtherArtwork, authorId string, viewer contentViewer) []m.AuthorOtherArtwork {
+	res := make([]m.AuthorOtherArtwork, len(others))
+	copy(res, others)
+	for i, art := range res {
+		if viewer.hideAdultPicture(art.Adults, authorId) {
+			res[i].Cover = ""
+		}
+	}
+	return res
+}
+
+// maskAdultTrend 清空需要隐藏的敏感作品动态的图片 转发是指针 替换成副本避免改到缓存数据
+func maskAdultTrend(trends m.TrendList, viewer contentViewer) {
+	for i, trend := range trends {
+		if trend.Type == "aw" && viewer.hideAdultPicture(trend.Adults, trend.UserId) {
+			trends[i].Pics = nil
+		}
+		f := trend.Forward
+		if f != nil && f.Type == "aw" && viewer.hideAdultPicture(f.Adults, f.UserId) {
+			temp := *f
+			temp.Pics = nil
+			trends[i].Forward = &temp
+		}
+	}
+}
+
+// GetAdultSetting 获取敏感内容设置
+func GetAdultSetting(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("userInfo")
+	userInfo := ctxData.(m.UserTokenPayload)
+
+	setting, err := mysql.GetUserAdultSetting(userInfo.Id)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	now := time.Now()
+	ResponseSuccess(ctx, gin.H{
+		"pref":      setting.Pref,
+		"effective": setting.Effective(now),
+		"isAdult":   setting.IsAdult(now),
+	})
+}
+
+// UpdateAdultSetting 修改敏感内容偏好 需要生日已满18岁
+func UpdateAdultSetting(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("userInfo")
+	userInfo := ctxData.(m.UserTokenPayload)
+
+	ctxData, _ = ctx.Get("adultPref")
+	data := ctxData.(m.UpdateAdultPref)
+
+	setting, err := mysql.GetUserAdultSetting(userInfo.Id)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+	// 隐藏总是允许的 其他选项需要先填写成年的生日
+	if data.Pref != m.AdultPrefHide && !setting.IsAdult(time.Now()) {
+		ResponseError(ctx, CodeNeedAdult)
+		return
+	}
+
+	err = mysql.UpdateUserAdultPref(data.Pref, userInfo.Id)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	err = c.DelOneCache(fmt.Sprintf(c.AdultPref, userInfo.Id))
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	ResponseSuccess(ctx, data)
+}
@@ -0,0 +1,201 @@
+package controller
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/go-redis/redis/v9"
+	"github.com/pkg/errors"
+	mongodb "go.mongodb.org/mongo-driver/mongo"
+	"onpaper-api-go/cache"
+	"onpaper-api-go/dao/mongo"
+	"onpaper-api-go/dao/mysql"
+	"onpaper-api-go/logger"
+	m "onpaper-api-go/models"
+	"onpaper-api-go/settings"
+	"strconv"
+	"time"
+)
+
+// analyzeBlock 读取压缩流时每次等待新消息的时间
+const analyzeBlock = 5 * time.Second
+
+// RunPictureAnalyzer 读取图片压缩流 计算作品新图片的哈希和主色并查重 在服务启动时运行
+// 和图片服务器使用不同的消费组 每条消息两边都会收到 处理失败的消息不确认 下次启动时重新处理
+func RunPictureAnalyzer() {
+	for {
+		err := cache.CreateAnalyzeGroup()
+		if err == nil {
+			break
+		}
+		logger.ErrZapLog(err, "RunPictureAnalyzer CreateAnalyzeGroup fail")
+		time.Sleep(time.Minute)
+	}
+
+	consumer := fmt.Sprintf("api-%d", settings.Conf.MachineId)
+	// 先处理上次读取后没有确认的消息 处理完再读取新消息
+	start := "0"
+	for {
+		msgs, err := cache.ReadAnalyzeQueue(consumer, start, analyzeBlock)
+		if err != nil {
+			logger.ErrZapLog(err, "RunPictureAnalyzer ReadAnalyzeQueue fail")
+			time.Sleep(analyzeBlock)
+			continue
+		}
+		if start != ">" {
+			if len(msgs) == 0 {
+				start = ">"
+				continue
+			}
+			start = msgs[len(msgs)-1].ID
+		}
+
+		for _, msg := range msgs {
+			if !handleAnalyzeMessage(msg) {
+				continue
+			}
+			err = cache.AckAnalyzeQueue(msg.ID)
+			if err != nil {
+				logger.ErrZapLog(err, msg.ID)
+			}
+		}
+	}
+}
+
+// handleAnalyzeMessage 处理一条压缩消息 只处理作品 返回是否可以确认
+func handleAnalyzeMessage(msg redis.XMessage) (done bool) {
+	// 出现 panic 不能让任务退出 消息不确认
+	defer func() {
+		if p := recover(); p != nil {
+			logger.ErrZapLog(fmt.Errorf("%v", p), "handleAnalyzeMessage panic "+msg.ID)
+			done = false
+		}
+	}()
+
+	if fmt.Sprint(msg.Values["type"]) != "aw" {
+		return true
+	}
+	userId := fmt.Sprint(msg.Values["uid"])
+	artworkId, err := strconv.ParseInt(fmt.Sprint(msg.Values["mid"]), 10, 64)
+	if err != nil {
+		logger.ErrZapLog(err, msg.Values)
+		return true
+	}
+
+	err = analyzeArtwork(artworkId, userId)
+	if err != nil {
+		logger.ErrZapLog(err, artworkId)
+		return false
+	}
+	return true
+}
+
+// analyzeArtwork 计算作品还没有哈希的图片 保存哈希和主色 查找相似的作品
+// 与已下架作品相同时下架 与其他作品相似时加入审核列表 都没有时公开等待检查的作品
+// 第一次公开的作品推送到粉丝的feed
+func analyzeArtwork(artworkId int64, userId string) (err error) {
+	state, err := mysql.GetArtworkState(artworkId)
+	if err != nil {
+		// 作品已经删除
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		err = errors.Wrap(err, "analyzeArtwork GetArtworkState fail")
+		return
+	}
+
+	fileNames, err := mysql.GetArtworkFileNames(artworkId)
+	if err != nil {
+		return
+	}
+	hashed, err := mongo.GetPictureHashFiles(artworkId)
+	if err != nil {
+		return
+	}
+	hashedMap := make(map[string]bool, len(hashed))
+	for _, name := range hashed {
+		hashedMap[name] = true
+	}
+	var newFiles []*m.PicsType
+	for _, name := range fileNames {
+		if !hashedMap[name] {
+			newFiles = append(newFiles, &m.PicsType{FileName: name})
+		}
+	}
+
+	// 已经检查过的作品 只是重新压缩
+	if len(newFiles) == 0 && state != m.ArtStateReview {
+		return
+	}
+
+	hashes, err := analyzePictures(userId, newFiles)
+	if err != nil {
+		return
+	}
+	err = mysql.SaveArtworkColors(artworkId, newFiles)
+	if err != nil {
+		return
+	}
+
+	strId := strconv.FormatInt(artworkId, 10)
+	info := &m.SaveArtworkInfo{ArtworkId: artworkId, UserId: userId}
+	err = matchSimilarArtwork(info, hashes)
+	if err == errSimilarTakenDown {
+		err = savePictureHashes(artworkId, userId, hashes)
+		if err != nil {
+			return
+		}
+		_, err = mysql.SetArtworkState(artworkId, m.ArtStateTakenDown)
+		if err != nil {
+			return
+		}
+		return delArtworkCache(strId, userId)
+	}
+	if err != nil {
+		return
+	}
+	err = saveArtworkHashes(info, hashes)
+	if err != nil {
+		return
+	}
+
+	if info.State == m.ArtStateReview {
+		if state != m.ArtStateNormal {
+			return
+		}
+		_, err = mysql.SetArtworkState(artworkId, m.ArtStateReview)
+		if err != nil {
+			return
+		}
+		return delArtworkCache(strId, userId)
+	}
+
+	// 已下架的作品不变 还有等待管理员审核的记录时继续等待
+	if state != m.ArtStateReview {
+		return
+	}
+	review, err := mongo.GetArtworkReview(artworkId)
+	if err == nil && review.Status == 0 {
+		return
+	}
+	if err != nil && err != mongodb.ErrNoDocuments {
+		err = errors.Wrap(err, "analyzeArtwork GetArtworkReview fail")
+		return
+	}
+
+	first, err := mysql.SetArtworkState(artworkId, m.ArtStateNormal)
+	if err != nil {
+		return
+	}
+	err = delArtworkCache(strId, userId)
+	if err != nil {
+		logger.ErrZapLog(err, artworkId)
+	}
+	if first {
+		fanOutFeed(m.UploadArtOrTrend{
+			MsgID:  artworkId,
+			SendId: userId,
+			Type:   "aw",
+		})
+	}
+	return nil
+}
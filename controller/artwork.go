@@ -67,11 +67,17 @@ func GetOneArtworkInfo(ctx *gin.Context) {
 
 	// 是否是本人的作品
 	isOwner := artInfo.UserId == userInfo.Id
-	//如果是私密作品不是本人查看 返回没有作品
-	if artInfo.WhoSee == "privacy" && !isOwner {
+	//如果是私密作品或者审核中、已下架的作品 不是本人查看 返回没有作品
+	if (artInfo.WhoSee == "privacy" || artInfo.State != m.ArtStateNormal) && !isOwner {
 		ResponseError(ctx, CodeArtworkNoExists)
 		return
 	}
+	// 游客、未成年和选择隐藏的用户 不返回敏感作品
+	viewer := getContentViewer(ctx)
+	if viewer.hideAdultPicture(artInfo.Adults, artInfo.UserId) {
+		ResponseError(ctx, CodeAdultContent)
+		return
+	}
 
 	//合成作品点赞收藏数据,没有缓存会从数据库查到 就不用合成
 	if countCtx.HaveCache {
@@ -105,8 +111,10 @@ func GetOneArtworkInfo(ctx *gin.Context) {
 	}
 	artInfo.Interact = *interact
 	artInfo.IsOwner = isOwner
-	// 返回数据
-	ResponseSuccess(ctx, artInfo)
+	// 返回数据 作者的其他作品需要另外隐藏 缓存的仍是完整数据
+	res := artInfo
+	res.OtherArtwork = maskAdultOtherArt(artInfo.OtherArtwork, artInfo.UserId, viewer)
+	ResponseSuccess(ctx, res)
 	//传递给设置缓存
 	ctx.Set("artInfo", artInfo)
 }
@@ -166,6 +174,12 @@ func SaveCollect(ctx *gin.Context) {
 		return
 	}
 
+	// 同步到收藏夹
+	err = mongo.SyncCollectFolder(userInfo.Id, *collectData)
+	if err != nil {
+		logger.ErrZapLog(err, "SaveCollect SyncCollectFolder fail")
+	}
+
 	// 返回数据
 	ResponseSuccess(ctx, collectData)
 }
@@ -212,8 +226,9 @@ func GetArtworkRank(ctx *gin.Context) {
 	// 获取缓存数据
 	dataCtx, _ := ctx.Get("rankData")
 	rankData, _ := dataCtx.(cache.CtxCacheVale)
+	viewer := getContentViewer(ctx)
 	if rankData.HaveCache {
-		ResponseSuccess(ctx, rankData.Val.([]m.ArtworkRank))
+		ResponseSuccess(ctx, maskAdultRank(rankData.Val.([]m.ArtworkRank), viewer))
 		ctx.Abort()
 		return
 	}
@@ -258,7 +273,7 @@ func GetArtworkRank(ctx *gin.Context) {
 
 	// 返回数据
 	artData := res.([]m.ArtworkRank)
-	ResponseSuccess(ctx, artData)
+	ResponseSuccess(ctx, maskAdultRank(artData, viewer))
 	ctx.Set("artworkRank", artData)
 }
 
@@ -287,6 +302,15 @@ func GetHotArtwork(ctx *gin.Context) {
 		}
 	}
 
+	// 热门数据每次从缓存反序列化 直接清空即可
+	viewer := getContentViewer(ctx)
+	for i, art := range artData {
+		if viewer.hideAdultPicture(art.Adults, art.UserId) {
+			artData[i].Cover = ""
+			artData[i].FirstPic = ""
+		}
+	}
+
 	// 返回数据
 	ResponseSuccess(ctx, artData)
 
@@ -311,7 +335,7 @@ func GetChannelArtwork(ctx *gin.Context) {
 		userIds = append(userIds, data.AuthorId)
 	}
 
-	artData, findData, err := BatchGetBasicArtInfo(artIds, userIds)
+	artData, findData, err := BatchGetBasicArtInfo(artIds, userIds, getContentViewer(ctx))
 	if err != nil {
 		err = errors.Wrap(err, "GetArtFeed fail")
 		ResponseErrorAndLog(ctx, CodeServerBusy, err)
@@ -334,28 +358,27 @@ func UpdateArtInfo(ctx *gin.Context) {
 	ctxData, _ = ctx.Get("userInfo")
 	userInfo, _ := ctxData.(m.UserTokenPayload)
 
-	err := mysql.UpdateArtInfo(artInfo)
+	ctxData, _ = ctx.Get("artSnapshot")
+	before := ctxData.(m.ArtworkSnapshot)
+
+	tags, err := mysql.CanonicalTags(artInfo.Tags)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+	artInfo.Tags = tags
+
+	err = mysql.UpdateArtInfo(artInfo)
 	if err != nil {
 		err = errors.Wrap(err, "UpdateArtInfo 更新错误")
 		ResponseErrorAndLog(ctx, CodeServerBusy, err)
 		return
 	}
 
-	var keys []string
-	//删除 作品封面 缓存
-	artBasicsKey := fmt.Sprintf(cache.ArtworkBasic, artInfo.ArtworkId)
-	keys = append(keys, artBasicsKey)
-	//删除作品详情
-	artProfileKey := fmt.Sprintf(cache.ArtworkProfile, artInfo.ArtworkId)
-	keys = append(keys, artProfileKey)
-	// 删除动态缓存
-	trendProfileKey := fmt.Sprintf(cache.TrendProfile, artInfo.ArtworkId)
-	keys = append(keys, trendProfileKey)
-	// 用户大卡片资料
-	userBigCardKey := fmt.Sprintf(cache.UserBigCard, userInfo.Id)
-	keys = append(keys, userBigCardKey)
+	// 保存修改记录
+	saveArtworkRevision(artInfo.ArtworkId, userInfo.Id, before, artInfo.ShowUpdate)
 
-	err = cache.BatchDelCache(keys)
+	err = delArtworkCache(artInfo.ArtworkId, userInfo.Id)
 	if err != nil {
 		ResponseErrorAndLog(ctx, CodeServerBusy, err)
 		return
@@ -381,6 +404,26 @@ func UpdateArtInfo(ctx *gin.Context) {
 	return
 }
 
+// delArtworkCache 作品修改后 删除作品相关缓存
+func delArtworkCache(artworkId, userId string) (err error) {
+	var keys []string
+	//删除 作品封面 缓存
+	artBasicsKey := fmt.Sprintf(cache.ArtworkBasic, artworkId)
+	keys = append(keys, artBasicsKey)
+	//删除作品详情
+	artProfileKey := fmt.Sprintf(cache.ArtworkProfile, artworkId)
+	keys = append(keys, artProfileKey)
+	// 删除动态缓存
+	trendProfileKey := fmt.Sprintf(cache.TrendProfile, artworkId)
+	keys = append(keys, trendProfileKey)
+	// 用户大卡片资料
+	userBigCardKey := fmt.Sprintf(cache.UserBigCard, userId)
+	keys = append(keys, userBigCardKey)
+
+	err = cache.BatchDelCache(keys)
+	return
+}
+
 // DeleteArtwork 删除作品
 func DeleteArtwork(ctx *gin.Context) {
 	ctxData, _ := ctx.Get("artworkId")
@@ -416,6 +459,12 @@ func DeleteArtwork(ctx *gin.Context) {
 		ResponseErrorAndLog(ctx, CodeServerBusy, err)
 		return
 	}
+	err = mongo.DeleteOutboxFeed(intArtId, userInfo.Id)
+	if err != nil {
+		err = errors.Wrap(err, "DeleteArtwork mongo 删除错误")
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
 
 	err = cache.DeleteAboutArt(userInfo.Id, artId)
 	if err != nil {
@@ -438,10 +487,10 @@ func GetHomePageZone(ctx *gin.Context) {
 		//数据库错误
 		ResponseErrorAndLog(ctx, CodeServerBusy, err)
 	}
+	maskAdultArt(artData, getContentViewer(ctx))
 	// 返回数据
-	zoneList := []string{"插画", "同人", "古风", "日系", "场景", "原画", "头像", "Q版", "自设/OC"}
 	ResponseSuccess(ctx, gin.H{
-		"title":   zoneList[zoneIndex],
+		"title":   m.HomeZoneList[zoneIndex],
 		"artwork": artData,
 	})
 
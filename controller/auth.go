@@ -114,7 +114,7 @@ func ReturnSTSData(ctx *gin.Context) {
 
 	// 验证类型 符号规范
 	typeVerify := false
-	typeList := []string{"avatars", "banners", "artworks", "trends", "messages", "commission"}
+	typeList := []string{"avatars", "banners", "artworks", "trends", "messages", "commission", "dispute"}
 	for _, s := range typeList {
 		if stsType == s {
 			typeVerify = true
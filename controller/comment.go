@@ -17,19 +17,41 @@ import (
 	"time"
 )
 
-// GetRootComment 获取作品评论信息
+// GetRootComment 获取作品评论信息 第一页带上置顶的评论
 func GetRootComment(ctx *gin.Context) {
 	// 1. 获取缓存的数据
 	dataCtx, _ := ctx.Get("userInfo")
 	loginUser, _ := dataCtx.(m.UserTokenPayload)
 
+	dataCtx, _ = ctx.Get("queryData")
+	queryData := dataCtx.(m.VerifyGetCommentQuery)
+
 	dataCtx, _ = ctx.Get("comment")
 	commentCtx := dataCtx.(cache.CtxCacheVale)
 
+	// 置顶的评论不在评论列表中 只在第一页返回
+	var showComment m.ReturnComments
+	if queryData.LastCid == "0" {
+		topComment, err := getTopComment(queryData.OwnId)
+		if err != nil {
+			logger.ErrZapLog(err, "GetRootComment getTopComment fail")
+		}
+		if topComment != nil {
+			showComment = append(showComment, *topComment)
+		}
+	}
+
 	dataCtx, exi := ctx.Get("noComment")
 	noComment := dataCtx.(bool)
 	// 如果没有评论
 	if noComment && exi {
+		// 只有置顶的评论
+		if len(showComment) != 0 {
+			fillCommentLike(showComment, loginUser.Id)
+			ResponseSuccess(ctx, showComment)
+			ctx.Abort()
+			return
+		}
 		// 返回数据
 		ResponseError(ctx, CodeCommentNoHave)
 		return
@@ -43,19 +65,47 @@ func GetRootComment(ctx *gin.Context) {
 		ctx.Abort()
 	} else {
 		//2. 没有缓存到数据库查询
-		data, _ := ctx.Get("queryData")
-		queryData := data.(m.VerifyGetCommentQuery)
-
-		rootComment, childComment, findUser, err := mongo.GetRootComment(queryData.OwnId, queryData.LastCid)
+		var rootComment []m.ReturnComment
+		var childComment []m.FirstShowChildComment
+		var findUser []string
+		var err error
+		if queryData.Sort == "hot" {
+			// 按热度翻页 sore 是上一页最后一条的热度
+			lastCid, _ := strconv.ParseInt(queryData.LastCid, 10, 64)
+			lastHot, _ := strconv.Atoi(queryData.Sore)
+			rootComment, childComment, findUser, err = mongo.GetHotRootComment(queryData.OwnId, lastCid, lastHot)
+		} else {
+			rootComment, childComment, findUser, err = mongo.GetRootComment(queryData.OwnId, queryData.LastCid)
+		}
 		if err != nil {
 			ResponseErrorAndLog(ctx, CodeServerBusy, err)
 			return
 		}
+
+		// 第一页 重新生成热度排序快照
+		if queryData.Sort == "hot" && queryData.LastCid == "0" {
+			ranks, _err := mongo.GetHotRootCommentRank(queryData.OwnId, cache.CommentHotSnapshot)
+			if _err != nil {
+				logger.ErrZapLog(_err, "GetRootComment GetHotRootCommentRank fail")
+			} else {
+				if ranks == nil {
+					ranks = make([]m.CommentHotRank, 0)
+				}
+				ctx.Set("hotRank", ranks)
+			}
+		}
+
 		// 如果没有评论
 		if rootComment == nil {
 			//传送给缓存
 			ctx.Set("rootComment", make(m.ReturnComments, 0))
 			ctx.Next()
+			// 只有置顶的评论
+			if len(showComment) != 0 {
+				fillCommentLike(showComment, loginUser.Id)
+				ResponseSuccess(ctx, showComment)
+				return
+			}
 			// 返回数据
 			ResponseError(ctx, CodeCommentNoHave)
 			return
@@ -67,51 +117,73 @@ func GetRootComment(ctx *gin.Context) {
 			ResponseErrorAndLog(ctx, CodeServerBusy, err)
 			return
 		}
+		formatRootComment(rootComment, childComment, userMap)
 
-		normTime, _ := time.ParseInLocation("2006-01-02", "2005-01-01", time.Local)
-		//将评论内容和 用户信息赋值给 查询返回的数据
-		for ic, comment := range rootComment {
-			// 时间相减为 redis zSet 的 sore 减少数字量级
-			rootComment[ic].Sore = comment.CreateAT.Unix() - normTime.Unix()
-			rootComment[ic].ChildComments = make([]m.Comment, 0)
+		returnComment = rootComment
+	}
 
-			user := userMap[comment.UserId]
-			rootComment[ic].UserName = user.UserName
-			rootComment[ic].Avatar = user.Avatar
-			rootComment[ic].VTag = user.VTag
-			rootComment[ic].VStatus = user.VStatus
-			if comment.IsDelete {
-				rootComment[ic].Text = "「该评论已删除」"
-			}
+	// 返回的数据和缓存的分开 热度排序时 sore 是热度
+	pageStart := len(showComment)
+	showComment = append(showComment, returnComment...)
+	if queryData.Sort == "hot" && !commentCtx.HaveCache {
+		for i := pageStart; i < len(showComment); i++ {
+			showComment[i].Sore = int64(showComment[i].HotScore())
 		}
+	}
+	fillCommentLike(showComment, loginUser.Id)
 
-		for _, item := range childComment {
-			for ic, comment := range item.Comment {
-				user := userMap[comment.UserId]
-				// 匹配发评论的用户信息
-				item.Comment[ic].UserName = user.UserName
-				item.Comment[ic].Avatar = user.Avatar
-				//匹配被回复评论的用户信息 把 userID 换成 用户名
-				item.Comment[ic].ReplyUserName = userMap[comment.ReplyUserId].UserName
-			}
+	// 返回数据
+	ResponseSuccess(ctx, showComment)
+	//传送给缓存
+	ctx.Set("rootComment", returnComment)
+}
+
+// formatRootComment 将评论内容和用户信息组合 子评论赋值到根评论里
+func formatRootComment(rootComment []m.ReturnComment, childComment []m.FirstShowChildComment, userMap map[string]m.UserSimpleInfo) {
+	normTime, _ := time.ParseInLocation("2006-01-02", "2005-01-01", time.Local)
+	//将评论内容和 用户信息赋值给 查询返回的数据
+	for ic, comment := range rootComment {
+		// 时间相减为 redis zSet 的 sore 减少数字量级
+		rootComment[ic].Sore = comment.CreateAT.Unix() - normTime.Unix()
+		rootComment[ic].ChildComments = make([]m.Comment, 0)
+
+		user := userMap[comment.UserId]
+		rootComment[ic].UserName = user.UserName
+		rootComment[ic].Avatar = user.Avatar
+		rootComment[ic].VTag = user.VTag
+		rootComment[ic].VStatus = user.VStatus
+		if comment.IsDelete {
+			rootComment[ic].Text = "「该评论已删除」"
 		}
+	}
 
-		//将子评论 赋值到 根评论里 形成解构树
-		for _, comment := range childComment {
-			for ir, root := range rootComment {
-				if comment.RootId == root.CId {
-					rootComment[ir].ChildComments = comment.Comment
-					break
-				}
-			}
+	for _, item := range childComment {
+		for ic, comment := range item.Comment {
+			user := userMap[comment.UserId]
+			// 匹配发评论的用户信息
+			item.Comment[ic].UserName = user.UserName
+			item.Comment[ic].Avatar = user.Avatar
+			//匹配被回复评论的用户信息 把 userID 换成 用户名
+			item.Comment[ic].ReplyUserName = userMap[comment.ReplyUserId].UserName
 		}
+	}
 
-		returnComment = rootComment
+	//将子评论 赋值到 根评论里 形成解构树
+	for _, comment := range childComment {
+		for ir, root := range rootComment {
+			if comment.RootId == root.CId {
+				rootComment[ir].ChildComments = comment.Comment
+				break
+			}
+		}
 	}
+}
 
+// fillCommentLike 填充根评论和子评论的点赞数 以及用户是否点赞过
+func fillCommentLike(returnComment m.ReturnComments, userId string) {
 	checkId := returnComment.GetAllCId()
 	// 检查是否点赞过评论
-	likeMap, err := cache.CheckUserLike(checkId, loginUser.Id)
+	likeMap, err := cache.CheckUserLike(checkId, userId)
 	if err != nil {
 		logger.ErrZapLog(err, "GetRootComment CheckUserLike fail ")
 	}
@@ -140,11 +212,47 @@ func GetRootComment(ctx *gin.Context) {
 			}
 		}
 	}
+}
 
-	// 返回数据
-	ResponseSuccess(ctx, returnComment)
-	//传送给缓存
-	ctx.Set("rootComment", returnComment)
+// getTopComment 获取作品或动态置顶的根评论 没有置顶返回 nil
+func getTopComment(ownId string) (top *m.ReturnComment, err error) {
+	cid, err := cache.GetTopCommentId(ownId)
+	if err == nil {
+		if cid == "0" {
+			return nil, nil
+		}
+		res, cErr := cache.GetOneComment(cid)
+		if cErr == nil {
+			var temp m.ReturnComment
+			if json.Unmarshal([]byte(res), &temp) == nil && temp.IsTop {
+				return &temp, nil
+			}
+		}
+	}
+
+	// 缓存没有 到数据库查询
+	rootComment, childComment, findUser, err := mongo.GetTopComment(ownId)
+	if err != nil {
+		return
+	}
+	if len(rootComment) == 0 {
+		err = cache.SetTopComment(ownId, nil)
+		return
+	}
+
+	userMap, err := mysql.GetBatchUserSimpleInfo(findUser)
+	if err != nil {
+		return
+	}
+	formatRootComment(rootComment, childComment, userMap)
+	top = &rootComment[0]
+
+	err = cache.SetTopComment(ownId, top)
+	if err != nil {
+		logger.ErrZapLog(err, ownId)
+		err = nil
+	}
+	return
 }
 
 // GetCommentReply 获取作品根评论中的所有子评论
@@ -354,6 +462,17 @@ func SaveCommentLike(ctx *gin.Context) {
 
 	// 如果没有实际更新(重复点赞） 不对缓存操作
 	if isChange {
+		// 点赞数保存到评论 用于热度排序
+		incr := 1
+		if likeData.IsCancel {
+			incr = -1
+		}
+		cid, _ := strconv.ParseInt(likeData.CId, 10, 64)
+		err = mongo.IncCommentLikes(cid, incr)
+		if err != nil {
+			logger.ErrZapLog(err, likeData)
+		}
+
 		err = cache.SetCommentLike(userInfo.Id, likeData)
 		if err != nil {
 			logger.ErrZapLog(err, likeData)
@@ -457,3 +576,151 @@ func DelComment(ctx *gin.Context) {
 		"status": "ok",
 	})
 }
+
+// EditComment 修改一条评论 只能修改自己发布不久的评论
+func EditComment(ctx *gin.Context) {
+	// 取出 ctx 传递的数据
+	ctxData, _ := ctx.Get("userInfo")
+	userInfo := ctxData.(m.UserTokenPayload)
+
+	ctxData, _ = ctx.Get("edit")
+	editData := ctxData.(m.EditComment)
+
+	comment, err := mongo.GetOneComment(editData.CId)
+	if err != nil {
+		if err == mongodb.ErrNoDocuments {
+			ResponseError(ctx, CodeCommentNoHave)
+			return
+		}
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	if comment.UserId != userInfo.Id || comment.IsDelete {
+		ResponseError(ctx, CodeUnPermission)
+		return
+	}
+	if time.Since(comment.CreateAT) > m.CommentEditTime {
+		ResponseError(ctx, CodeEditTimeout)
+		return
+	}
+
+	// 内容没有变化 不产生新版本
+	if comment.Text == editData.Text {
+		ResponseSuccess(ctx, comment)
+		return
+	}
+
+	err = mongo.EditOneComment(comment, editData.Text)
+	if err == mongodb.ErrNoDocuments {
+		ResponseError(ctx, CodeCommentNoHave)
+		return
+	}
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	err = cache.ResetCommentDetail(comment)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	comment.Text = editData.Text
+	comment.IsEdit = true
+	ResponseSuccess(ctx, comment)
+}
+
+// GetCommentHistory 获取评论的修改历史
+func GetCommentHistory(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("query")
+	query := ctxData.(m.QueryCommentHistory)
+
+	comment, err := mongo.GetOneComment(query.CId)
+	if err != nil {
+		if err == mongodb.ErrNoDocuments {
+			ResponseError(ctx, CodeCommentNoHave)
+			return
+		}
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+	// 删除的评论 不返回历史内容
+	if comment.IsDelete || !comment.IsEdit {
+		ResponseSuccess(ctx, make([]struct{}, 0))
+		return
+	}
+
+	history, err := mongo.GetCommentHistory(query.CId)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+	if len(history) == 0 {
+		ResponseSuccess(ctx, make([]struct{}, 0))
+		return
+	}
+
+	ResponseSuccess(ctx, history)
+}
+
+// SetCommentTop 作者置顶或取消置顶自己作品或动态下的根评论 每个最多置顶一条
+func SetCommentTop(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("userInfo")
+	userInfo := ctxData.(m.UserTokenPayload)
+
+	ctxData, _ = ctx.Get("top")
+	topData := ctxData.(m.PostCommentTop)
+
+	comment, err := mongo.GetOneComment(topData.CId)
+	if err != nil {
+		if err == mongodb.ErrNoDocuments {
+			ResponseError(ctx, CodeCommentNoHave)
+			return
+		}
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+	// 只能置顶根评论
+	if comment.RootId != 0 || comment.IsDelete {
+		ResponseError(ctx, CodeParamsError)
+		return
+	}
+
+	ownId, _ := strconv.ParseInt(comment.OwnId, 10, 64)
+	author, err := getTargetAuthor(m.ForwardInfo{Id: ownId, Type: comment.OwnType})
+	if err == errTargetNotFound {
+		ResponseError(ctx, CodeParamsError)
+		return
+	}
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+	if author != userInfo.Id {
+		ResponseError(ctx, CodeUnPermission)
+		return
+	}
+
+	// 取消的不是置顶的评论
+	if topData.IsCancel && !comment.IsTop {
+		ResponseSuccess(ctx, topData)
+		return
+	}
+
+	oldTop, err := mongo.SetCommentTop(comment, topData.IsCancel)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	// 评论列表的排序变化 删除缓存
+	err = cache.ResetCommentList(comment.OwnId, []int64{comment.CId, oldTop})
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	ResponseSuccess(ctx, topData)
+}
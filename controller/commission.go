@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
 	mongodb "go.mongodb.org/mongo-driver/mongo"
@@ -10,6 +11,7 @@ import (
 	"onpaper-api-go/logger"
 	m "onpaper-api-go/models"
 	"onpaper-api-go/settings"
+	ag "onpaper-api-go/utils/agreement"
 	"onpaper-api-go/utils/oss"
 	"onpaper-api-go/utils/snowflake"
 	"strconv"
@@ -62,6 +64,10 @@ func SaveInvitePlan(ctx *gin.Context) {
 
 	invitePlan.UserId = userInfo.Id
 	invitePlan.InviteId = snowflake.CreateID()
+	invitePlan.DisputeId = 0
+	invitePlan.Quote = nil
+	invitePlan.NeedQuote = true
+	invitePlan.Agreement = nil
 	invitePlan.UpdateAt = time.Now()
 	invitePlan.CreateAt = time.Now()
 
@@ -152,6 +158,15 @@ func GetAcceptPlan(ctx *gin.Context) {
 		res.ContactType = ""
 	}
 
+	// 排队情况实时查询 不使用缓存
+	queue, err := mysql.GetArtistQueue(queryId)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+	res.Queue = queue
+	res.StartDate = res.EstimateStart(queue).Format("2006-01-02")
+
 	ResponseSuccess(ctx, res)
 }
 
@@ -334,6 +349,12 @@ func HandlePlanNext(ctx *gin.Context) {
 	ctxData, _ = ctx.Get("planUser")
 	planUser := ctxData.(m.PlanUserInfo)
 
+	// 开始创作前 双方必须接受了报价 只有报价功能上线前创建的约稿不做限制
+	if planNext.Status == 2 && planUser.NeedQuote && planUser.Quote == nil {
+		ResponseError(ctx, CodeNeedAcceptedQuote)
+		return
+	}
+
 	// 更新status
 	err := mongo.UpdatePlanStatus(loginUser.Id, planNext)
 	if err != nil {
@@ -487,6 +508,327 @@ func GetUserReceiveEvaluate(ctx *gin.Context) {
 	ResponseSuccess(ctx, evaluate)
 }
 
+// SaveEvaluateReply 保存被评价人对评价的回复
+func SaveEvaluateReply(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("evaluateReply")
+	reply := ctxData.(m.EvaluateReply)
+
+	err := mysql.SaveEvaluateReply(reply)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	ResponseSuccess(ctx, gin.H{
+		"evaluateId": strconv.FormatInt(reply.EvaluateId, 10),
+		"reply":      reply.Text,
+	})
+}
+
+// SaveQuote 保存约稿报价/还价
+func SaveQuote(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("userInfo")
+	loginUser := ctxData.(m.UserTokenPayload)
+
+	ctxData, _ = ctx.Get("quote")
+	quote := ctxData.(m.CommissionQuote)
+
+	ctxData, _ = ctx.Get("planUser")
+	planUser := ctxData.(m.PlanUserInfo)
+
+	quote.QuoteId = snowflake.CreateID()
+	quote.Proposer = loginUser.Id
+	quote.Status = 0
+	quote.UpdateAt = time.Now()
+	quote.CreateAt = time.Now()
+
+	err := mongo.SaveQuote(quote)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	ResponseSuccess(ctx, gin.H{
+		"quoteId": strconv.FormatInt(quote.QuoteId, 10),
+	})
+
+	receiver := planUser.ArtistId
+	if loginUser.Id == planUser.ArtistId {
+		receiver = planUser.Sender
+	}
+	ctx.Set("commissionRemind", m.CommissionRemind{
+		InviteId:  quote.InviteId,
+		Action:    "quote",
+		Status:    quote.Status,
+		Receivers: []string{receiver},
+	})
+}
+
+// ReplyQuote 接受或拒绝报价
+func ReplyQuote(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("quoteReply")
+	reply := ctxData.(m.QuoteReply)
+
+	ctxData, _ = ctx.Get("quote")
+	quote := ctxData.(m.CommissionQuote)
+
+	err := mongo.ReplyQuote(quote, reply.Accept)
+	if err == mongo.ErrQuoteNotPending {
+		ResponseError(ctx, CodeQuoteNotPending)
+		return
+	}
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	var status int8 = -1
+	if reply.Accept {
+		status = 1
+	}
+	ResponseSuccess(ctx, gin.H{
+		"quoteId": strconv.FormatInt(quote.QuoteId, 10),
+		"status":  status,
+	})
+
+	ctx.Set("inviteId", quote.InviteId)
+	ctx.Set("commissionRemind", m.CommissionRemind{
+		InviteId:  quote.InviteId,
+		Action:    "quote",
+		Status:    status,
+		Receivers: []string{quote.Proposer},
+	})
+}
+
+// GetInviteQuotes 获取约稿的报价记录
+func GetInviteQuotes(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("inviteId")
+	inviteId := ctxData.(int64)
+
+	ctxData, _ = ctx.Get("userInfo")
+	loginUser := ctxData.(m.UserTokenPayload)
+
+	// 查找约稿方案的两个用户
+	userInfo, err := mongo.GetPlanUserInfo(inviteId)
+	if err != nil {
+		if err == mongodb.ErrNoDocuments {
+			ResponseError(ctx, CodeParamsError)
+			return
+		}
+		err = errors.Wrap(err, "GetPlanUserInfo mongodb fail")
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	// 如果 不是计划中的两个用户不能查看
+	if userInfo.Sender != loginUser.Id && userInfo.ArtistId != loginUser.Id {
+		ResponseError(ctx, CodeUnPermission)
+		return
+	}
+
+	quotes, err := mongo.GetInviteQuotes(inviteId)
+	if err != nil {
+		err = errors.Wrap(err, "GetInviteQuotes mongodb fail")
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	ResponseSuccess(ctx, quotes)
+}
+
+// CreatePlanAgreement 约稿进入创作阶段时生成约稿协议
+func CreatePlanAgreement(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("planNext")
+	planNext := ctxData.(m.PlanNext)
+
+	// 协议字体不可用时不生成协议
+	if planNext.Status != 2 || !ag.Enabled() {
+		return
+	}
+
+	_, err := createAgreement(planNext.InviteId)
+	if err != nil {
+		logger.ErrZapLog(err, planNext.InviteId)
+	}
+}
+
+// GetPlanAgreement 获取约稿协议的下载链接 只有约稿双方可以下载
+func GetPlanAgreement(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("inviteId")
+	inviteId := ctxData.(int64)
+
+	ctxData, _ = ctx.Get("userInfo")
+	loginUser := ctxData.(m.UserTokenPayload)
+
+	plan, err := mongo.GetPlanDetail(inviteId)
+	if err != nil {
+		if err == mongodb.ErrNoDocuments {
+			ResponseError(ctx, CodeParamsError)
+			return
+		}
+		err = errors.Wrap(err, "GetPlanDetail mongodb fail")
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	if plan.UserId != loginUser.Id && plan.ArtistId != loginUser.Id {
+		ResponseError(ctx, CodeUnPermission)
+		return
+	}
+	// 没有进入创作阶段的约稿没有协议
+	if plan.Status != 2 && plan.Status != 3 && plan.Status != -2 {
+		ResponseError(ctx, CodeParamsError)
+		return
+	}
+
+	// 生成失败的协议 下载时重新生成
+	agreement := plan.Agreement
+	if agreement == nil {
+		if !ag.Enabled() {
+			ResponseError(ctx, CodeAgreementDisabled)
+			return
+		}
+		var newAgreement m.PlanAgreement
+		newAgreement, err = createAgreement(inviteId)
+		if err != nil {
+			ResponseErrorAndLog(ctx, CodeServerBusy, err)
+			return
+		}
+		agreement = &newAgreement
+		// 约稿详情缓存中的协议信息需要更新
+		ctx.Set("inviteId", inviteId)
+	}
+
+	url, err := oss.SignOssGetURL(settings.Conf.OriginalBucket, agreement.FileName, 600)
+	if err != nil {
+		err = errors.Wrap(err, "GetPlanAgreement SignOssGetURL fail")
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	ResponseSuccess(ctx, gin.H{
+		"url":       url,
+		"hash":      agreement.Hash,
+		"fileHash":  agreement.FileHash,
+		"createAt":  agreement.CreateAt,
+		"inviteId":  strconv.FormatInt(inviteId, 10),
+		"expiredIn": 600,
+	})
+}
+
+// VerifyPlanAgreement 校验约稿协议的 hash 返回平台保存的协议条款 只有约稿双方可以校验
+func VerifyPlanAgreement(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("query")
+	query := ctxData.(m.AgreementVerifyQuery)
+
+	ctxData, _ = ctx.Get("userInfo")
+	loginUser := ctxData.(m.UserTokenPayload)
+
+	plan, err := mongo.GetPlanDetail(query.InviteId)
+	if err != nil {
+		if err == mongodb.ErrNoDocuments {
+			ResponseError(ctx, CodeParamsError)
+			return
+		}
+		err = errors.Wrap(err, "VerifyPlanAgreement GetPlanDetail fail")
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	if plan.UserId != loginUser.Id && plan.ArtistId != loginUser.Id {
+		ResponseError(ctx, CodeUnPermission)
+		return
+	}
+	if plan.Agreement == nil {
+		ResponseError(ctx, CodeParamsError)
+		return
+	}
+
+	// 页脚的条款 hash 和 pdf 文件 hash 都可以校验
+	agreement := plan.Agreement
+	ResponseSuccess(ctx, gin.H{
+		"inviteId": strconv.FormatInt(query.InviteId, 10),
+		"match":    query.Hash == agreement.Hash || query.Hash == agreement.FileHash,
+		"hash":     agreement.Hash,
+		"fileHash": agreement.FileHash,
+		"terms":    agreement.Terms,
+		"createAt": agreement.CreateAt,
+	})
+}
+
+// createAgreement 根据约稿方案 接稿方案和接受的报价生成协议 上传并保存
+func createAgreement(inviteId int64) (agreement m.PlanAgreement, err error) {
+	plan, err := mongo.GetPlanDetail(inviteId)
+	if err != nil {
+		err = errors.Wrap(err, "createAgreement GetPlanDetail fail")
+		return
+	}
+	// 使用发出邀请时的接稿条款 之前的邀请没有快照 只能读取当前的接稿方案
+	planTerms := plan.PlanTerms
+	if planTerms == nil {
+		accept, aErr := mongo.GetAcceptPlan(plan.ArtistId)
+		if aErr != nil && aErr != mongodb.ErrNoDocuments {
+			err = errors.Wrap(aErr, "createAgreement GetAcceptPlan fail")
+			return
+		}
+		planTerms = &m.PlanTerms{Change: accept.Change, Refuse: accept.Refuse}
+	}
+	userMap, err := mysql.GetBatchUserSimpleInfo([]string{plan.UserId, plan.ArtistId})
+	if err != nil {
+		return
+	}
+
+	terms := m.AgreementTerms{
+		InviteId:   plan.InviteId,
+		Name:       plan.Name,
+		SenderId:   plan.UserId,
+		SenderName: userMap[plan.UserId].UserName,
+		ArtistId:   plan.ArtistId,
+		ArtistName: userMap[plan.ArtistId].UserName,
+		Category:   plan.Category,
+		Purpose:    plan.Purpose,
+		FileSize:   plan.FileSize,
+		Color:      plan.Color,
+		FileType:   plan.FileType,
+		Deadline:   plan.Date,
+		Money:      plan.Money,
+		Change:     planTerms.Change,
+		Refuse:     planTerms.Refuse,
+		SignDate:   time.Now().Format("2006-01-02"),
+	}
+	// 以双方接受的报价为准
+	if plan.Quote != nil {
+		terms.Deadline = plan.Quote.Deadline
+		terms.Money = plan.Quote.Money
+		terms.Change = plan.Quote.Change
+	}
+
+	hash, err := ag.TermsHash(terms)
+	if err != nil {
+		return
+	}
+	data, err := ag.CreatePDF(terms, hash)
+	if err != nil {
+		return
+	}
+
+	agreement = m.PlanAgreement{
+		FileName: fmt.Sprintf("agreement/%d.pdf", inviteId),
+		Hash:     hash,
+		FileHash: ag.FileHash(data),
+		Terms:    terms,
+		CreateAt: time.Now(),
+	}
+	err = oss.PutOssObject(settings.Conf.OriginalBucket, agreement.FileName, data, "application/pdf")
+	if err != nil {
+		err = errors.Wrap(err, "createAgreement PutOssObject fail")
+		return
+	}
+
+	err = mongo.SavePlanAgreement(inviteId, agreement)
+	return
+}
+
 // UpdateCommissionStatus 更新约稿状态
 func UpdateCommissionStatus(ctx *gin.Context) {
 	ctxData, _ := ctx.Get("status")
@@ -0,0 +1,214 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"onpaper-api-go/dao/mongo"
+	"onpaper-api-go/dao/mysql"
+	"onpaper-api-go/logger"
+	m "onpaper-api-go/models"
+	"onpaper-api-go/settings"
+	"onpaper-api-go/utils/oss"
+	"onpaper-api-go/utils/snowflake"
+	"strconv"
+	"time"
+)
+
+// SaveDispute 发起约稿纠纷
+func SaveDispute(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("userInfo")
+	loginUser := ctxData.(m.UserTokenPayload)
+
+	ctxData, _ = ctx.Get("dispute")
+	post := ctxData.(m.DisputePost)
+
+	ctxData, _ = ctx.Get("planUser")
+	planUser := ctxData.(m.PlanUserInfo)
+
+	respondent := planUser.ArtistId
+	if loginUser.Id == planUser.ArtistId {
+		respondent = planUser.Sender
+	}
+
+	dispute := m.CommissionDispute{
+		DisputeId:  snowflake.CreateID(),
+		InviteId:   post.InviteId,
+		Sponsor:    loginUser.Id,
+		Respondent: respondent,
+		Evidence: m.DisputeEvidence{
+			Text:     post.Text,
+			FileList: post.FileList,
+			CreateAt: time.Now(),
+		},
+		Status:   0,
+		UpdateAt: time.Now(),
+		CreateAt: time.Now(),
+	}
+
+	err := mongo.SaveDispute(dispute)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	ResponseSuccess(ctx, gin.H{
+		"disputeId": strconv.FormatInt(dispute.DisputeId, 10),
+	})
+	deleteDisputeTempFiles(loginUser.Id, post.FileList)
+
+	ctx.Set("inviteId", dispute.InviteId)
+	ctx.Set("commissionRemind", m.CommissionRemind{
+		InviteId:  dispute.InviteId,
+		Action:    "dispute",
+		Status:    dispute.Status,
+		Receivers: []string{respondent},
+	})
+}
+
+// SaveDisputeResponse 保存被申诉人的回应
+func SaveDisputeResponse(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("userInfo")
+	loginUser := ctxData.(m.UserTokenPayload)
+
+	ctxData, _ = ctx.Get("disputeResponse")
+	post := ctxData.(m.DisputePost)
+
+	ctxData, _ = ctx.Get("dispute")
+	dispute := ctxData.(m.CommissionDispute)
+
+	response := m.DisputeEvidence{
+		Text:     post.Text,
+		FileList: post.FileList,
+		CreateAt: time.Now(),
+	}
+	err := mongo.SaveDisputeResponse(dispute.DisputeId, response)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	ResponseSuccess(ctx, gin.H{"status": "ok"})
+	deleteDisputeTempFiles(loginUser.Id, post.FileList)
+
+	ctx.Set("commissionRemind", m.CommissionRemind{
+		InviteId:  dispute.InviteId,
+		Action:    "dispute",
+		Status:    1,
+		Receivers: []string{dispute.Sponsor},
+	})
+}
+
+// SaveDisputeDecision 管理员裁决纠纷 调整评价权重和双方信誉
+func SaveDisputeDecision(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("userInfo")
+	loginUser := ctxData.(m.UserTokenPayload)
+
+	ctxData, _ = ctx.Get("decision")
+	decision := ctxData.(m.DisputeDecision)
+
+	ctxData, _ = ctx.Get("dispute")
+	dispute := ctxData.(m.CommissionDispute)
+
+	planUser, err := mongo.GetPlanUserInfo(dispute.InviteId)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	// 先在 mysql 中生效 同一个纠纷只生效一次 mongo 保存失败时可以重新提交
+	appliedFault, err := mysql.ApplyDisputeDecision(dispute.DisputeId, dispute.InviteId, planUser.Sender, planUser.ArtistId, decision.Fault, decision.Refund)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+	// 其他管理员已经做出了不同的裁决
+	if appliedFault != decision.Fault {
+		ResponseError(ctx, CodeDisputeDecided)
+		return
+	}
+
+	decision.AdminId = loginUser.Id
+	decision.CreateAt = time.Now()
+	decision.RefundStatus = m.RefundNone
+	if decision.Refund > 0 {
+		decision.RefundStatus = m.RefundWait
+	}
+	err = mongo.SaveDisputeDecision(decision)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	ResponseSuccess(ctx, gin.H{"status": "ok"})
+
+	ctx.Set("inviteId", dispute.InviteId)
+	ctx.Set("commissionRemind", m.CommissionRemind{
+		InviteId:  dispute.InviteId,
+		Action:    "dispute",
+		Status:    2,
+		Receivers: []string{dispute.Sponsor, dispute.Respondent},
+	})
+	// 双方的评分变化了 需要删除面板缓存
+	ctx.Set("planUser", planUser)
+}
+
+// SaveDisputeRefund 管理员线下退款后 标记裁决的退款已完成
+func SaveDisputeRefund(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("refund")
+	data := ctxData.(m.DisputeRefund)
+
+	isChange, err := mysql.SetDisputeRefunded(data.DisputeId)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+	// 没有裁决 不需要退款 或者已经标记过
+	if !isChange {
+		ResponseError(ctx, CodeParamsError)
+		return
+	}
+
+	err = mongo.SetDisputeRefunded(data.DisputeId)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	ResponseSuccess(ctx, gin.H{"status": "ok"})
+}
+
+// GetDispute 获取纠纷详情
+func GetDispute(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("dispute")
+	dispute := ctxData.(m.CommissionDispute)
+
+	ResponseSuccess(ctx, dispute)
+}
+
+// GetDisputeList 管理员获取纠纷列表
+func GetDisputeList(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("query")
+	query := ctxData.(m.DisputeListQuery)
+
+	disputes, err := mongo.GetDisputeList(query)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	ResponseSuccess(ctx, disputes)
+}
+
+// deleteDisputeTempFiles 删除临时桶中这次提交的证据文件
+func deleteDisputeTempFiles(userId string, files []m.PicsType) {
+	if len(files) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(files))
+	for _, file := range files {
+		keys = append(keys, "dispute/"+userId+"/"+file.FileName)
+	}
+	err := oss.DeleteOssObjects(settings.Conf.TempBucket, keys)
+	if err != nil {
+		logger.ErrZapLog(err, "deleteDisputeTempFiles DeleteOssObjects fail")
+	}
+}
@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v9"
+	"github.com/pkg/errors"
+	"net/http"
+	"onpaper-api-go/cache"
+	"onpaper-api-go/dao/mysql"
+	"onpaper-api-go/logger"
+	m "onpaper-api-go/models"
+	"onpaper-api-go/settings"
+	"onpaper-api-go/utils/encrypt"
+	"onpaper-api-go/utils/oss"
+	"onpaper-api-go/utils/watermark"
+	"time"
+)
+
+// DownloadArtwork 返回作品原图的临时下载链接 需要时先生成水印图
+// 链接指向 DownloadArtworkFile 打开链接时才计入下载数
+func DownloadArtwork(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("query")
+	query := ctxData.(m.DownloadQuery)
+
+	ctxData, _ = ctx.Get("downloadInfo")
+	info := ctxData.(m.ArtworkDownloadInfo)
+
+	key := "artworks/" + info.UserId + "/" + query.FileName
+	if query.Watermark {
+		// 水印字体不可用时 需要水印的作品暂时不能下载 不能返回没有水印的原图
+		if !watermark.Enabled() {
+			ResponseError(ctx, CodeWatermarkDisabled)
+			return
+		}
+		wmKey, err := createWatermark(info, key, query.FileName)
+		if err != nil {
+			ResponseErrorAndLog(ctx, CodeServerBusy, err)
+			return
+		}
+		key = wmKey
+	}
+
+	expire := settings.Conf.DownloadExpire
+	if expire <= 0 {
+		expire = 300
+	}
+	token := encrypt.CreateUUID()
+	data := m.DownloadToken{ArtworkId: info.ArtworkId, Key: key}
+	err := cache.SetDownloadToken(token, data, time.Duration(expire)*time.Second)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	ResponseSuccess(ctx, gin.H{
+		"url":       fmt.Sprintf("%s/artwork/download/file?token=%s", settings.Conf.Host, token),
+		"expire":    expire,
+		"watermark": query.Watermark,
+	})
+}
+
+// DownloadArtworkFile 使用下载链接 计入下载数后跳转到原图的签名地址 每个链接只能使用一次
+func DownloadArtworkFile(ctx *gin.Context) {
+	var query m.DownloadTokenQuery
+	err := ctx.ShouldBindQuery(&query)
+	if err != nil {
+		ResponseError(ctx, CodeParamsError)
+		return
+	}
+
+	data, err := cache.UseDownloadToken(query.Token)
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			ResponseError(ctx, CodeParamsError)
+			return
+		}
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	url, err := oss.SignOssGetURL(settings.Conf.OriginalBucket, data.Key, 60)
+	if err != nil {
+		err = errors.Wrap(err, "DownloadArtworkFile SignOssGetURL fail")
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	err = mysql.IncrArtworkDownload(data.ArtworkId)
+	if err != nil {
+		logger.ErrZapLog(err, data.ArtworkId)
+	}
+
+	ctx.Redirect(http.StatusFound, url)
+}
+
+// createWatermark 生成带作者名和作品地址的水印图 已经生成过的直接使用
+// 作者改名后 key 会变化 会重新生成
+func createWatermark(info m.ArtworkDownloadInfo, key, fileName string) (wmKey string, err error) {
+	sum := sha256.Sum256([]byte(info.UserName))
+	wmKey = fmt.Sprintf("watermark/%s/%s_%s.jpg", info.ArtworkId, hex.EncodeToString(sum[:4]), fileName)
+
+	_, err = oss.SelectOssFileInfo(settings.Conf.OriginalBucket, wmKey)
+	if err == nil {
+		return
+	}
+
+	src, err := oss.GetOssObject(settings.Conf.OriginalBucket, key)
+	if err != nil {
+		err = errors.Wrap(err, "createWatermark GetOssObject fail")
+		return
+	}
+
+	lines := []string{"@" + info.UserName, fmt.Sprintf(settings.Conf.ArtworkURL, info.ArtworkId)}
+	data, err := watermark.Render(src, lines)
+	if err != nil {
+		return
+	}
+
+	err = oss.PutOssObject(settings.Conf.OriginalBucket, wmKey, data, "image/jpeg")
+	if err != nil {
+		err = errors.Wrap(err, "createWatermark PutOssObject fail")
+	}
+	return
+}
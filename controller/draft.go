@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	mongodb "go.mongodb.org/mongo-driver/mongo"
+	"onpaper-api-go/cache"
+	"onpaper-api-go/dao/mongo"
+	"onpaper-api-go/dao/mysql"
+	"onpaper-api-go/logger"
+	m "onpaper-api-go/models"
+	"strconv"
+	"time"
+)
+
+// DraftPublishInterval 定时发布检查的间隔
+const DraftPublishInterval = time.Minute
+
+// SaveArtworkDraft 保存作品草稿 草稿不会出现在任何地方
+func SaveArtworkDraft(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("draft")
+	draft := ctxData.(m.ArtworkDraft)
+
+	err := mongo.SaveArtworkDraft(draft)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	ResponseSuccess(ctx, gin.H{
+		"draftId": strconv.FormatInt(draft.DraftId, 10),
+		"status":  draft.Status,
+	})
+}
+
+// GetDraftList 获取自己的草稿列表
+func GetDraftList(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("userInfo")
+	userInfo := ctxData.(m.UserTokenPayload)
+
+	ctxData, _ = ctx.Get("query")
+	query := ctxData.(m.DraftListQuery)
+
+	drafts, err := mongo.GetUserDrafts(userInfo.Id, query.Page-1)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	ResponseSuccess(ctx, drafts)
+}
+
+// GetDraft 获取一个草稿 用于继续编辑
+func GetDraft(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("draft")
+	draft := ctxData.(m.ArtworkDraft)
+
+	ResponseSuccess(ctx, draft)
+}
+
+// DeleteDraft 删除草稿
+func DeleteDraft(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("draft")
+	draft := ctxData.(m.ArtworkDraft)
+
+	err := mongo.DeleteArtworkDraft(draft.DraftId)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	ResponseSuccess(ctx, gin.H{"status": "ok"})
+}
+
+// PublishDraft 立即发布草稿
+func PublishDraft(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("draft")
+	draft := ctxData.(m.ArtworkDraft)
+
+	// 认领失败说明定时任务正在发布
+	draft, err := mongo.ClaimArtworkDraft(draft.DraftId, false)
+	if err != nil {
+		if err == mongodb.ErrNoDocuments {
+			ResponseError(ctx, CodeParamsError)
+			return
+		}
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	artworkInfo, err := publishArtworkDraft(draft)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	ResponseSuccess(ctx, gin.H{
+		"artworkId": strconv.FormatInt(artworkInfo.ArtworkId, 10),
+		"state":     artworkInfo.State,
+	})
+
+	ctx.Set("artworkInfo", artworkInfo)
+}
+
+// RunDraftPublisher 定时发布到时间的草稿 在服务启动时运行
+func RunDraftPublisher() {
+	ticker := time.NewTicker(DraftPublishInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		publishDueDrafts()
+	}
+}
+
+// publishDueDrafts 发布所有到时间的草稿 并设置缓存 图片检查完成后再推送到粉丝feed
+func publishDueDrafts() {
+	// 发布出现 panic 不能让定时任务退出 草稿认领超时后会重新发布
+	defer func() {
+		if p := recover(); p != nil {
+			logger.ErrZapLog(fmt.Errorf("%v", p), "publishDueDrafts panic")
+		}
+	}()
+
+	for {
+		draft, err := mongo.ClaimArtworkDraft(0, true)
+		if err != nil {
+			if err != mongodb.ErrNoDocuments {
+				logger.ErrZapLog(err, "publishDueDrafts ClaimArtworkDraft fail")
+			}
+			return
+		}
+
+		artworkInfo, err := publishArtworkDraft(draft)
+		if err != nil {
+			logger.ErrZapLog(err, fmt.Sprintf("publishDueDrafts publish fail %d", draft.DraftId))
+			continue
+		}
+
+		err = cache.SetUploadArtAbout(artworkInfo.UserId, artworkInfo.Tags)
+		if err != nil {
+			logger.ErrZapLog(err, artworkInfo.UserId)
+		}
+	}
+}
+
+// publishArtworkDraft 把草稿保存为作品 成功后删除草稿 失败时退回草稿状态
+func publishArtworkDraft(draft m.ArtworkDraft) (artworkInfo *m.SaveArtworkInfo, err error) {
+	artworkInfo = draft.ArtworkInfo()
+
+	err = createArtwork(artworkInfo)
+	if err != nil {
+		// 作品已经保存 只是后续步骤失败 不能退回草稿 否则再次发布会用同一个id重复创建作品
+		state, sErr := mysql.GetArtworkState(artworkInfo.ArtworkId)
+		if sErr != nil {
+			mErr := mongo.SetDraftPublishFail(draft.DraftId, "publish_fail")
+			if mErr != nil {
+				logger.ErrZapLog(mErr, draft.DraftId)
+			}
+			return
+		}
+		logger.ErrZapLog(err, draft.DraftId)
+		artworkInfo.State = state
+	}
+
+	err = mongo.DeleteArtworkDraft(draft.DraftId)
+	if err != nil {
+		// 作品已经发布成功 草稿删除失败不影响发布
+		logger.ErrZapLog(err, draft.DraftId)
+		err = nil
+	}
+	return
+}
@@ -42,6 +42,23 @@ const (
 	CodeUserStopCommission
 	CodeGetOpenIdFail
 	CodeUserNoHaveFans
+	CodeEditTimeout
+	CodeDisputeExists
+	CodeArtistQueueFull
+	CodeNeedAcceptedQuote
+	CodeFolderLimit
+	CodeArtworkTakenDown
+	CodeNeedAdult
+	CodeAdultContent
+	CodeInterestLimit
+	CodeMuteWordLimit
+	CodePollClosed
+	CodePollVoted
+	CodeDisputeDecided
+	CodeQuoteNotPending
+	CodeBirthdayLocked
+	CodeAgreementDisabled
+	CodeWatermarkDisabled
 )
 
 var codeMsgMap = map[ResCode]string{
@@ -83,6 +100,23 @@ var codeMsgMap = map[ResCode]string{
 	CodeUserStopCommission:   "user_stop_commission",
 	CodeGetOpenIdFail:        "get_openid_fail",
 	CodeUserNoHaveFans:       "user_no_have_fans",
+	CodeEditTimeout:          "edit_timeout",
+	CodeDisputeExists:        "dispute_exists",
+	CodeArtistQueueFull:      "artist_queue_full",
+	CodeNeedAcceptedQuote:    "need_accepted_quote",
+	CodeFolderLimit:          "folder_limit",
+	CodeArtworkTakenDown:     "artwork_taken_down",
+	CodeNeedAdult:            "need_adult",
+	CodeAdultContent:         "adult_content",
+	CodeInterestLimit:        "interest_limit",
+	CodeMuteWordLimit:        "mute_word_limit",
+	CodePollClosed:           "poll_closed",
+	CodePollVoted:            "poll_voted",
+	CodeDisputeDecided:       "dispute_decided",
+	CodeQuoteNotPending:      "quote_not_pending",
+	CodeBirthdayLocked:       "birthday_locked",
+	CodeAgreementDisabled:    "agreement_disabled",
+	CodeWatermarkDisabled:    "watermark_disabled",
 }
 
 func (c ResCode) Msg() string {
@@ -5,12 +5,14 @@ import (
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
+	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 	c "onpaper-api-go/cache"
 	"onpaper-api-go/dao/mongo"
 	"onpaper-api-go/dao/mysql"
 	"onpaper-api-go/logger"
 	m "onpaper-api-go/models"
+	"onpaper-api-go/settings"
 	tools "onpaper-api-go/utils/formatTools"
 	"sort"
 	"strconv"
@@ -25,9 +27,12 @@ func GetArtFeed(ctx *gin.Context) {
 	ctxData, _ = ctx.Get("nextId")
 	MsgId := ctxData.(*int64)
 
-	dataList, err := mongo.GetFeed(*MsgId, userInfo.Id, "aw")
+	mute := getFeedMute(userInfo.Id)
+	dataList, next, err := getUnmutedFeed(*MsgId, mute, func(msgId int64) ([]m.MongoFeed, error) {
+		return getMergedFeed(msgId, userInfo.Id, "aw")
+	})
 	if err != nil {
-		err = errors.Wrap(err, "GetArtFeed mongo fail")
+		err = errors.Wrap(err, "GetArtFeed fail")
 		ResponseErrorAndLog(ctx, CodeServerBusy, err)
 		return
 	}
@@ -35,8 +40,7 @@ func GetArtFeed(ctx *gin.Context) {
 	dataLen := len(dataList)
 	if dataLen == 0 {
 		// 返回数据
-		ResponseSuccess(ctx, make([]struct{}, 0))
-		ctx.Abort()
+		responseEmptyFeed(ctx, next)
 		return
 	}
 
@@ -47,7 +51,7 @@ func GetArtFeed(ctx *gin.Context) {
 		uIds = append(uIds, data.SendId)
 	}
 
-	artData, findData, err := BatchGetBasicArtInfo(artIds, uIds)
+	artData, findData, err := BatchGetBasicArtInfo(artIds, uIds, getContentViewer(ctx))
 	if err != nil {
 		err = errors.Wrap(err, "GetArtFeed fail")
 		ResponseErrorAndLog(ctx, CodeServerBusy, err)
@@ -124,9 +128,12 @@ func GetAllFeed(ctx *gin.Context) {
 	MsgId := ctxData.(*int64)
 
 	// 获取 feed
-	feedData, err := mongo.GetFeed(*MsgId, userInfo.Id, "all")
+	mute := getFeedMute(userInfo.Id)
+	feedData, next, err := getUnmutedFeed(*MsgId, mute, func(msgId int64) ([]m.MongoFeed, error) {
+		return getMergedFeed(msgId, userInfo.Id, "all")
+	})
 	if err != nil {
-		err = errors.Wrap(err, "GetAllFeed mongo fail")
+		err = errors.Wrap(err, "GetAllFeed fail")
 		ResponseErrorAndLog(ctx, CodeServerBusy, err)
 		return
 	}
@@ -135,8 +142,7 @@ func GetAllFeed(ctx *gin.Context) {
 	dataLen := len(feedData)
 	if dataLen == 0 {
 		// 返回数据
-		ResponseSuccess(ctx, make([]struct{}, 0))
-		ctx.Abort()
+		responseEmptyFeed(ctx, next)
 		return
 	}
 	ctx.Set("feedData", feedData)
@@ -392,6 +398,8 @@ func GetFeedTrend(ctx *gin.Context) {
 	// 去重必须！
 	res, _ = tools.RemoveSliceDuplicate(res)
 	sort.Sort(res)
+	fillTrendPoll(res, loginInfo.Id)
+	maskAdultTrend(res, getContentViewer(ctx))
 	ResponseSuccess(ctx, res)
 
 	// 设置缓存
@@ -410,9 +418,28 @@ func GetFeedTrend(ctx *gin.Context) {
 
 // SetFeed 发布作品或动态后 设置到用户feed
 func SetFeed(ctx *gin.Context) {
-	ctxData, _ := ctx.Get("feed")
+	ctxData, exists := ctx.Get("feed")
+	// 审核中的作品不推送
+	if !exists {
+		return
+	}
 	feed := ctxData.(m.UploadArtOrTrend)
 
+	fanOutFeed(feed)
+}
+
+// fanOutFeed 把作品或动态推送到所有粉丝的feed流
+// 粉丝数达到配置的用户只保存发布记录 粉丝读取feed时再拉取
+func fanOutFeed(feed m.UploadArtOrTrend) {
+	err := mongo.SetOutboxFeed([]m.UploadArtOrTrend{feed})
+	if err != nil {
+		logger.ErrZapLog(err, fmt.Sprintf("SetFeed SetOutboxFeed fail %+v", feed))
+	}
+
+	if isCelebrity(feed.SendId) {
+		return
+	}
+
 	lastUserID := "0"
 	limit := 500
 
@@ -434,3 +461,134 @@ func SetFeed(ctx *gin.Context) {
 		lastUserID = fans[len(fans)-1]
 	}
 }
+
+// isCelebrity 是否是粉丝数达到配置 不推送feed的用户
+func isCelebrity(userId string) bool {
+	minFans := settings.Conf.CelebrityFans
+	if minFans <= 0 {
+		return false
+	}
+	count, err := mysql.GetUserCount(userId)
+	if err != nil {
+		// 查询失败时按普通用户推送
+		logger.ErrZapLog(err, "isCelebrity GetUserCount fail")
+		return false
+	}
+	return count.Fans >= minFans
+}
+
+// celebrityPullFans 读取feed时拉取发布记录的粉丝数 比不推送的阈值低五分之一
+// 发布记录保存了所有用户的 多拉取一些没有影响 粉丝数在阈值附近波动时 不推送期间发布的内容也不会丢
+func celebrityPullFans(minFans int) int {
+	return minFans - minFans/5
+}
+
+// getMergedFeed 获取推送到自己的feed 合并关注的大用户的发布记录 翻页方式不变
+func getMergedFeed(msgId int64, userId, feedType string) (feedData []m.MongoFeed, err error) {
+	var eg errgroup.Group
+
+	var inbox []m.MongoFeed
+	eg.Go(func() (mErr error) {
+		inbox, mErr = mongo.GetFeed(msgId, userId, feedType)
+		if mErr != nil {
+			mErr = errors.Wrap(mErr, "getMergedFeed GetFeed fail")
+		}
+		return
+	})
+
+	var outbox []m.MongoFeed
+	if minFans := settings.Conf.CelebrityFans; minFans > 0 {
+		eg.Go(func() (mErr error) {
+			sendIds, mErr := mysql.GetFocusCelebrity(userId, celebrityPullFans(minFans))
+			if mErr != nil {
+				return
+			}
+			outbox, mErr = mongo.GetOutboxFeed(sendIds, msgId, feedType)
+			return
+		})
+	}
+
+	err = eg.Wait()
+	if err != nil || len(outbox) == 0 {
+		return inbox, err
+	}
+
+	// 成为大用户之前推送的feed 可能同时存在 按 msgId 去重
+	exist := make(map[int64]bool, len(inbox))
+	for _, feed := range inbox {
+		exist[feed.MsgID] = true
+	}
+	feedData = inbox
+	for _, feed := range outbox {
+		if !exist[feed.MsgID] {
+			feedData = append(feedData, feed)
+		}
+	}
+	sort.Slice(feedData, func(i, j int) bool {
+		return feedData[i].MsgID > feedData[j].MsgID
+	})
+	if len(feedData) > 30 {
+		feedData = feedData[:30]
+	}
+	return
+}
+
+// BackfillFeedOutbox 补全已有作品和动态的发布记录
+// prune 为 true 时 删除已经推送给大用户粉丝的feed
+func BackfillFeedOutbox(prune bool) (err error) {
+	const batch = 1000
+
+	var lastId int64
+	var total int
+	for {
+		feeds, mErr := mysql.GetArtworkFeedAfter(lastId, batch)
+		if mErr != nil {
+			return mErr
+		}
+		if len(feeds) == 0 {
+			break
+		}
+		mErr = mongo.SetOutboxFeed(feeds)
+		if mErr != nil {
+			return mErr
+		}
+		total += len(feeds)
+		lastId = feeds[len(feeds)-1].MsgID
+	}
+	zap.L().Info("BackfillFeedOutbox artwork done", zap.Int("count", total))
+
+	lastId, total = 0, 0
+	for {
+		feeds, mErr := mongo.GetTrendFeedAfter(lastId, batch)
+		if mErr != nil {
+			return mErr
+		}
+		if len(feeds) == 0 {
+			break
+		}
+		mErr = mongo.SetOutboxFeed(feeds)
+		if mErr != nil {
+			return mErr
+		}
+		total += len(feeds)
+		lastId = feeds[len(feeds)-1].MsgID
+	}
+	zap.L().Info("BackfillFeedOutbox trend done", zap.Int("count", total))
+
+	minFans := settings.Conf.CelebrityFans
+	if !prune || minFans <= 0 {
+		return
+	}
+	userIds, err := mysql.GetCelebrityUser(minFans)
+	if err != nil {
+		return
+	}
+	for _, userId := range userIds {
+		count, mErr := mongo.PruneFansFeed(userId)
+		if mErr != nil {
+			return mErr
+		}
+		zap.L().Info("BackfillFeedOutbox prune", zap.String("userId", userId), zap.Int64("count", count))
+	}
+	return
+}
@@ -1,8 +1,10 @@
 package controller
 
 import (
+	"bytes"
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
+	"image"
 	"onpaper-api-go/cache"
 	"onpaper-api-go/dao/mongo"
 	"onpaper-api-go/dao/mysql"
@@ -10,10 +12,15 @@ import (
 	"onpaper-api-go/models"
 	"onpaper-api-go/settings"
 	"onpaper-api-go/utils/oss"
+	"onpaper-api-go/utils/palette"
+	"onpaper-api-go/utils/phash"
 	"strconv"
 	"strings"
 )
 
+// PaletteSize 每张图片提取的主色数量
+const PaletteSize = 5
+
 // SaveBannerInfo 保存上传的背景图片信息
 func SaveBannerInfo(ctx *gin.Context) {
 	//1.获取所有的图像信息
@@ -134,36 +141,76 @@ func SaveArtworkInfo(ctx *gin.Context) {
 	ctxData, _ := ctx.Get("artworkInfo")
 	artworkInfo := ctxData.(*models.SaveArtworkInfo)
 
-	//到数据库中保存
-	err := mysql.CreateArtworkInfo(artworkInfo)
+	err := createArtwork(artworkInfo)
 	if err != nil {
 		ResponseErrorAndLog(ctx, CodeServerBusy, err)
 		return
 	}
+
+	// 图片检查完成后公开作品并推送到粉丝的feed
+	ResponseSuccess(ctx, gin.H{
+		"artworkId": strconv.FormatInt(artworkInfo.ArtworkId, 10),
+		"state":     artworkInfo.State,
+	})
+}
+
+// createArtwork 保存作品到数据库 加入自己的feed流 并发送压缩消息
+// 作品先保存为等待审核 图片哈希 主色和查重在 RunPictureAnalyzer 中处理 完成后再公开
+func createArtwork(artworkInfo *models.SaveArtworkInfo) (err error) {
+	// 标签换成规范标签 后续热度统计也使用规范标签
+	artworkInfo.Tags, err = mysql.CanonicalTags(artworkInfo.Tags)
+	if err != nil {
+		return
+	}
+	artworkInfo.State = models.ArtStateReview
+	//到数据库中保存
+	err = mysql.CreateArtworkInfo(artworkInfo)
+	if err != nil {
+		return
+	}
 	// 给自己的feed 流添加一条
 	err = mongo.SetTheUserFeed([]int64{artworkInfo.ArtworkId}, "aw", artworkInfo.UserId, artworkInfo.UserId)
 	if err != nil {
 		err = errors.Wrap(err, "SetTheUserFeed fail")
-		ResponseErrorAndLog(ctx, CodeServerBusy, err)
 		return
 	}
 	//发送 压缩消息到服务器
 	err = cache.SendCompressQueue(artworkInfo.UserId, artworkInfo.ArtworkId, "aw")
 	if err != nil {
 		err = errors.Wrap(err, "SendCompressQueue fail")
-		ResponseErrorAndLog(ctx, CodeServerBusy, err)
-		return
 	}
+	return
+}
 
-	ResponseSuccess(ctx, gin.H{
-		"artworkId": strconv.FormatInt(artworkInfo.ArtworkId, 10),
-	})
+// analyzePictures 读取原始桶中的作品图片 计算感知哈希并提取主色
+// 主色保存到 file.Palette 无法解码的图片跳过
+func analyzePictures(userId string, fileList []*models.PicsType) (hashes map[string]uint64, err error) {
+	hashes = make(map[string]uint64, len(fileList))
+	for _, file := range fileList {
+		key := "artworks/" + userId + "/" + file.FileName
+		data, oErr := oss.GetOssObject(settings.Conf.OriginalBucket, key)
+		if oErr != nil {
+			err = errors.Wrap(oErr, "analyzePictures GetOssObject fail: "+key)
+			return
+		}
+		img, _, dErr := image.Decode(bytes.NewReader(data))
+		if dErr != nil {
+			logger.ErrZapLog(dErr, key)
+			continue
+		}
 
-	ctx.Set("feed", models.UploadArtOrTrend{
-		MsgID:  artworkInfo.ArtworkId,
-		SendId: artworkInfo.UserId,
-		Type:   "aw",
-	})
+		hashes[file.FileName] = phash.DHashImage(img)
+		colors := palette.Extract(img, PaletteSize)
+		file.Palette = make([]models.PictureColor, 0, len(colors))
+		for _, color := range colors {
+			file.Palette = append(file.Palette, models.PictureColor{
+				FileName: file.FileName,
+				Color:    color.Hex(),
+				Weight:   color.Weight,
+			})
+		}
+	}
+	return
 }
 
 // SaveTrendInfo 保存trend 信息
@@ -213,11 +260,16 @@ func SaveTrendInfo(ctx *gin.Context) {
 		UserName:    "",
 		ForwardInfo: trendInfo.ForwardInfo,
 		Topic:       trendInfo.Topic,
+		Hashtags:    trendInfo.Hashtags,
 		Count:       models.TrendCount{},
 		Interact:    models.UserTrendInteract{},
 		CreateAT:    trendInfo.CreateAt,
 		IsDelete:    false,
 	}
+	if trendInfo.Poll != nil {
+		poll := applyPollCount(*trendInfo.Poll, nil, nil, true)
+		formatData.Poll = &poll
+	}
 
 	ResponseSuccess(ctx, formatData)
 
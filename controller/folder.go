@@ -0,0 +1,225 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"onpaper-api-go/dao/mongo"
+	m "onpaper-api-go/models"
+	"onpaper-api-go/utils/snowflake"
+	"strconv"
+	"time"
+)
+
+// GetUserFolders 获取用户的收藏夹列表 别人查看时只返回公开的收藏夹
+func GetUserFolders(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("userInfo")
+	loginUser := ctxData.(m.UserTokenPayload)
+
+	ctxData, _ = ctx.Get("userId")
+	userId := ctxData.(string)
+
+	isOwner := loginUser.Id == userId
+	// 自己查看时保证默认收藏夹存在 之前的收藏会迁移进去
+	if isOwner {
+		_, err := mongo.EnsureDefaultFolder(userId)
+		if err != nil {
+			ResponseErrorAndLog(ctx, CodeServerBusy, err)
+			return
+		}
+	}
+
+	folders, err := mongo.GetUserFolders(userId, !isOwner)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	// 找出每个收藏夹的封面作品
+	coverItems, err := mongo.GetFolderCovers(folders)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+	var artIds []string
+	var uIds []string
+	for _, item := range coverItems {
+		artIds = append(artIds, item.MsgId)
+		uIds = append(uIds, item.AuthorId)
+	}
+
+	artData, findData, err := BatchGetBasicArtInfo(artIds, uIds, getContentViewer(ctx))
+	if err != nil {
+		err = errors.Wrap(err, "GetUserFolders BatchGetBasicArtInfo fail")
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+	covers := make(map[string]string, len(artData))
+	for _, art := range artData {
+		if !art.IsDelete {
+			covers[art.ArtworkId] = art.Cover
+		}
+	}
+	for i, folder := range folders {
+		folders[i].Cover = covers[coverItems[folder.FolderId].MsgId]
+	}
+
+	ResponseSuccess(ctx, folders)
+
+	ctx.Set("artData", findData)
+}
+
+// SaveFolder 创建收藏夹
+func SaveFolder(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("userInfo")
+	loginUser := ctxData.(m.UserTokenPayload)
+
+	ctxData, _ = ctx.Get("folder")
+	post := ctxData.(m.PostCollectFolder)
+
+	// 新建的收藏夹是空的 不能设置封面
+	folder := m.CollectFolder{
+		FolderId: snowflake.CreateID(),
+		UserId:   loginUser.Id,
+		Name:     post.Name,
+		Intro:    post.Intro,
+		IsPublic: post.IsPublic,
+		UpdateAt: time.Now(),
+		CreateAt: time.Now(),
+	}
+	err := mongo.CreateFolder(folder)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	ResponseSuccess(ctx, gin.H{
+		"folderId": strconv.FormatInt(folder.FolderId, 10),
+	})
+}
+
+// UpdateFolder 编辑收藏夹
+func UpdateFolder(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("folder")
+	post := ctxData.(m.PostCollectFolder)
+
+	err := mongo.UpdateFolder(post)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	ResponseSuccess(ctx, gin.H{"status": "ok"})
+}
+
+// DeleteFolder 删除收藏夹 作品移回默认收藏夹 不取消收藏
+func DeleteFolder(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("userInfo")
+	loginUser := ctxData.(m.UserTokenPayload)
+
+	ctxData, _ = ctx.Get("folder")
+	folder := ctxData.(m.CollectFolder)
+
+	defaultFolder, err := mongo.EnsureDefaultFolder(loginUser.Id)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	err = mongo.DeleteFolder(folder, defaultFolder)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	ResponseSuccess(ctx, gin.H{"status": "ok"})
+}
+
+// GetFolderArtwork 获取收藏夹中的作品
+func GetFolderArtwork(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("query")
+	query := ctxData.(m.FolderQuery)
+
+	items, err := mongo.GetFolderItems(query.FolderId, query.Page-1)
+	if err != nil {
+		err = errors.Wrap(err, "GetFolderArtwork: mongodb get fail")
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	if len(items) == 0 && query.Page == 1 {
+		ResponseError(ctx, CodeUserNoHaveCollects)
+		return
+	}
+
+	var artIds []string
+	var uIds []string
+	for _, data := range items {
+		artIds = append(artIds, data.MsgId)
+		uIds = append(uIds, data.AuthorId)
+	}
+	artData, findData, err := BatchGetBasicArtInfo(artIds, uIds, getContentViewer(ctx))
+	if err != nil {
+		err = errors.Wrap(err, "GetFolderArtwork BatchGetBasicArtInfo fail")
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	ResponseSuccess(ctx, artData)
+
+	ctx.Set("artData", findData)
+}
+
+// MoveFolderArtwork 把作品移动或复制到另一个收藏夹
+func MoveFolderArtwork(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("move")
+	move := ctxData.(m.MoveFolderArtwork)
+
+	ctxData, _ = ctx.Get("folderItems")
+	items := ctxData.([]m.CollectFolderItem)
+
+	ctxData, _ = ctx.Get("folder")
+	to := ctxData.(m.CollectFolder)
+
+	// 放到目标收藏夹的最前面
+	msgIds := make([]string, 0, len(items))
+	now := time.Now()
+	for i := range items {
+		items[i].Sort = now.UnixMilli()
+		items[i].CreateAt = now
+		msgIds = append(msgIds, items[i].MsgId)
+	}
+
+	err := mongo.AddFolderItems(to, items)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	if !move.IsCopy {
+		err = mongo.RemoveFolderItems(move.FromId, msgIds)
+		if err != nil {
+			ResponseErrorAndLog(ctx, CodeServerBusy, err)
+			return
+		}
+	}
+
+	ResponseSuccess(ctx, gin.H{"msgIds": msgIds})
+}
+
+// SortFolderArtwork 调整收藏夹中作品的顺序
+func SortFolderArtwork(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("sort")
+	data := ctxData.(m.SortFolderArtwork)
+
+	err := mongo.SortFolderItems(data.FolderId, data.MsgIds)
+	if err == mongo.ErrFolderItemNotFound {
+		ResponseError(ctx, CodeParamsError)
+		return
+	}
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	ResponseSuccess(ctx, gin.H{"status": "ok"})
+}
@@ -0,0 +1,205 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"onpaper-api-go/cache"
+	"onpaper-api-go/dao/mongo"
+	"onpaper-api-go/dao/mysql"
+	"onpaper-api-go/logger"
+	m "onpaper-api-go/models"
+	"strconv"
+	"time"
+)
+
+// GetForwardList 获取作品或动态的转发列表 包括转发时说的话
+func GetForwardList(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("query")
+	query := ctxData.(m.ForwardListQuery)
+
+	list, err := mongo.GetForwardList(query)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+	if len(list) == 0 {
+		ResponseSuccess(ctx, make([]struct{}, 0))
+		return
+	}
+
+	uIds := make([]string, 0, len(list))
+	for _, item := range list {
+		uIds = append(uIds, item.UserId)
+	}
+	userMap, err := mysql.GetBatchUserSimpleInfo(uIds)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+	for i, item := range list {
+		user := userMap[item.UserId]
+		list[i].UserName = user.UserName
+		list[i].Avatar = user.Avatar
+		list[i].VTag = user.VTag
+		list[i].VStatus = user.VStatus
+	}
+
+	ResponseSuccess(ctx, list)
+}
+
+// SetForwardNotify 设置被转发的提醒 原作作者和直接转发的动态作者都会收到
+func SetForwardNotify(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("trendInfo")
+	trendInfo := ctxData.(m.SaveTrendInfo)
+
+	info := trendInfo.ForwardInfo
+	if info.Id == 0 {
+		return
+	}
+
+	targets := []m.ForwardInfo{{Id: info.Id, Type: info.Type}}
+	if n := len(info.Chain); n != 0 {
+		parentId, _ := strconv.ParseInt(info.Chain[n-1], 10, 64)
+		targets = append(targets, m.ForwardInfo{Id: parentId, Type: "tr"})
+	}
+
+	// 自己转发自己的 不提醒
+	notified := map[string]struct{}{trendInfo.UserId: {}}
+	for _, target := range targets {
+		author, err := getTargetAuthor(target)
+		if err == errTargetNotFound {
+			continue
+		}
+		if err != nil {
+			logger.ErrZapLog(err, target)
+			continue
+		}
+		if _, ok := notified[author]; ok || author == "" {
+			continue
+		}
+		notified[author] = struct{}{}
+		sendForwardNotify(trendInfo.UserId, author, target, trendInfo.TrendId)
+	}
+}
+
+// sendForwardNotify 按接收者的通知配置发送被转发的提醒
+func sendForwardNotify(senderId, receiverId string, target m.ForwardInfo, trendId int64) {
+	config, _, err := GetUserNotifyConfig(receiverId)
+	if err != nil {
+		logger.ErrZapLog(err, "sendForwardNotify GetUserNotifyConfig fail")
+		return
+	}
+
+	// 设置了不通知 不做操作
+	if config.Forward == 0 {
+		return
+	}
+
+	//仅关注的人 查询是否关注
+	if config.Forward == 2 {
+		isFocus, _err := cache.CheckUserFollow([]string{senderId}, receiverId)
+		if _err != nil {
+			logger.ErrZapLog(_err, "sendForwardNotify CheckUserFollow fail")
+		}
+		// 说明没有关注
+		if isFocus[senderId] == 0 || len(isFocus) == 0 {
+			return
+		}
+	}
+
+	notify := m.NotifyBody{
+		BaseNotify: m.BaseNotify{
+			Type:       "remind",
+			TargetId:   strconv.FormatInt(target.Id, 10),
+			TargetType: target.Type,
+			Action:     "forward",
+			Sender:     m.UserSimpleInfo{UserId: senderId},
+			ReceiverId: receiverId,
+			UpdateAt:   time.Now(),
+		},
+		Content: m.ForwardNotify{TrendId: trendId},
+	}
+	err = mongo.SendRepetitionNotify(notify)
+	if err != nil {
+		logger.ErrZapLog(err, notify)
+	}
+
+	err = mysql.SetNotifyUnread(receiverId, "forward")
+	if err != nil {
+		logger.ErrZapLog(err, notify)
+	}
+}
+
+// GetForwardNotify 获取被转发的提醒
+func GetForwardNotify(ctx *gin.Context) {
+	// 取出 ctx 传递的数据
+	ctxData, _ := ctx.Get("userInfo")
+	userInfo, _ := ctxData.(m.UserTokenPayload)
+	ctxData, _ = ctx.Get("query")
+	queryData, _ := ctxData.(m.NotifyQuery)
+
+	notify, err := mongo.GetForwardNotify(userInfo.Id, queryData.NextId)
+	if err != nil {
+		err = errors.Wrap(err, "GetForwardNotify fail")
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	if notify == nil {
+		ResponseSuccess(ctx, make([]struct{}, 0))
+		ctx.Abort()
+		return
+	}
+
+	var uIds []string   // 查找用户信息
+	var findAw []string // 查找被转发的作品
+	var findTr []int64  // 查找被转发的动态和转发产生的动态
+	for _, n := range notify {
+		uIds = append(uIds, n.Sender.UserId)
+		findTr = append(findTr, n.Content.(m.ForwardNotify).TrendId)
+		if n.TargetType == "aw" {
+			findAw = append(findAw, n.TargetId)
+		} else {
+			i, _ := strconv.ParseInt(n.TargetId, 10, 64)
+			findTr = append(findTr, i)
+		}
+	}
+
+	userMap, err := mysql.GetBatchUserSimpleInfo(uIds)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	artMap, trendMap, findData, err := BatchGetNotifyFactorInfo([]string{userInfo.Id}, findAw, findTr, getContentViewer(ctx))
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	for i, body := range notify {
+		notify[i].Sender = userMap[body.Sender.UserId]
+		content := body.Content.(m.ForwardNotify)
+		if body.TargetType == "aw" {
+			content.Target = artMap[body.TargetId]
+		} else {
+			content.Target = trendMap[body.TargetId]
+		}
+		forward := trendMap[strconv.FormatInt(content.TrendId, 10)]
+		content.Text = forward.Text
+		content.IsDelete = forward.IsDelete
+		notify[i].Content = content
+	}
+
+	ResponseSuccess(ctx, notify)
+	// 清除未读
+	if queryData.NextId == "0" {
+		err = mysql.AckNotifyUnread(userInfo.Id, "forward")
+		if err != nil {
+			logger.ErrZapLog(err, "AckNotifyUnread forward fail")
+		}
+	}
+
+	//需要缓存的数据
+	ctx.Set("artData", findData)
+}
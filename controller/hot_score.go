@@ -0,0 +1,217 @@
+package controller
+
+import (
+	"fmt"
+	"golang.org/x/sync/errgroup"
+	"math"
+	"onpaper-api-go/cache"
+	"onpaper-api-go/dao/mongo"
+	"onpaper-api-go/dao/mysql"
+	"onpaper-api-go/logger"
+	m "onpaper-api-go/models"
+	"onpaper-api-go/settings"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// hotScoreInterval 重新计算热门的间隔 配置修改后下一次生效
+func hotScoreInterval() time.Duration {
+	if settings.Conf.HotScore == nil || settings.Conf.HotInterval <= 0 {
+		return 30 * time.Minute
+	}
+	return time.Duration(settings.Conf.HotInterval) * time.Minute
+}
+
+// RunHotScoreJob 定时计算热门动态 热门作品和分区热门 在服务启动时运行
+func RunHotScoreJob() {
+	for {
+		buildHotScore()
+		time.Sleep(hotScoreInterval())
+	}
+}
+
+// buildHotScore 按互动数据和发布时间计算热度 重新生成 hot:trend:all hot:artwork:all hot:zone:*
+func buildHotScore() {
+	conf := settings.Conf.HotScore
+	if conf == nil {
+		return
+	}
+	interval := hotScoreInterval()
+	// 多个服务只需要一个计算
+	ok, err := cache.LockHotScore(interval / 2)
+	if err != nil {
+		logger.ErrZapLog(err, "buildHotScore lock fail")
+		return
+	}
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	since := now.AddDate(0, 0, -conf.HotWindowDays)
+	var eg errgroup.Group
+
+	var artworks []m.HotCandidate
+	eg.Go(func() (mErr error) {
+		artworks, mErr = mysql.GetHotCandidateArtwork(since)
+		return
+	})
+
+	var trends []m.HotCandidate
+	eg.Go(func() (mErr error) {
+		trends, mErr = mongo.GetHotCandidateTrend(since)
+		return
+	})
+
+	var likes []m.HotInteract
+	eg.Go(func() (mErr error) {
+		likes, mErr = mongo.GetHotInteract("user_like", since)
+		return
+	})
+
+	var collects []m.HotInteract
+	eg.Go(func() (mErr error) {
+		collects, mErr = mongo.GetHotInteract("user_collect", since)
+		return
+	})
+
+	err = eg.Wait()
+	if err != nil {
+		logger.ErrZapLog(err, "buildHotScore get data fail")
+		return
+	}
+
+	// 没有候选时也继续 写入空列表 不保留上一轮的热门
+	candidates := append(artworks, trends...)
+
+	// 缓存中的评论 转发数比数据库新 浏览量只在缓存中
+	live, err := cache.GetHotLiveCount(candidates)
+	if err != nil {
+		logger.ErrZapLog(err, "buildHotScore GetHotLiveCount fail")
+	}
+
+	likeCount := countHotInteract(likes, conf.HotLikerCap)
+	collectCount := countHotInteract(collects, conf.HotLikerCap)
+
+	scores := make([]float64, len(candidates))
+	for i, d := range candidates {
+		comments, forwards, views := d.Comments, d.Forwards, int64(d.Views)
+		if live != nil {
+			comments = liveCountField(live[i].Count, "Comments", comments)
+			forwards = liveCountField(live[i].Count, "Forwards", forwards)
+			views += live[i].Views
+		}
+		key := d.Type + "&" + d.MsgId
+		points := conf.HotLikeWeight*likeCount[key] +
+			conf.HotCollectWeight*collectCount[key] +
+			conf.HotCommentWeight*float64(comments) +
+			conf.HotForwardWeight*float64(forwards) +
+			conf.HotViewWeight*math.Log1p(float64(views))
+		scores[i] = hotScore(points, now.Sub(d.CreateAt), conf.HotGravity)
+	}
+
+	order := make([]int, 0, len(candidates))
+	for i := range candidates {
+		if scores[i] > 0 {
+			order = append(order, i)
+		}
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return scores[order[i]] > scores[order[j]]
+	})
+
+	// 每个分区都要写入 这一轮没有热门的分区写入空列表
+	zoneIndex := make(map[string]int, len(m.HomeZoneList))
+	zones := make(map[int][]string, len(m.HomeZoneList))
+	for i, zone := range m.HomeZoneList {
+		zoneIndex[zone] = i
+		zones[i] = nil
+	}
+
+	var trendMembers, artIds, zoneArtIds, zoneUserIds []string
+	for _, i := range order {
+		d := candidates[i]
+		if len(trendMembers) < conf.HotTrendCount {
+			trendMembers = append(trendMembers, fmt.Sprintf("%s&%s&%s", d.MsgId, d.Type, d.UserId))
+		}
+		if d.Type != "aw" {
+			continue
+		}
+		if len(artIds) < conf.HotArtworkCount {
+			artIds = append(artIds, d.MsgId)
+		}
+		index, ok := zoneIndex[d.Zone]
+		if ok && len(zones[index]) < conf.HotZoneCount {
+			zones[index] = append(zones[index], d.MsgId)
+			zoneArtIds = append(zoneArtIds, d.MsgId)
+			zoneUserIds = append(zoneUserIds, d.UserId)
+		}
+	}
+
+	artData, err := mysql.GetBatchHotArtwork(artIds)
+	if err != nil {
+		logger.ErrZapLog(err, "buildHotScore GetBatchHotArtwork fail")
+		return
+	}
+
+	// 分区热门读取的是作品基础缓存 缓存不存在的作品不会显示
+	basicData, err := mysql.GetBatchBasicShowArtInfo(zoneArtIds, zoneUserIds)
+	if err != nil {
+		logger.ErrZapLog(err, "buildHotScore GetBatchBasicShowArtInfo fail")
+		return
+	}
+	basicIds := make([]string, 0, len(basicData))
+	for _, art := range basicData {
+		basicIds = append(basicIds, art.ArtworkId)
+	}
+	err = cache.BatchSetTypeOfString(fmt.Sprintf(cache.ArtworkBasic, "%s"), basicIds, basicData, time.Hour*24)
+	if err != nil {
+		logger.ErrZapLog(err, "buildHotScore BatchSetTypeOfString fail")
+		return
+	}
+
+	err = cache.SetHotList(trendMembers, artData, zones, interval*3)
+	if err != nil {
+		logger.ErrZapLog(err, "buildHotScore SetHotList fail")
+	}
+}
+
+// countHotInteract 统计每个作品 动态的点赞或收藏数 key 为 type&msgId
+// 同一用户对同一作者最多计算 limit 次 不计算给自己的点赞 收藏
+func countHotInteract(list []m.HotInteract, limit int) map[string]float64 {
+	res := make(map[string]float64)
+	perLiker := make(map[string]int)
+	for _, d := range list {
+		if d.UserId == "" || d.UserId == d.AuthorId {
+			continue
+		}
+		if limit > 0 {
+			liker := d.UserId + "&" + d.AuthorId
+			if perLiker[liker] >= limit {
+				continue
+			}
+			perLiker[liker]++
+		}
+		res[d.Type+"&"+d.MsgId]++
+	}
+	return res
+}
+
+// liveCountField 读取缓存统计中的字段 不存在时使用数据库的值
+func liveCountField(count map[string]string, field string, dbValue int) int {
+	val, err := strconv.Atoi(count[field])
+	if err != nil {
+		return dbValue
+	}
+	return val
+}
+
+// hotScore 按发布时间衰减的热度 points / (小时数 + 2) ^ gravity
+func hotScore(points float64, age time.Duration, gravity float64) float64 {
+	if points <= 0 {
+		return 0
+	}
+	hours := math.Max(age.Hours(), 0)
+	return points / math.Pow(hours+2, gravity)
+}
@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+	"onpaper-api-go/dao/mongo"
+	"onpaper-api-go/dao/mysql"
+	m "onpaper-api-go/models"
+	"sort"
+)
+
+// SaveUserInterest 关注或取消关注标签 话题
+func SaveUserInterest(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("userInfo")
+	userInfo := ctxData.(m.UserTokenPayload)
+
+	ctxData, _ = ctx.Get("interest")
+	data := ctxData.(m.PostInterest)
+
+	if !data.IsCancel {
+		exists, err := mysql.CheckInterestTarget(data.Type, data.TargetId)
+		if err != nil {
+			ResponseErrorAndLog(ctx, CodeServerBusy, err)
+			return
+		}
+		if !exists {
+			ResponseError(ctx, CodeParamsError)
+			return
+		}
+
+		count, err := mysql.CountUserInterest(userInfo.Id, data.Type)
+		if err != nil {
+			ResponseErrorAndLog(ctx, CodeServerBusy, err)
+			return
+		}
+		if count >= m.InterestMaxCount {
+			ResponseError(ctx, CodeInterestLimit)
+			return
+		}
+	}
+
+	_, err := mysql.SaveUserInterest(userInfo.Id, data)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	ResponseSuccess(ctx, data)
+}
+
+// GetUserInterest 获取用户关注的标签或话题列表
+func GetUserInterest(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("query")
+	query := ctxData.(m.InterestQuery)
+
+	list, err := mysql.GetUserInterestList(query.UId, query.Type)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	ResponseSuccess(ctx, list)
+}
+
+// GetInterestFeed 获取关注的标签和话题的 feed
+// 不写入每个用户的feed 查询时按关注的标签 话题拉取 和 feed 一样按 msgId 翻页
+func GetInterestFeed(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("userInfo")
+	userInfo := ctxData.(m.UserTokenPayload)
+
+	ctxData, _ = ctx.Get("nextId")
+	nextId := ctxData.(*int64)
+
+	mute := getFeedMute(userInfo.Id)
+	feedData, next, err := getUnmutedFeed(*nextId, mute, func(msgId int64) ([]m.MongoFeed, error) {
+		return getInterestFeed(userInfo.Id, msgId)
+	})
+	if err != nil {
+		err = errors.Wrap(err, "GetInterestFeed fail")
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	// 如果没有feed 直接返回
+	if len(feedData) == 0 {
+		responseEmptyFeed(ctx, next)
+		return
+	}
+	ctx.Set("feedData", feedData)
+}
+
+// getInterestFeed 按关注的标签和话题拉取一页 feed
+func getInterestFeed(userId string, nextId int64) (feedData []m.MongoFeed, err error) {
+	var eg errgroup.Group
+
+	var artFeed []m.MongoFeed
+	eg.Go(func() (mErr error) {
+		tagIds, mErr := mysql.GetUserInterestIds(userId, "tag")
+		if mErr != nil {
+			return
+		}
+		artFeed, mErr = mysql.GetInterestArtworkFeed(tagIds, userId, nextId)
+		return
+	})
+
+	var trendFeed []m.MongoFeed
+	eg.Go(func() (mErr error) {
+		topicIds, mErr := mysql.GetUserInterestIds(userId, "topic")
+		if mErr != nil {
+			return
+		}
+		trendFeed, mErr = mongo.GetInterestTrendFeed(topicIds, userId, nextId)
+		return
+	})
+
+	err = eg.Wait()
+	if err != nil {
+		return
+	}
+
+	// 作品和动态的id 都是雪花id 合并后按时间倒序 取一页
+	feedData = append(artFeed, trendFeed...)
+	sort.Slice(feedData, func(i, j int) bool {
+		return feedData[i].MsgID > feedData[j].MsgID
+	})
+	if len(feedData) > m.InterestFeedLimit {
+		feedData = feedData[:m.InterestFeedLimit]
+	}
+	for i := range feedData {
+		feedData[i].AcceptId = userId
+	}
+	return
+}
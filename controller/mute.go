@@ -0,0 +1,320 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	c "onpaper-api-go/cache"
+	"onpaper-api-go/dao/mysql"
+	"onpaper-api-go/logger"
+	m "onpaper-api-go/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// muteRetry 一页feed全部被屏蔽时 继续往后查找的次数
+const muteRetry = 3
+
+// NextIdHeader 一页feed全部被屏蔽时 返回下一页 next 的响应头
+const NextIdHeader = "X-Next-Id"
+
+// GetUserMute 获取用户的屏蔽设置 先查缓存
+func GetUserMute(userId string) (mute m.UserMute, err error) {
+	key := fmt.Sprintf(c.UserMute, userId)
+	cache, err := c.GetOneStringValue(key, m.UserMute{})
+	if err != nil {
+		logger.ErrZapLog(err, key)
+	}
+	if cache.HaveCache {
+		mute = cache.Val.(m.UserMute)
+		return
+	}
+
+	mute, err = mysql.GetUserMute(userId)
+	if err != nil {
+		err = errors.Wrap(err, "GetUserMute fail")
+		return
+	}
+
+	err = c.SetOneStringValue(key, mute, 24*time.Hour)
+	if err != nil {
+		logger.ErrZapLog(err, key)
+		err = nil
+	}
+	return
+}
+
+// getFeedMute 获取登录用户的屏蔽设置 出错时不屏蔽
+func getFeedMute(userId string) (mute m.UserMute) {
+	if userId == "" {
+		return
+	}
+	mute, err := GetUserMute(userId)
+	if err != nil {
+		logger.ErrZapLog(err, userId)
+	}
+	return
+}
+
+// filterMutedFeed 去掉屏蔽了的关注用户的feed
+func filterMutedFeed(feeds []m.MongoFeed, mute m.UserMute) []m.MongoFeed {
+	if len(mute.Users) == 0 {
+		return feeds
+	}
+	users := mute.UserMap()
+	res := make([]m.MongoFeed, 0, len(feeds))
+	for _, feed := range feeds {
+		switch users[feed.SendId] {
+		case m.MuteAll:
+			continue
+		case m.MuteTrend:
+			if feed.Type == "tr" {
+				continue
+			}
+		}
+		res = append(res, feed)
+	}
+	return res
+}
+
+// getUnmutedFeed 获取feed并去掉屏蔽的 一页全部被屏蔽时继续往后找 翻页方式不变
+// 找了 muteRetry 页都被屏蔽时返回空列表和已经找到的位置 nextId 为 0 表示没有更多
+func getUnmutedFeed(msgId int64, mute m.UserMute, getFeed func(msgId int64) ([]m.MongoFeed, error)) (res []m.MongoFeed, nextId int64, err error) {
+	for i := 0; i < muteRetry; i++ {
+		feeds, mErr := getFeed(msgId)
+		if mErr != nil || len(feeds) == 0 {
+			return nil, 0, mErr
+		}
+		res = filterMutedFeed(feeds, mute)
+		res, err = filterMutedFeedContent(res, mute)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(feeds) < 30 {
+			return res, 0, nil
+		}
+		msgId = feeds[len(feeds)-1].MsgID
+		nextId = msgId
+		if len(res) != 0 {
+			return
+		}
+	}
+	return
+}
+
+// responseEmptyFeed 没有feed时返回空列表
+// 后面还有feed时 在响应头中返回下一页的 next 客户端用它继续翻页 没有这个头表示已经到底
+func responseEmptyFeed(ctx *gin.Context, nextId int64) {
+	if nextId != 0 {
+		ctx.Header(NextIdHeader, strconv.FormatInt(nextId, 10))
+	}
+	ResponseSuccess(ctx, make([]struct{}, 0))
+	ctx.Abort()
+}
+
+// needContentFilter 是否有需要查询内容才能判断的屏蔽 关键词和屏蔽转发
+func needContentFilter(mute m.UserMute) bool {
+	if len(mute.Words) != 0 {
+		return true
+	}
+	for _, user := range mute.Users {
+		if user.Mute == m.MuteForward {
+			return true
+		}
+	}
+	return false
+}
+
+// getFeedContent 查询feed的内容 先查缓存 缓存里没有转发的内容 有转发的重新查询
+func getFeedContent(feeds []m.MongoFeed) (trends m.TrendList, err error) {
+	ids := make([]string, 0, len(feeds))
+	typeMap := make(map[string]string, len(feeds))
+	for _, feed := range feeds {
+		id := strconv.FormatInt(feed.MsgID, 10)
+		ids = append(ids, id)
+		typeMap[id] = feed.Type
+	}
+
+	cacheTrends, needFind, err := c.GetBatchTrendCache(ids)
+	if err != nil {
+		logger.ErrZapLog(err, "getFeedContent GetBatchTrendCache fail")
+	}
+
+	var findArt []int64
+	var findTrend []int64
+	for _, data := range needFind {
+		id, _ := strconv.ParseInt(data.Id, 10, 64)
+		if typeMap[data.Id] == "aw" {
+			findArt = append(findArt, id)
+		} else {
+			findTrend = append(findTrend, id)
+		}
+	}
+	for _, trend := range cacheTrends {
+		if trend.ForwardInfo.Id != 0 {
+			findTrend = append(findTrend, trend.TrendId)
+			continue
+		}
+		trends = append(trends, trend)
+	}
+
+	artData, _, err := GetTrendInfo(findArt, "aw")
+	if err != nil {
+		err = errors.Wrap(err, "getFeedContent aw fail")
+		return
+	}
+	trendData, _, err := GetTrendInfo(findTrend, "tr")
+	if err != nil {
+		err = errors.Wrap(err, "getFeedContent tr fail")
+		return
+	}
+	trends = append(trends, artData...)
+	trends = append(trends, trendData...)
+	return
+}
+
+// filterMutedFeedContent 去掉屏蔽转发的用户的转发 和包含屏蔽关键词的feed
+// 需要查询内容 放在翻页查找里 一页全部被屏蔽时也能继续往后找
+func filterMutedFeedContent(feeds []m.MongoFeed, mute m.UserMute) (res []m.MongoFeed, err error) {
+	if len(feeds) == 0 || !needContentFilter(mute) {
+		return feeds, nil
+	}
+	trends, err := getFeedContent(feeds)
+	if err != nil {
+		return
+	}
+
+	// 查不到内容的 交给后面按删除处理
+	muted := make(map[int64]bool, len(trends))
+	for _, trend := range trends {
+		muted[trend.TrendId] = true
+	}
+	for _, trend := range filterMutedTrend(trends, mute) {
+		delete(muted, trend.TrendId)
+	}
+
+	res = make([]m.MongoFeed, 0, len(feeds))
+	for _, feed := range feeds {
+		if !muted[feed.MsgID] {
+			res = append(res, feed)
+		}
+	}
+	return
+}
+
+// hasMuteWord 文本是否包含屏蔽的关键词
+func hasMuteWord(words []string, texts ...string) bool {
+	for _, text := range texts {
+		if text == "" {
+			continue
+		}
+		text = strings.ToLower(text)
+		for _, word := range words {
+			if strings.Contains(text, strings.ToLower(word)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// filterMutedTrend 去掉屏蔽转发的用户的转发 和包含屏蔽关键词的动态 作品
+func filterMutedTrend(trends m.TrendList, mute m.UserMute) m.TrendList {
+	if mute.IsEmpty() {
+		return trends
+	}
+	users := mute.UserMap()
+	res := make(m.TrendList, 0, len(trends))
+	for _, trend := range trends {
+		if trend.ForwardInfo.Id != 0 && users[trend.UserId] == m.MuteForward {
+			continue
+		}
+		texts := []string{trend.Intro, trend.Title}
+		if trend.Forward != nil {
+			texts = append(texts, trend.Forward.Intro, trend.Forward.Title)
+		}
+		if hasMuteWord(mute.Words, texts...) {
+			continue
+		}
+		res = append(res, trend)
+	}
+	return res
+}
+
+// GetMuteSetting 获取自己的屏蔽设置
+func GetMuteSetting(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("userInfo")
+	userInfo := ctxData.(m.UserTokenPayload)
+
+	mute, err := mysql.GetUserMute(userInfo.Id)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	ResponseSuccess(ctx, mute)
+}
+
+// UpdateFocusMute 设置关注用户在feed中屏蔽的内容
+func UpdateFocusMute(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("userInfo")
+	userInfo := ctxData.(m.UserTokenPayload)
+
+	ctxData, _ = ctx.Get("focusMute")
+	data := ctxData.(m.PostFocusMute)
+
+	// 只能屏蔽已经关注的用户
+	isFocus, err := mysql.UpdateFocusMute(userInfo.Id, data)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+	if !isFocus {
+		ResponseError(ctx, CodeParamsError)
+		return
+	}
+
+	err = c.DelOneCache(fmt.Sprintf(c.UserMute, userInfo.Id))
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	ResponseSuccess(ctx, data)
+}
+
+// SaveMuteWord 添加或删除屏蔽关键词
+func SaveMuteWord(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("userInfo")
+	userInfo := ctxData.(m.UserTokenPayload)
+
+	ctxData, _ = ctx.Get("muteWord")
+	data := ctxData.(m.PostMuteWord)
+
+	if !data.IsCancel {
+		count, err := mysql.CountMuteWord(userInfo.Id)
+		if err != nil {
+			ResponseErrorAndLog(ctx, CodeServerBusy, err)
+			return
+		}
+		if count >= m.MuteWordMaxCount {
+			ResponseError(ctx, CodeMuteWordLimit)
+			return
+		}
+	}
+
+	err := mysql.SaveMuteWord(userInfo.Id, data)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	err = c.DelOneCache(fmt.Sprintf(c.UserMute, userInfo.Id))
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	ResponseSuccess(ctx, data)
+}
@@ -243,7 +243,7 @@ func GetLikeAndCollectNotify(ctx *gin.Context) {
 		return
 	}
 
-	artMap, trendMap, findData, err := BatchGetNotifyFactorInfo([]string{userInfo.Id}, findAw, findTr)
+	artMap, trendMap, findData, err := BatchGetNotifyFactorInfo([]string{userInfo.Id}, findAw, findTr, getContentViewer(ctx))
 	if err != nil {
 		ResponseErrorAndLog(ctx, CodeServerBusy, err)
 		return
@@ -493,7 +493,7 @@ func GetCommentNotify(ctx *gin.Context) {
 		return
 	}
 
-	artMap, trendMap, findData, err := BatchGetNotifyFactorInfo([]string{userInfo.Id}, findAw, findTr)
+	artMap, trendMap, findData, err := BatchGetNotifyFactorInfo([]string{userInfo.Id}, findAw, findTr, getContentViewer(ctx))
 	if err != nil {
 		ResponseErrorAndLog(ctx, CodeServerBusy, err)
 		return
@@ -580,7 +580,27 @@ func UpdateNotifySetting(ctx *gin.Context) {
 	userInfo, _ := ctxData.(m.UserTokenPayload)
 
 	ctxData, _ = ctx.Get("config")
-	config, _ := ctxData.(m.NotifyConfig)
+	data, _ := ctxData.(m.UpdateNotifyConfig)
+
+	config := m.NotifyConfig{
+		Comment: data.Comment,
+		Like:    data.Like,
+		Collect: data.Collect,
+		Follow:  data.Follow,
+		Message: data.Message,
+		At:      data.At,
+	}
+	// 没有传转发设置 保留原来的
+	if data.Forward != nil {
+		config.Forward = *data.Forward
+	} else {
+		old, _, err := GetUserNotifyConfig(userInfo.Id)
+		if err != nil {
+			ResponseErrorAndLog(ctx, CodeServerBusy, err)
+			return
+		}
+		config.Forward = old.Forward
+	}
 
 	err := mysql.SettingNotifySetting(userInfo.Id, config)
 	if err != nil {
@@ -590,6 +610,7 @@ func UpdateNotifySetting(ctx *gin.Context) {
 
 	ResponseSuccess(ctx, config)
 	ctx.Set("userId", userInfo.Id)
+	ctx.Set("config", config)
 }
 
 // SetFocusNotify 设置关注通知
@@ -716,6 +737,44 @@ func SetCommissionNotify(ctx *gin.Context) {
 	}
 }
 
+// SetCommissionRemind 设置约稿纠纷 报价等提醒
+func SetCommissionRemind(ctx *gin.Context) {
+	ctxData, ok := ctx.Get("commissionRemind")
+	// 如果没有传则不需要设置通知
+	if !ok {
+		return
+	}
+	remind := ctxData.(m.CommissionRemind)
+
+	ctxData, _ = ctx.Get("userInfo")
+	loginUser := ctxData.(m.UserTokenPayload)
+
+	for _, receiver := range remind.Receivers {
+		notify := m.NotifyBody{
+			BaseNotify: m.BaseNotify{
+				Type:       "remind",
+				TargetId:   strconv.FormatInt(remind.InviteId, 10),
+				TargetType: "com",
+				Action:     remind.Action, // 纠纷/报价状态变化
+				Sender:     m.UserSimpleInfo{UserId: loginUser.Id},
+				ReceiverId: receiver,
+				UpdateAt:   time.Now(),
+			},
+			Content: m.NotifyCommissionInfo{Status: remind.Status}, // 纠纷/报价状态
+		}
+
+		err := mongo.SendRepetitionNotify(notify)
+		if err != nil {
+			logger.ErrZapLog(err, notify)
+		}
+
+		err = mysql.SetNotifyUnread(receiver, "commission")
+		if err != nil {
+			logger.ErrZapLog(err, notify)
+		}
+	}
+}
+
 // GetCommission 获取约稿的通知
 func GetCommission(ctx *gin.Context) {
 	ctxData, _ := ctx.Get("userInfo")
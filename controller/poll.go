@@ -0,0 +1,263 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"golang.org/x/sync/errgroup"
+	"onpaper-api-go/cache"
+	"onpaper-api-go/dao/mongo"
+	"onpaper-api-go/dao/mysql"
+	"onpaper-api-go/logger"
+	m "onpaper-api-go/models"
+	"strconv"
+	"time"
+)
+
+// pollFlushInterval 投票统计落盘的间隔
+const pollFlushInterval = time.Minute
+
+// pollFlushBatch 每次落盘的投票数
+const pollFlushBatch = 200
+
+// SavePollVote 给动态的投票投票 每个用户只能投一次
+func SavePollVote(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("userInfo")
+	userInfo := ctxData.(m.UserTokenPayload)
+
+	ctxData, _ = ctx.Get("pollVote")
+	data := ctxData.(m.PostPollVote)
+
+	info, err := mongo.GetTrendPollInfo(data.TrendId)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+	if info.Poll == nil || (info.WhoSee == "privacy" && info.UserId != userInfo.Id) {
+		ResponseError(ctx, CodeTrendNoExists)
+		return
+	}
+	// 仅粉丝可见的动态 需要关注了作者才能投票
+	if info.WhoSee == "onlyFans" && info.UserId != userInfo.Id {
+		focus, mErr := mysql.CheckUserFocus([]string{userInfo.Id}, info.UserId)
+		if mErr != nil {
+			ResponseErrorAndLog(ctx, CodeServerBusy, mErr)
+			return
+		}
+		if len(focus) == 0 || focus[0].IsFocus == 0 {
+			ResponseError(ctx, CodeUnPermission)
+			return
+		}
+	}
+	if time.Now().After(info.Poll.EndAt) {
+		ResponseError(ctx, CodePollClosed)
+		return
+	}
+
+	choices, ok := verifyPollChoices(data.Choices, *info.Poll)
+	if !ok {
+		ResponseError(ctx, CodeParamsError)
+		return
+	}
+
+	// 先保存投票记录 保证每个用户只计数一次
+	isNew, err := mongo.SavePollVote(m.PollVote{
+		TrendId:  data.TrendId,
+		UserId:   userInfo.Id,
+		Choices:  choices,
+		CreateAt: time.Now(),
+	})
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+	if !isNew {
+		ResponseError(ctx, CodePollVoted)
+		return
+	}
+
+	// 计数失败时删除投票记录 让用户可以重新投票
+	count, err := cache.SetPollVote(strconv.FormatInt(data.TrendId, 10), *info.Poll, choices)
+	if err != nil {
+		mErr := mongo.DeletePollVote(data.TrendId, userInfo.Id)
+		if mErr != nil {
+			logger.ErrZapLog(mErr, data.TrendId)
+		}
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	poll := applyPollCount(*info.Poll, count, choices, info.UserId == userInfo.Id)
+	ResponseSuccess(ctx, poll)
+}
+
+// verifyPollChoices 验证选择的选项 去掉重复的 单选只能选一个
+func verifyPollChoices(choices []int, poll m.TrendPoll) (res []int, ok bool) {
+	seen := make(map[int]struct{}, len(choices))
+	for _, choice := range choices {
+		if choice < 0 || choice >= len(poll.Options) {
+			return nil, false
+		}
+		if _, ok := seen[choice]; ok {
+			continue
+		}
+		seen[choice] = struct{}{}
+		res = append(res, choice)
+	}
+	if len(res) == 0 || (!poll.Multiple && len(res) > 1) {
+		return nil, false
+	}
+	return res, true
+}
+
+// applyPollCount 合成投票的统计和登录用户的选择 返回新的投票 不修改缓存的数据
+// 没有投票并且没有结束时 不显示每个选项的票数 作者可以看到
+func applyPollCount(poll m.TrendPoll, count map[string]string, choices []int, isOwner bool) m.TrendPoll {
+	res := poll
+	res.Options = make([]m.PollOption, len(poll.Options))
+	copy(res.Options, poll.Options)
+
+	// 缓存的票数比数据库新
+	for i := range res.Options {
+		if votes, err := strconv.Atoi(count[strconv.Itoa(i)]); err == nil {
+			res.Options[i].Votes = votes
+		}
+	}
+	if voters, err := strconv.Atoi(count["Voters"]); err == nil {
+		res.Voters = voters
+	}
+
+	res.Choices = choices
+	if res.Choices == nil {
+		res.Choices = make([]int, 0)
+	}
+	res.IsEnd = time.Now().After(res.EndAt)
+	res.ShowResult = res.IsEnd || len(choices) != 0 || isOwner
+	if !res.ShowResult {
+		for i := range res.Options {
+			res.Options[i].Votes = 0
+		}
+	}
+	return res
+}
+
+// fillTrendPoll 给动态和转发的投票 填充票数和登录用户的选择
+// 只替换 Poll 和 Forward 指针 不修改需要缓存的数据
+func fillTrendPoll(trends m.TrendList, loginId string) {
+	var strIds []string
+	var intIds []int64
+	for _, trend := range trends {
+		if trend.Poll != nil {
+			strIds = append(strIds, strconv.FormatInt(trend.TrendId, 10))
+			intIds = append(intIds, trend.TrendId)
+		}
+		if trend.Forward != nil && trend.Forward.Poll != nil {
+			strIds = append(strIds, strconv.FormatInt(trend.Forward.TrendId, 10))
+			intIds = append(intIds, trend.Forward.TrendId)
+		}
+	}
+	if len(strIds) == 0 {
+		return
+	}
+
+	var eg errgroup.Group
+
+	countMap := map[int64]map[string]string{}
+	eg.Go(func() (mErr error) {
+		countMap, mErr = cache.GetPollCount(strIds)
+		if mErr != nil {
+			logger.ErrZapLog(mErr, "fillTrendPoll GetPollCount fail")
+		}
+		// 出错就用数据库的票数
+		return nil
+	})
+
+	choiceMap := map[int64][]int{}
+	eg.Go(func() (mErr error) {
+		choiceMap, mErr = mongo.GetUserPollVote(intIds, loginId)
+		if mErr != nil {
+			logger.ErrZapLog(mErr, "fillTrendPoll GetUserPollVote fail")
+		}
+		return nil
+	})
+	_ = eg.Wait()
+
+	for i, trend := range trends {
+		if trend.Poll != nil {
+			poll := applyPollCount(*trend.Poll, countMap[trend.TrendId], choiceMap[trend.TrendId], trend.UserId == loginId)
+			trends[i].Poll = &poll
+		}
+		if trend.Forward != nil && trend.Forward.Poll != nil {
+			forward := *trend.Forward
+			poll := applyPollCount(*forward.Poll, countMap[forward.TrendId], choiceMap[forward.TrendId], forward.UserId == loginId)
+			forward.Poll = &poll
+			trends[i].Forward = &forward
+		}
+	}
+}
+
+// RunPollFlusher 定时把缓存中的投票统计落盘 在服务启动时运行
+func RunPollFlusher() {
+	ticker := time.NewTicker(pollFlushInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		flushPollCount()
+	}
+}
+
+// flushPollCount 落盘有新投票的统计 多个服务同时运行时 SPOP 保证不会重复
+func flushPollCount() {
+	for {
+		trendIds, err := cache.PopDirtyPoll(pollFlushBatch)
+		if err != nil {
+			logger.ErrZapLog(err, "flushPollCount PopDirtyPoll fail")
+			return
+		}
+		if len(trendIds) == 0 {
+			return
+		}
+
+		countMap, err := cache.GetPollCount(trendIds)
+		if err != nil {
+			logger.ErrZapLog(err, "flushPollCount GetPollCount fail")
+			restoreDirtyPoll(trendIds)
+			return
+		}
+
+		counts := make([]m.PollCount, 0, len(countMap))
+		for trendId, count := range countMap {
+			data := m.PollCount{TrendId: trendId, Votes: make(map[int]int)}
+			for field, val := range count {
+				num, mErr := strconv.Atoi(val)
+				if mErr != nil {
+					continue
+				}
+				if field == "Voters" {
+					data.Voters = num
+					continue
+				}
+				if index, mErr := strconv.Atoi(field); mErr == nil {
+					data.Votes[index] = num
+				}
+			}
+			counts = append(counts, data)
+		}
+
+		err = mongo.UpdatePollCount(counts)
+		if err != nil {
+			logger.ErrZapLog(err, "flushPollCount UpdatePollCount fail")
+			restoreDirtyPoll(trendIds)
+			return
+		}
+		if len(trendIds) < pollFlushBatch {
+			return
+		}
+	}
+}
+
+// restoreDirtyPoll 落盘失败 放回去下次再落盘
+func restoreDirtyPoll(trendIds []string) {
+	err := cache.AddDirtyPoll(trendIds)
+	if err != nil {
+		logger.ErrZapLog(err, "restoreDirtyPoll fail")
+	}
+}
@@ -0,0 +1,322 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+	"math"
+	"onpaper-api-go/cache"
+	"onpaper-api-go/dao/mongo"
+	"onpaper-api-go/dao/mysql"
+	"onpaper-api-go/logger"
+	m "onpaper-api-go/models"
+	"sort"
+	"time"
+)
+
+// RecommendBuildInterval 重新计算推荐的间隔
+const RecommendBuildInterval = 6 * time.Hour
+
+// recommendSaveBatch 每次写入缓存的用户数
+const recommendSaveBatch = 500
+
+type scoredId struct {
+	id    string
+	score float64
+}
+
+// RunRecommendBuilder 定时计算推荐 在服务启动时运行
+func RunRecommendBuilder() {
+	buildRecommend()
+
+	ticker := time.NewTicker(RecommendBuildInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		buildRecommend()
+	}
+}
+
+// buildRecommend 按最近的点赞收藏计算作品共现 生成每个用户的推荐作品和推荐用户
+func buildRecommend() {
+	// 多个服务只需要一个计算
+	ok, err := cache.LockRecommendBuild(RecommendBuildInterval / 2)
+	if err != nil {
+		logger.ErrZapLog(err, "buildRecommend lock fail")
+		return
+	}
+	if !ok {
+		return
+	}
+
+	since := time.Now().AddDate(0, 0, -m.RecommendWindowDays)
+	var eg errgroup.Group
+
+	var likes []m.RecommendInteract
+	eg.Go(func() (mErr error) {
+		likes, mErr = mongo.GetRecentArtInteract("user_like", since)
+		return
+	})
+
+	var collects []m.RecommendInteract
+	eg.Go(func() (mErr error) {
+		collects, mErr = mongo.GetRecentArtInteract("user_collect", since)
+		return
+	})
+
+	var edges []m.FocusEdge
+	eg.Go(func() (mErr error) {
+		edges, mErr = mysql.GetAllFocusEdge()
+		return
+	})
+
+	err = eg.Wait()
+	if err != nil {
+		logger.ErrZapLog(err, "buildRecommend get data fail")
+		return
+	}
+
+	// 1. 每个用户最近互动过的作品 收藏比点赞权重更高
+	userItems := make(map[string]map[string]float64)
+	authors := make(map[string]string)
+	addInteract := func(list []m.RecommendInteract, weight float64) {
+		for _, d := range list {
+			if d.UserId == "" || d.MsgId == "" || d.UserId == d.AuthorId {
+				continue
+			}
+			items := userItems[d.UserId]
+			if items == nil {
+				items = make(map[string]float64)
+				userItems[d.UserId] = items
+			}
+			if _, ok := items[d.MsgId]; !ok && len(items) >= m.RecommendUserItemLimit {
+				continue
+			}
+			items[d.MsgId] += weight
+			authors[d.MsgId] = d.AuthorId
+		}
+	}
+	addInteract(collects, 2)
+	addInteract(likes, 1)
+
+	// 2. 作品共现 互动越多的用户 单次共现的贡献越小
+	itemCount := make(map[string]float64)
+	coCount := make(map[string]map[string]float64)
+	addCo := func(a, b string, w float64) {
+		row := coCount[a]
+		if row == nil {
+			row = make(map[string]float64)
+			coCount[a] = row
+		}
+		row[b] += w
+	}
+	for _, items := range userItems {
+		ids := make([]string, 0, len(items))
+		for id := range items {
+			ids = append(ids, id)
+			itemCount[id]++
+		}
+		w := 1 / math.Log(2+float64(len(ids)))
+		for i := 0; i < len(ids); i++ {
+			for j := i + 1; j < len(ids); j++ {
+				addCo(ids[i], ids[j], w)
+				addCo(ids[j], ids[i], w)
+			}
+		}
+	}
+
+	// 3. 余弦相似度 每个作品只保留最相似的部分
+	similar := make(map[string][]scoredId, len(coCount))
+	for a, row := range coCount {
+		scores := make(map[string]float64, len(row))
+		for b, co := range row {
+			scores[b] = co / math.Sqrt(itemCount[a]*itemCount[b])
+		}
+		similar[a] = topScores(scores, m.RecommendSimilarLimit)
+	}
+	coCount = nil
+
+	// 4. 推荐作品 排除互动过的和自己的作品
+	artRecommend := make(map[string]map[string]float64, len(userItems))
+	for userId, items := range userItems {
+		scores := make(map[string]float64)
+		for itemId, weight := range items {
+			for _, s := range similar[itemId] {
+				if _, ok := items[s.id]; ok || authors[s.id] == userId {
+					continue
+				}
+				scores[s.id] += weight * s.score
+			}
+		}
+		artRecommend[userId] = scoreMap(topScores(scores, m.RecommendArtworkLimit))
+	}
+
+	// 5. 推荐用户 关注的人也关注的用户 和喜欢过作品但还没关注的作者
+	follows := make(map[string]map[string]bool)
+	for _, e := range edges {
+		if follows[e.UserId] == nil {
+			follows[e.UserId] = make(map[string]bool)
+		}
+		follows[e.UserId][e.FocusId] = true
+	}
+	userRecommend := make(map[string]map[string]float64)
+	buildUser := func(userId string) {
+		scores := make(map[string]float64)
+		for f := range follows[userId] {
+			for g := range follows[f] {
+				scores[g]++
+			}
+		}
+		for itemId, weight := range userItems[userId] {
+			scores[authors[itemId]] += weight
+		}
+		for itemId, score := range artRecommend[userId] {
+			scores[authors[itemId]] += score
+		}
+		delete(scores, "")
+		delete(scores, userId)
+		for f := range follows[userId] {
+			delete(scores, f)
+		}
+		userRecommend[userId] = scoreMap(topScores(scores, m.RecommendUserLimit))
+	}
+	for userId := range userItems {
+		buildUser(userId)
+	}
+	for userId := range follows {
+		if _, ok := userRecommend[userId]; !ok {
+			buildUser(userId)
+		}
+	}
+
+	// 6. 写入缓存 推荐在下次计算前过期也不影响 会使用热门数据
+	expire := RecommendBuildInterval * 4
+	saveRecommend(cache.RecommendArtwork, artRecommend, expire)
+	saveRecommend(cache.RecommendUser, userRecommend, expire)
+}
+
+// saveRecommend 分批写入推荐缓存
+func saveRecommend(fmtKey string, data map[string]map[string]float64, expire time.Duration) {
+	batch := make(map[string]map[string]float64, recommendSaveBatch)
+	for userId, list := range data {
+		batch[userId] = list
+		if len(batch) < recommendSaveBatch {
+			continue
+		}
+		err := cache.SetRecommendList(fmtKey, batch, expire)
+		if err != nil {
+			logger.ErrZapLog(err, "saveRecommend fail")
+		}
+		batch = make(map[string]map[string]float64, recommendSaveBatch)
+	}
+	err := cache.SetRecommendList(fmtKey, batch, expire)
+	if err != nil {
+		logger.ErrZapLog(err, "saveRecommend fail")
+	}
+}
+
+// topScores 按分数倒序取前 limit 个
+func topScores(scores map[string]float64, limit int) []scoredId {
+	list := make([]scoredId, 0, len(scores))
+	for id, score := range scores {
+		list = append(list, scoredId{id: id, score: score})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].score > list[j].score
+	})
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list
+}
+
+func scoreMap(list []scoredId) map[string]float64 {
+	res := make(map[string]float64, len(list))
+	for _, s := range list {
+		res[s.id] = s.score
+	}
+	return res
+}
+
+// GetRecommendArtwork 获取为用户推荐的作品 没有推荐数据时返回热门作品
+func GetRecommendArtwork(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("userInfo")
+	loginInfo, _ := ctxData.(m.UserTokenPayload)
+
+	ctxData, _ = ctx.Get("query")
+	query := ctxData.(m.RecommendQuery)
+
+	var dataList []m.ArtIdAndUid
+	var total int64
+	if loginInfo.Id != "" {
+		var artIds []string
+		var err error
+		artIds, total, err = cache.GetRecommendArtId(loginInfo.Id, query.Page, m.RecommendPageSize)
+		if err != nil {
+			ResponseErrorAndLog(ctx, CodeServerBusy, err)
+			return
+		}
+		// 推荐生成后作品可能被删除或修改权限
+		dataList, err = mysql.FilterPublicArtwork(artIds)
+		if err != nil {
+			ResponseErrorAndLog(ctx, CodeServerBusy, err)
+			return
+		}
+	}
+
+	// 未登录或者还没有推荐数据的用户
+	if total == 0 {
+		var err error
+		dataList, err = mysql.GetChannelArtwork(m.QueryChanelType{
+			NextId: "0",
+			Zone:   "all",
+			Sort:   "hot",
+			Page:   int(query.Page) + 1,
+		})
+		if err != nil {
+			ResponseErrorAndLog(ctx, CodeServerBusy, err)
+			return
+		}
+	}
+
+	artIds := make([]string, 0, len(dataList))
+	userIds := make([]string, 0, len(dataList))
+	for _, data := range dataList {
+		artIds = append(artIds, data.ArtworkId)
+		userIds = append(userIds, data.AuthorId)
+	}
+
+	artData, findData, err := BatchGetBasicArtInfo(artIds, userIds, getContentViewer(ctx))
+	if err != nil {
+		err = errors.Wrap(err, "GetRecommendArtwork fail")
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+	if len(artData) == 0 {
+		artData = make([]m.BasicArtwork, 0)
+	}
+	ResponseSuccess(ctx, artData)
+
+	ctx.Set("artData", findData)
+}
+
+// bigCardToHotUser 不在热门中的推荐用户 转换成和热门用户一样的数据
+func bigCardToHotUser(user m.UserBigCard) m.HotUser {
+	covers := make([]m.ArtworkCover, 0, len(user.Artworks))
+	for _, art := range user.Artworks {
+		covers = append(covers, m.ArtworkCover{
+			ArtworkId: art.ArtworkId,
+			UserId:    art.UserId,
+			Cover:     art.Cover,
+		})
+	}
+	return m.HotUser{
+		UserId:   user.UserId,
+		Avatar:   user.Avatar,
+		UserName: user.UserName,
+		Artworks: covers,
+		Count:    user.Count.UserAllCount,
+		VTag:     user.VTag,
+		VStatus:  user.VStatus,
+	}
+}
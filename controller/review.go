@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"onpaper-api-go/dao/mongo"
+	"onpaper-api-go/dao/mysql"
+	"onpaper-api-go/logger"
+	m "onpaper-api-go/models"
+	"onpaper-api-go/utils/phash"
+	"strconv"
+	"time"
+)
+
+// errSimilarTakenDown 作品的图片和已下架的作品相同
+var errSimilarTakenDown = errors.New("similar to taken down artwork")
+
+// matchSimilarArtwork 用作品图片的感知哈希 查找其他用户的相似作品
+// 与已下架作品相似返回 errSimilarTakenDown 与其他作品相似时作品进入审核
+func matchSimilarArtwork(info *m.SaveArtworkInfo, hashes map[string]uint64) (err error) {
+	similar, err := mongo.FindSimilarPictures(info.UserId, hashes)
+	if err != nil || len(similar) == 0 {
+		return
+	}
+
+	artworkIds := make([]string, 0, len(similar))
+	for _, picture := range similar {
+		artworkIds = append(artworkIds, picture.ArtworkId)
+	}
+	states, err := mysql.GetArtworkStates(artworkIds)
+	if err != nil {
+		return
+	}
+	stateMap := make(map[string]uint8, len(states))
+	for _, state := range states {
+		stateMap[state.ArtworkId] = state.State
+	}
+
+	// 只比对还没有删除的作品
+	matched := make([]m.SimilarPicture, 0, len(similar))
+	for _, picture := range similar {
+		state, ok := stateMap[picture.ArtworkId]
+		if !ok {
+			continue
+		}
+		if state == m.ArtStateTakenDown {
+			err = errSimilarTakenDown
+			return
+		}
+		matched = append(matched, picture)
+	}
+
+	if len(matched) > 0 {
+		info.State = m.ArtStateReview
+		info.Similar = matched
+	}
+	return
+}
+
+// saveArtworkHashes 保存图片哈希索引 需要审核的作品加入审核列表
+func saveArtworkHashes(info *m.SaveArtworkInfo, hashes map[string]uint64) (err error) {
+	err = savePictureHashes(info.ArtworkId, info.UserId, hashes)
+	if err != nil {
+		return
+	}
+
+	if info.State != m.ArtStateReview {
+		return
+	}
+	now := time.Now()
+	err = mongo.CreateArtworkReview(m.ArtworkReview{
+		ArtworkId: info.ArtworkId,
+		UserId:    info.UserId,
+		Reason:    "duplicate",
+		Similar:   info.Similar,
+		Status:    0,
+		UpdateAt:  now,
+		CreateAt:  now,
+	})
+	return
+}
+
+// savePictureHashes 保存图片哈希索引
+func savePictureHashes(artworkId int64, userId string, hashes map[string]uint64) (err error) {
+	now := time.Now()
+	list := make([]m.PictureHash, 0, len(hashes))
+	for fileName, hash := range hashes {
+		list = append(list, m.PictureHash{
+			ArtworkId: artworkId,
+			UserId:    userId,
+			FileName:  fileName,
+			Hash:      int64(hash),
+			Bands:     phash.Bands(hash),
+			CreateAt:  now,
+		})
+	}
+	err = mongo.SavePictureHashes(list)
+	return
+}
+
+// GetArtworkReviewList 管理员获取审核列表
+func GetArtworkReviewList(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("query")
+	query := ctxData.(m.ReviewListQuery)
+
+	reviews, err := mongo.GetArtworkReviewList(query)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	ResponseSuccess(ctx, reviews)
+}
+
+// SaveReviewDecision 管理员审核作品 通过后推送给粉丝 下架后作者以外不可见
+func SaveReviewDecision(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("userInfo")
+	loginUser := ctxData.(m.UserTokenPayload)
+
+	ctxData, _ = ctx.Get("decision")
+	decision := ctxData.(m.ReviewDecision)
+
+	ctxData, _ = ctx.Get("review")
+	review := ctxData.(m.ArtworkReview)
+
+	state, status := m.ArtStateNormal, int8(1)
+	if decision.Decision == "takedown" {
+		state, status = m.ArtStateTakenDown, 2
+	}
+
+	first, err := mysql.SetArtworkState(review.ArtworkId, state)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	err = mongo.SaveReviewDecision(review.ArtworkId, status, loginUser.Id, decision.Remark)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	ResponseSuccess(ctx, gin.H{"status": status})
+
+	err = delArtworkCache(strconv.FormatInt(review.ArtworkId, 10), review.UserId)
+	if err != nil {
+		logger.ErrZapLog(err, review.ArtworkId)
+	}
+
+	// 第一次公开的作品 推送到粉丝的feed 修改图片后重新审核的作品已经推送过
+	if first {
+		ctx.Set("feed", m.UploadArtOrTrend{
+			MsgID:  review.ArtworkId,
+			SendId: review.UserId,
+			Type:   "aw",
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	mongodb "go.mongodb.org/mongo-driver/mongo"
+	"onpaper-api-go/cache"
+	"onpaper-api-go/dao/mongo"
+	"onpaper-api-go/dao/mysql"
+	"onpaper-api-go/logger"
+	m "onpaper-api-go/models"
+	"onpaper-api-go/settings"
+	"onpaper-api-go/utils/oss"
+	"strconv"
+	"time"
+)
+
+// ReplaceArtPicture 替换 添加 调整作品的图片 作品id和互动数据不变
+func ReplaceArtPicture(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("userInfo")
+	userInfo := ctxData.(m.UserTokenPayload)
+
+	ctxData, _ = ctx.Get("picture")
+	data := ctxData.(m.ReplaceArtPicture)
+
+	ctxData, _ = ctx.Get("fileList")
+	fileList := ctxData.([]*m.PicsType)
+
+	ctxData, _ = ctx.Get("firstPic")
+	firstPic := ctxData.(string)
+
+	ctxData, _ = ctx.Get("artSnapshot")
+	before := ctxData.(m.ArtworkSnapshot)
+
+	// 新加入的图片需要计算哈希和主色 和上传作品一样在图片检查完成前等待审核
+	var newFiles int
+	for _, file := range fileList {
+		if !hasPicture(before.Pictures, file.FileName) {
+			newFiles++
+		}
+	}
+
+	artworkId, _ := strconv.ParseInt(data.ArtworkId, 10, 64)
+	err := mysql.ReplaceArtworkPictures(data, fileList, firstPic)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+	// 已下架和已经在审核的作品状态不变
+	if newFiles != 0 {
+		state, sErr := mysql.GetArtworkState(artworkId)
+		if sErr != nil {
+			ResponseErrorAndLog(ctx, CodeServerBusy, sErr)
+			return
+		}
+		if state == m.ArtStateNormal {
+			_, err = mysql.SetArtworkState(artworkId, m.ArtStateReview)
+			if err != nil {
+				ResponseErrorAndLog(ctx, CodeServerBusy, err)
+				return
+			}
+		}
+	}
+
+	saveArtworkRevision(data.ArtworkId, userInfo.Id, before, data.ShowUpdate)
+	deleteReplacedPictures(userInfo.Id, artworkId, before, data.Cover, fileList)
+
+	err = delArtworkCache(data.ArtworkId, userInfo.Id)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	// 新的图片需要压缩 同时计算哈希和主色
+	err = cache.SendCompressQueue(userInfo.Id, artworkId, "aw")
+	if err != nil {
+		logger.ErrZapLog(err, data.ArtworkId)
+	}
+
+	ResponseSuccess(ctx, gin.H{"status": "ok"})
+}
+
+// GetArtworkRevisions 获取作品的修改记录
+func GetArtworkRevisions(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("query")
+	query := ctxData.(m.RevisionQuery)
+
+	revisions, err := mongo.GetArtworkRevisions(query.ArtworkId)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	ResponseSuccess(ctx, revisions)
+}
+
+// GetRevisionDiff 比较作品的两个版本
+func GetRevisionDiff(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("query")
+	query := ctxData.(m.RevisionQuery)
+
+	if query.From >= query.To {
+		ResponseError(ctx, CodeParamsError)
+		return
+	}
+
+	from, err := mongo.GetArtworkRevision(query.ArtworkId, query.From)
+	if err != nil {
+		responseRevisionError(ctx, err)
+		return
+	}
+	to, err := mongo.GetArtworkRevision(query.ArtworkId, query.To)
+	if err != nil {
+		responseRevisionError(ctx, err)
+		return
+	}
+
+	ResponseSuccess(ctx, gin.H{
+		"from":    from,
+		"to":      to,
+		"changes": m.DiffSnapshot(from.Snapshot, to.Snapshot),
+	})
+}
+
+// responseRevisionError 版本不存在时返回参数错误
+func responseRevisionError(ctx *gin.Context, err error) {
+	if err == mongodb.ErrNoDocuments {
+		ResponseError(ctx, CodeParamsError)
+		return
+	}
+	ResponseErrorAndLog(ctx, CodeServerBusy, err)
+}
+
+// saveArtworkRevision 对比修改前后的作品 有修改时保存一个版本 出错只记录日志
+func saveArtworkRevision(artworkId, userId string, before m.ArtworkSnapshot, showUpdate bool) {
+	after, err := mysql.GetArtworkSnapshot(artworkId)
+	if err != nil {
+		logger.ErrZapLog(err, artworkId)
+		return
+	}
+
+	changes := m.DiffSnapshot(before, after)
+	if len(changes) == 0 {
+		return
+	}
+
+	err = mongo.SaveArtworkRevision(before, m.ArtworkRevision{
+		ArtworkId:  artworkId,
+		UserId:     userId,
+		Snapshot:   after,
+		Changes:    changes,
+		ShowUpdate: showUpdate,
+		CreateAt:   time.Now(),
+	})
+	if err != nil {
+		logger.ErrZapLog(err, artworkId)
+	}
+}
+
+// deleteReplacedPictures 删除作品不再使用的图片和封面 原图 预览图和图片哈希都删除
+func deleteReplacedPictures(userId string, artworkId int64, before m.ArtworkSnapshot, cover string, fileList []*m.PicsType) {
+	used := make(map[string]bool, len(fileList)+1)
+	used[cover] = true
+	for _, file := range fileList {
+		used[file.FileName] = true
+	}
+
+	fileNames := []string{before.Cover}
+	for _, pic := range before.Pictures {
+		fileNames = append(fileNames, pic.FileName)
+	}
+	var removed, keys []string
+	for _, fileName := range fileNames {
+		if fileName == "" || used[fileName] {
+			continue
+		}
+		used[fileName] = true
+		removed = append(removed, fileName)
+		keys = append(keys, "artworks/"+userId+"/"+fileName)
+	}
+	if len(keys) == 0 {
+		return
+	}
+
+	err := mongo.DeletePictureHashes(artworkId, removed)
+	if err != nil {
+		logger.ErrZapLog(err, artworkId)
+	}
+
+	for _, bucket := range []string{settings.Conf.OriginalBucket, settings.Conf.PreviewBucket} {
+		err := oss.DeleteOssObjects(bucket, keys)
+		if err != nil {
+			logger.ErrZapLog(err, "deleteReplacedPictures DeleteOssObjects fail")
+		}
+	}
+}
+
+// hasPicture 图片是否在作品原来的图片中
+func hasPicture(pictures []m.ArtworkPicture, fileName string) bool {
+	for _, pic := range pictures {
+		if pic.FileName == fileName {
+			return true
+		}
+	}
+	return false
+}
@@ -3,6 +3,7 @@ package controller
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
 	"golang.org/x/sync/errgroup"
@@ -29,7 +30,7 @@ func GetTagArtwork(ctx *gin.Context) {
 	if cacheData.HaveCache {
 		dataList = cacheData.Val.([]m.ArtIdAndUid)
 	} else {
-		res, err := mysql.GetTagArtworkId(queryData.TagId, queryData.Sort, queryData.Page-1)
+		res, err := mysql.GetTagArtworkId(queryData.TagId, queryData.Sort, queryData.Page-1, queryData.LicenseFilter, queryData.ColorFilter)
 		if err != nil {
 			ResponseErrorAndLog(ctx, CodeServerBusy, err)
 			return
@@ -57,7 +58,7 @@ func GetTagArtwork(ctx *gin.Context) {
 	var artData []m.BasicArtwork
 	var findData []m.BasicArtwork
 	eg.Go(func() (mErr error) {
-		artData, findData, mErr = BatchGetBasicArtInfo(artIds, userIds)
+		artData, findData, mErr = BatchGetBasicArtInfo(artIds, userIds, getContentViewer(ctx))
 		if mErr != nil {
 			mErr = errors.Wrap(mErr, "GetTagArtwork BatchGetBasicArtInfo fail")
 		}
@@ -161,6 +162,18 @@ func GetRelevantTags(ctx *gin.Context) {
 		return
 	})
 
+	// 父子标签和别名
+	var parent *m.ArtworkTag
+	var children []m.ArtworkTag
+	var aliases []string
+	eg.Go(func() (err error) {
+		parent, children, aliases, err = mysql.GetTagGraph(queryData.TagId)
+		if err != nil {
+			err = errors.Wrap(err, "GetRelevantTags fail")
+		}
+		return
+	})
+
 	err := eg.Wait()
 	if err != nil {
 		if errors.Cause(err) == sql.ErrNoRows {
@@ -172,6 +185,9 @@ func GetRelevantTags(ctx *gin.Context) {
 		}
 	}
 	res.Tags = tags
+	res.Parent = parent
+	res.Children = children
+	res.Aliases = aliases
 	ResponseSuccess(ctx, res)
 	ctx.Set("tagData", res)
 	ctx.Set("nowTag", queryData)
@@ -438,3 +454,109 @@ func GetHotTopicRank(ctx *gin.Context) {
 	ResponseSuccess(ctx, res)
 	ctx.Set("hotTag", res)
 }
+
+// SaveTagAlias 管理员给标签添加别名
+func SaveTagAlias(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("tagAlias")
+	data := ctxData.(m.TagAliasParam)
+
+	err := mysql.SaveTagAlias(data.TagId, data.Alias)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			ResponseError(ctx, CodeParamsError)
+			return
+		}
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	err = c.DelTagCache([]string{data.TagId})
+	if err != nil {
+		logger.ErrZapLog(err, data)
+	}
+	ResponseSuccess(ctx, data)
+}
+
+// DeleteTagAlias 管理员删除标签别名
+func DeleteTagAlias(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("tagAlias")
+	data := ctxData.(m.TagAliasParam)
+
+	err := mysql.DeleteTagAlias(data.Alias)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+	// 别名只在标签详情展示 不知道属于哪个标签时 删除全部相关标签缓存
+	err = c.DelCacheByPattern(fmt.Sprintf(c.TagRelevant, "*"))
+	if err != nil {
+		logger.ErrZapLog(err, data)
+	}
+	ResponseSuccess(ctx, data)
+}
+
+// SetTagParent 管理员设置父标签
+func SetTagParent(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("tagParent")
+	data := ctxData.(m.TagParentParam)
+
+	err := mysql.SetTagParent(data.TagId, data.ParentId)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows || err == mysql.ErrTagCycle {
+			ResponseError(ctx, CodeParamsError)
+			return
+		}
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	// 旧的父标签也展示了子标签列表 统一删除
+	err = c.DelCacheByPattern(fmt.Sprintf(c.TagRelevant, "*"))
+	if err != nil {
+		logger.ErrZapLog(err, data)
+	}
+	ResponseSuccess(ctx, data)
+}
+
+// MergeTag 管理员合并标签 改写作品标签关系并清除缓存
+func MergeTag(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("tagMerge")
+	data := ctxData.(m.TagMergeParam)
+
+	res, err := mysql.MergeTag(data.FromId, data.ToId)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows || err == mysql.ErrTagCycle || err == mysql.ErrTagMergeSelf {
+			ResponseError(ctx, CodeParamsError)
+			return
+		}
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	ResponseSuccess(ctx, res)
+
+	// 清除标签缓存 相关标签列表里也可能有旧标签
+	err = c.DelTagCache([]string{res.From.TagId, res.To.TagId})
+	if err != nil {
+		logger.ErrZapLog(err, data)
+	}
+	err = c.DelCacheByPattern(fmt.Sprintf(c.TagRelevant, "*"))
+	if err != nil {
+		logger.ErrZapLog(err, data)
+	}
+	err = c.MergeHotTag(res.From.TagName, res.To.TagName)
+	if err != nil {
+		logger.ErrZapLog(err, data)
+	}
+	// 作品详情缓存里有标签
+	keys := make([]string, 0, len(res.ArtworkIds))
+	for _, artId := range res.ArtworkIds {
+		keys = append(keys, fmt.Sprintf(c.ArtworkProfile, artId))
+	}
+	if len(keys) != 0 {
+		err = c.BatchDelCache(keys)
+		if err != nil {
+			logger.ErrZapLog(err, data)
+		}
+	}
+}
@@ -19,7 +19,11 @@ func GetNewTrend(ctx *gin.Context) {
 	ctxData, _ := ctx.Get("nextId")
 	nextId := ctxData.(*int64)
 
-	feedData, err := mongo.GetNewTrend(*nextId)
+	ctxData, _ = ctx.Get("userInfo")
+	loginInfo, _ := ctxData.(m.UserTokenPayload)
+
+	mute := getFeedMute(loginInfo.Id)
+	feedData, next, err := getUnmutedFeed(*nextId, mute, mongo.GetNewTrend)
 	if err != nil {
 		err = errors.Wrap(err, "GetNewTrend mongo fail")
 		ResponseErrorAndLog(ctx, CodeServerBusy, err)
@@ -30,8 +34,7 @@ func GetNewTrend(ctx *gin.Context) {
 	dataLen := len(feedData)
 	if dataLen == 0 {
 		// 返回数据
-		ResponseSuccess(ctx, make([]struct{}, 0))
-		ctx.Abort()
+		responseEmptyFeed(ctx, next)
 		return
 	}
 	ctx.Set("feedData", feedData)
@@ -167,7 +170,11 @@ func GetTrendDetail(ctx *gin.Context) {
 		trendData[0].Forward = &temp
 	}
 
-	ResponseSuccess(ctx, trendData[0])
+	// 投票结果每个用户不同 不写入缓存
+	res := m.TrendList{trendData[0]}
+	fillTrendPoll(res, loginUserInfo.Id)
+	maskAdultTrend(res, getContentViewer(ctx))
+	ResponseSuccess(ctx, res[0])
 	ctx.Set("trendData", trendData[0])
 	ctx.Set("isHaveCache", isHaveCache)
 }
@@ -246,6 +253,12 @@ func DeleteTrend(ctx *gin.Context) {
 		ResponseErrorAndLog(ctx, CodeServerBusy, err)
 		return
 	}
+	err = mongo.DeleteOutboxFeed(query.TrendId, userInfo.Id)
+	if err != nil {
+		err = errors.Wrap(err, "DeleteTrend DeleteOutboxFeed fail")
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
 	// 动态标记删除
 	err = mongo.DeleteTrend(query.TrendId)
 	if err != nil {
@@ -109,7 +109,7 @@ func GetUserHomeCollect(ctx *gin.Context) {
 		artIds = append(artIds, data.MsgId)
 		uIds = append(uIds, data.AuthorId)
 	}
-	artData, findData, err := BatchGetBasicArtInfo(artIds, uIds)
+	artData, findData, err := BatchGetBasicArtInfo(artIds, uIds, getContentViewer(ctx))
 	if err != nil {
 		err = errors.Wrap(err, "GetUserHomeArtwork BatchGetBasicArtInfo fail")
 		ResponseErrorAndLog(ctx, CodeServerBusy, err)
@@ -134,7 +134,7 @@ func GetUserHomeArtwork(ctx *gin.Context) {
 	isOwner := loginUser.Id == query.UId
 
 	//data.Page -1 第一页 从第0条开始
-	artCounts, err := mysql.GetUserHomeArtwork(query.UId, query.Page-1, query.Sort)
+	artCounts, err := mysql.GetUserHomeArtwork(query.UId, query.Page-1, query.Sort, isOwner)
 	if err != nil {
 		ResponseErrorAndLog(ctx, CodeServerBusy, err)
 		return
@@ -160,7 +160,7 @@ func GetUserHomeArtwork(ctx *gin.Context) {
 	var artData []m.BasicArtwork
 	var needCacheArt []m.BasicArtwork
 	eg.Go(func() (mErr error) {
-		artData, needCacheArt, mErr = BatchGetBasicArtInfo(artIds, uIds)
+		artData, needCacheArt, mErr = BatchGetBasicArtInfo(artIds, uIds, getContentViewer(ctx))
 		if mErr != nil {
 			mErr = errors.Wrap(mErr, "GetUserHomeArtwork BatchGetBasicArtInfo fail")
 		}
@@ -268,6 +268,10 @@ func UpdateUserProfile(ctx *gin.Context) {
 		err = mysql.UpdateUserSex(profile.Profile, userInfo.Id)
 	case "birthday":
 		err = mysql.UpdateUserBirthday(profile.Profile, userInfo.Id)
+		if err == nil {
+			// 生日决定能否查看敏感内容 删除偏好缓存
+			err = c.DelOneCache(fmt.Sprintf(c.AdultPref, userInfo.Id))
+		}
 	case "workEmail":
 		err = mysql.UpdateUserWorkEmail(profile.Profile, userInfo.Id)
 	case "region":
@@ -283,6 +287,10 @@ func UpdateUserProfile(ctx *gin.Context) {
 	case "snsLink":
 		err = mysql.UpdateUserSns(profile.SnsData, userInfo.Id)
 	}
+	if err == mysql.ErrorBirthdayLocked {
+		ResponseError(ctx, CodeBirthdayLocked)
+		return
+	}
 	if err != nil {
 		ResponseError(ctx, CodeServerBusy)
 		return
@@ -358,6 +366,14 @@ func SaveUserFocus(ctx *gin.Context) {
 		ctx.Abort()
 	}
 
+	// 取消关注时屏蔽设置也重置了
+	if isChange && focusInfo.IsCancel {
+		err = c.DelOneCache(fmt.Sprintf(c.UserMute, loginUser.Id))
+		if err != nil {
+			logger.ErrZapLog(err, loginUser.Id)
+		}
+	}
+
 	// 返回数据
 	ResponseSuccess(ctx, FocusData)
 }
@@ -373,22 +389,79 @@ func GetRecommendUser(ctx *gin.Context) {
 
 	loginData, _ := ctxData.(m.UserTokenPayload)
 
-	hotUserId, err := c.GetHotUserId(loginData.Id)
+	// 优先使用计算好的推荐用户 已排除自己和已关注的用户
+	recommendId, err := c.GetRecommendUserId(loginData.Id)
 	if err != nil {
-		err = errors.Wrap(err, "GetRecommendUser GetHotUserId fail")
-		ResponseErrorAndLog(ctx, CodeServerBusy, err)
-		return
+		logger.ErrZapLog(err, "GetRecommendUser GetRecommendUserId fail")
+	}
+	if len(recommendId) > 24 {
+		recommendId = recommendId[:24]
+	}
+	recommendId = formatTools.RandGetSlice(recommendId, 12)
+
+	// 推荐不足时 用热门用户补充
+	if len(recommendId) < 12 {
+		hotUserId, mErr := c.GetHotUserId(loginData.Id)
+		if mErr != nil {
+			mErr = errors.Wrap(mErr, "GetRecommendUser GetHotUserId fail")
+			ResponseErrorAndLog(ctx, CodeServerBusy, mErr)
+			return
+		}
+		exist := make(map[string]bool, len(recommendId))
+		for _, id := range recommendId {
+			exist[id] = true
+		}
+		filterId := make([]string, 0, len(hotUserId))
+		for _, id := range hotUserId {
+			if !exist[id] && id != loginData.Id {
+				filterId = append(filterId, id)
+			}
+		}
+		recommendId = append(recommendId, formatTools.RandGetSlice(filterId, 12-len(recommendId))...)
 	}
 
-	// 随机取出30个
-	hotUserId = formatTools.RandGetSlice(hotUserId, 12)
 	// 到缓存中取数据
 	fmtKey := fmt.Sprintf(c.HotUser, "%s")
 	var temp m.HotUser
-	resData, _, err := c.BatchGetTypeOfString(fmtKey, hotUserId, temp)
+	resData, needFind, err := c.BatchGetTypeOfString(fmtKey, recommendId, temp)
+	if err != nil {
+		ResponseErrorAndLog(ctx, CodeServerBusy, err)
+		return
+	}
+
+	// 不在热门中的用户 使用用户卡片数据
+	var needCacheData []m.UserBigCard
+	if len(needFind) != 0 {
+		findId := make([]string, 0, len(needFind))
+		for _, find := range needFind {
+			findId = append(findId, find.Id)
+		}
+		cardData, cardNeedFind, mErr := c.GetUserBigCarCache(findId)
+		if mErr != nil {
+			logger.ErrZapLog(mErr, "GetRecommendUser GetUserBigCarCache fail")
+		}
+		queryId := make([]string, 0, len(cardNeedFind))
+		for _, find := range cardNeedFind {
+			queryId = append(queryId, find.Id)
+		}
+		needCacheData, mErr = mysql.BatchGetUserAllInfo(queryId, 5)
+		if mErr != nil {
+			mErr = errors.Wrap(mErr, "GetRecommendUser BatchGetUserAllInfo fail")
+			ResponseErrorAndLog(ctx, CodeServerBusy, mErr)
+			return
+		}
+		for _, card := range append(cardData, needCacheData...) {
+			resData = append(resData, bigCardToHotUser(card))
+		}
+	}
+	if len(resData) == 0 {
+		resData = make([]m.HotUser, 0)
+	}
 
 	// 返回数据
 	ResponseSuccess(ctx, resData)
+
+	ctx.Set("userData", needCacheData)
 }
 
 // GetUserRank 获取用户排名
@@ -13,7 +13,7 @@ import (
 )
 
 // BatchGetBasicArtInfo 批量获取基础作品数据
-func BatchGetBasicArtInfo(artIds, userIds []string) (artData, findData []m.BasicArtwork, err error) {
+func BatchGetBasicArtInfo(artIds, userIds []string, viewer contentViewer) (artData, findData []m.BasicArtwork, err error) {
 	if len(artIds) == 0 {
 		return
 	}
@@ -40,6 +40,8 @@ func BatchGetBasicArtInfo(artIds, userIds []string) (artData, findData []m.Basic
 		artData = append(artData[:data.Index], append(temp, artData[data.Index:]...)...)
 	}
 
+	// 只处理返回的数据 findData 需要原样缓存
+	maskAdultArt(artData, viewer)
 	return
 }
 
@@ -277,10 +279,10 @@ func BatchGetArtworkCount(findIds []string) (result map[string]map[string]string
 }
 
 // BatchGetNotifyFactorInfo 批量获取提醒需要的要素
-func BatchGetNotifyFactorInfo(uIds, findAw []string, findTr []int64) (
+func BatchGetNotifyFactorInfo(uIds, findAw []string, findTr []int64, viewer contentViewer) (
 	artMap, trendMap map[string]m.NotifyArtOrTrendInfo, findData []m.BasicArtwork, err error) {
 
-	artData, findData, err := BatchGetBasicArtInfo(findAw, uIds)
+	artData, findData, err := BatchGetBasicArtInfo(findAw, uIds, viewer)
 	if err != nil {
 		err = errors.Wrap(err, "BatchGetNotifyFactorInfo BatchGetBasicArtInfo fail")
 		return
@@ -320,19 +322,22 @@ func GetUserNotifyConfig(userId string) (config m.NotifyConfig, haveCache bool,
 		err = errors.Wrap(err, fmt.Sprintf("GetUserNotifyConfig chace fail %s", userId))
 		return
 	}
-	if len(cache) != 0 {
+	// 旧的缓存没有 Forward 重新到数据库查
+	if len(cache) != 0 && cache["Forward"] != "" {
 		at, _ := strconv.ParseUint(cache["At"], 10, 64)
 		like, _ := strconv.ParseUint(cache["Like"], 10, 64)
 		comment, _ := strconv.ParseUint(cache["Comment"], 10, 64)
 		follow, _ := strconv.ParseUint(cache["Follow"], 10, 64)
 		message, _ := strconv.ParseUint(cache["Message"], 10, 64)
 		collect, _ := strconv.ParseUint(cache["Collect"], 10, 64)
+		forward, _ := strconv.ParseUint(cache["Forward"], 10, 64)
 		config.At = uint8(at)
 		config.Like = uint8(like)
 		config.Comment = uint8(comment)
 		config.Follow = uint8(follow)
 		config.Message = uint8(message)
 		config.Collect = uint8(collect)
+		config.Forward = uint8(forward)
 		haveCache = true
 		return
 	}
@@ -345,3 +350,29 @@ func GetUserNotifyConfig(userId string) (config m.NotifyConfig, haveCache bool,
 
 	return
 }
+
+// errTargetNotFound 作品或动态不存在
+var errTargetNotFound = errors.New("target not found")
+
+// getTargetAuthor 获取作品或动态的作者 不存在时返回 errTargetNotFound
+func getTargetAuthor(target m.ForwardInfo) (author string, err error) {
+	if target.Type == "aw" {
+		arts, mErr := mysql.GetBatchArtSimpleInfo([]string{strconv.FormatInt(target.Id, 10)})
+		if mErr != nil {
+			return "", errors.Wrap(mErr, "getTargetAuthor artwork fail")
+		}
+		if len(arts) == 0 {
+			return "", errTargetNotFound
+		}
+		return arts[0].UserId, nil
+	}
+
+	trends, err := mongo.GetNotifyTrendInfo([]int64{target.Id})
+	if err != nil {
+		return "", errors.Wrap(err, "getTargetAuthor trend fail")
+	}
+	if len(trends) == 0 {
+		return "", errTargetNotFound
+	}
+	return trends[0].Author, nil
+}
@@ -67,7 +67,8 @@ func GetRootCommentInfo(ownId string, cid int64) (rootComment []m.ReturnComment,
 		Projection: bson.D{{"_id", 0}, {"reply_id", 0}, {"reply_user", 0}, {"updateAt", 0}},
 	}
 	var cur *mongo.Cursor
-	filter := bson.D{{"own_id", ownId}, {"root_id", 0}, {"is_delete", false}}
+	// 置顶的评论单独查询
+	filter := bson.D{{"own_id", ownId}, {"root_id", 0}, {"is_delete", false}, {"is_top", bson.M{"$ne": true}}}
 	if cid != 0 {
 		filter = append(filter, bson.E{Key: "cid", Value: bson.M{"$lt": cid}})
 	}
@@ -195,6 +196,12 @@ func GetRootComment(ownId, cid string) (rootComment []m.ReturnComment, childComm
 	if len(rootComment) == 0 {
 		return
 	}
+	childComment, userIds, err = getRootChildAndUser(rootComment)
+	return
+}
+
+// getRootChildAndUser 查询根评论展示的两条子评论 和需要查询的用户
+func getRootChildAndUser(rootComment []m.ReturnComment) (childComment []m.FirstShowChildComment, userIds []string, err error) {
 	// 需要查询的 所有评论id列表
 	var cIdList []int64
 	// 需要查询的 所有用户列表
@@ -342,3 +349,336 @@ func BatchGetComment(cIds []int64) (commentMap map[int64]m.Comment, err error) {
 	}
 	return
 }
+
+// EditOneComment 修改评论内容 修改前的版本保存到历史
+// 用 FindOneAndUpdate 取回修改前的文档 同时修改时每个版本都会被保存 评论已删除时返回 mongo.ErrNoDocuments
+func EditOneComment(comment m.Comment, text string) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	nowTime := time.Now()
+	filter := bson.D{{"cid", comment.CId}, {"is_delete", false}}
+	update := bson.D{{"$set", bson.D{
+		{"content", text},
+		{"is_edit", true},
+		{"edit_at", nowTime},
+		{"updateAt", nowTime},
+	}}}
+	opts := options.FindOneAndUpdate().
+		SetProjection(bson.D{{"_id", 0}, {"cid", 1}, {"content", 1}, {"is_edit", 1}, {"edit_at", 1}, {"createAt", 1}}).
+		SetReturnDocument(options.Before)
+	var before m.Comment
+	err = Mgo.Collection("comment").FindOneAndUpdate(ctx, filter, update, opts).Decode(&before)
+	if err == mongo.ErrNoDocuments {
+		return
+	}
+	if err != nil {
+		err = errors.Wrap(err, "EditOneComment: mongo update fail")
+		return
+	}
+	if before.Text == text {
+		return
+	}
+
+	// 上一个版本的发布时间 没有修改过的是评论的创建时间
+	versionAt := before.CreateAT
+	if before.IsEdit {
+		versionAt = before.EditAt
+	}
+	history := m.CommentHistory{
+		CId:      before.CId,
+		Text:     before.Text,
+		CreateAT: versionAt,
+	}
+	_, err = Mgo.Collection("comment_history").InsertOne(ctx, history)
+	if err != nil {
+		err = errors.Wrap(err, "EditOneComment: history insert fail")
+	}
+	return
+}
+
+// GetCommentHistory 获取评论修改前的版本 新的在前
+func GetCommentHistory(cid int64) (history []m.CommentHistory, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	table := Mgo.Collection("comment_history")
+	filter := bson.D{{"cid", cid}}
+	opts := options.FindOptions{
+		Sort:       bson.M{"createAt": -1},
+		Projection: bson.D{{"_id", 0}},
+	}
+	cur, err := table.Find(ctx, filter, &opts)
+	if err != nil {
+		err = errors.Wrap(err, "GetCommentHistory fail")
+		return
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var result m.CommentHistory
+		err = cur.Decode(&result)
+		if err != nil {
+			return
+		}
+		history = append(history, result)
+	}
+	if err = cur.Err(); err != nil {
+		return
+	}
+	return
+}
+
+// hotRootCommentStage 按热度排序根评论的查询阶段 置顶的评论不参与排序
+func hotRootCommentStage(ownId string) mongo.Pipeline {
+	matchStage := bson.D{{"$match", bson.D{
+		{"own_id", ownId},
+		{"root_id", 0},
+		{"is_delete", false},
+		{"is_top", bson.M{"$ne": true}},
+	}}}
+	hotStage := bson.D{{"$addFields", bson.D{
+		// 点赞数不小于 0 热度不能低于翻页结束的标记 -1
+		{"hot", bson.M{"$add": bson.A{
+			bson.M{"$max": bson.A{0, bson.M{"$ifNull": bson.A{"$likes", 0}}}},
+			bson.M{"$multiply": bson.A{bson.M{"$ifNull": bson.A{"$root_count", 0}}, m.CommentHotReplyWeight}},
+		}}},
+	}}}
+	return mongo.Pipeline{matchStage, hotStage}
+}
+
+// GetHotRootComment 按热度获取根评论 热度相同时按 cid 倒序 cid 和 hot 是上一页最后一条
+func GetHotRootComment(ownId string, cid int64, hot int) (rootComment []m.ReturnComment, childComment []m.FirstShowChildComment, userIds []string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pipeline := hotRootCommentStage(ownId)
+	if cid != 0 {
+		pipeline = append(pipeline, bson.D{{"$match", bson.D{{"$or", bson.A{
+			bson.M{"hot": bson.M{"$lt": hot}},
+			bson.M{"hot": hot, "cid": bson.M{"$lt": cid}},
+		}}}}})
+	}
+	pipeline = append(pipeline,
+		bson.D{{"$sort", bson.D{{"hot", -1}, {"cid", -1}}}},
+		bson.D{{"$limit", 20}},
+		bson.D{{"$unset", bson.A{"_id", "reply_id", "reply_user", "updateAt", "hot"}}},
+	)
+
+	table := Mgo.Collection("comment")
+	cur, err := table.Aggregate(ctx, pipeline)
+	if err != nil {
+		err = errors.Wrap(err, "GetHotRootComment: mongo get fail")
+		return
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var result m.ReturnComment
+		err = cur.Decode(&result)
+		if err != nil {
+			return
+		}
+		rootComment = append(rootComment, result)
+	}
+	if err = cur.Err(); err != nil {
+		return
+	}
+
+	// 如果没有评论
+	if len(rootComment) == 0 {
+		return
+	}
+	childComment, userIds, err = getRootChildAndUser(rootComment)
+	return
+}
+
+// GetHotRootCommentRank 获取根评论热度排名的前 limit 条 用于生成缓存快照
+func GetHotRootCommentRank(ownId string, limit int) (ranks []m.CommentHotRank, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pipeline := append(hotRootCommentStage(ownId),
+		bson.D{{"$sort", bson.D{{"hot", -1}, {"cid", -1}}}},
+		bson.D{{"$limit", limit}},
+		bson.D{{"$project", bson.D{{"_id", 0}, {"cid", 1}, {"hot", 1}}}},
+	)
+
+	table := Mgo.Collection("comment")
+	cur, err := table.Aggregate(ctx, pipeline)
+	if err != nil {
+		err = errors.Wrap(err, "GetHotRootCommentRank fail")
+		return
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var result m.CommentHotRank
+		err = cur.Decode(&result)
+		if err != nil {
+			return
+		}
+		ranks = append(ranks, result)
+	}
+	if err = cur.Err(); err != nil {
+		return
+	}
+	return
+}
+
+// GetTopComment 获取作品或动态置顶的根评论
+func GetTopComment(ownId string) (rootComment []m.ReturnComment, childComment []m.FirstShowChildComment, userIds []string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	table := Mgo.Collection("comment")
+	filter := bson.D{{"own_id", ownId}, {"root_id", 0}, {"is_top", true}, {"is_delete", false}}
+	opts := options.FindOneOptions{
+		Projection: bson.D{{"_id", 0}, {"reply_id", 0}, {"reply_user", 0}, {"updateAt", 0}},
+	}
+	var result m.ReturnComment
+	err = table.FindOne(ctx, filter, &opts).Decode(&result)
+	if err != nil {
+		// 没有置顶的评论
+		if err == mongo.ErrNoDocuments {
+			err = nil
+			return
+		}
+		err = errors.Wrap(err, "GetTopComment fail")
+		return
+	}
+
+	rootComment = []m.ReturnComment{result}
+	childComment, userIds, err = getRootChildAndUser(rootComment)
+	return
+}
+
+// SetCommentTop 置顶或取消置顶根评论 每个作品或动态最多一条 返回之前置顶的评论id
+// 部分唯一索引保证只有一条置顶 同时置顶冲突时重新取消再置顶 后置顶的生效
+func SetCommentTop(comment m.Comment, isCancel bool) (oldTop int64, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	table := Mgo.Collection("comment")
+	filter1 := bson.D{{"own_id", comment.OwnId}, {"is_top", true}}
+	opts := options.FindOneAndUpdate().SetProjection(bson.D{{"_id", 0}, {"cid", 1}})
+	filter2 := bson.D{{"cid", comment.CId}, {"is_delete", false}}
+
+	for i := 0; i < 3; i++ {
+		// 取消之前置顶的
+		var old m.Comment
+		err = table.FindOneAndUpdate(ctx, filter1, bson.D{{"$set", bson.M{"is_top": false}}}, opts).Decode(&old)
+		if err != nil && err != mongo.ErrNoDocuments {
+			err = errors.Wrap(err, "SetCommentTop: cancel old fail")
+			return
+		}
+		err = nil
+		if oldTop == 0 {
+			oldTop = old.CId
+		}
+
+		if isCancel {
+			return
+		}
+
+		_, err = table.UpdateOne(ctx, filter2, bson.D{{"$set", bson.M{"is_top": true}}})
+		if !mongo.IsDuplicateKeyError(err) {
+			break
+		}
+	}
+	if err != nil {
+		err = errors.Wrap(err, "SetCommentTop: set top fail")
+	}
+	return
+}
+
+// IncCommentLikes 更新评论的点赞数 用于热度排序
+func IncCommentLikes(cid int64, incr int) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	table := Mgo.Collection("comment")
+	filter := bson.D{{"cid", cid}}
+	// 取消点赞时 不减到 0 以下
+	if incr < 0 {
+		filter = append(filter, bson.E{Key: "likes", Value: bson.M{"$gt": 0}})
+	}
+	_, err = table.UpdateOne(ctx, filter, bson.D{{"$inc", bson.M{"likes": incr}}})
+	if err != nil {
+		err = errors.Wrap(err, "IncCommentLikes fail")
+	}
+	return
+}
+
+// BackfillCommentLikes 按点赞记录重新统计评论的点赞数 返回更新的评论数
+// 没有点赞数或点赞数小于 0 的先设为 0
+func BackfillCommentLikes() (count int, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	table := Mgo.Collection("comment")
+	filter := bson.D{{"$or", bson.A{bson.M{"likes": bson.M{"$exists": false}}, bson.M{"likes": bson.M{"$lt": 0}}}}}
+	_, err = table.UpdateMany(ctx, filter, bson.D{{"$set", bson.M{"likes": 0}}})
+	if err != nil {
+		err = errors.Wrap(err, "BackfillCommentLikes reset fail")
+		return
+	}
+
+	pipeline := mongo.Pipeline{
+		bson.D{{"$match", bson.D{{"type", "cm"}, {"is_cancel", false}}}},
+		bson.D{{"$group", bson.D{{"_id", "$msg_id"}, {"likes", bson.M{"$sum": 1}}}}},
+	}
+	cur, err := Mgo.Collection("user_like").Aggregate(ctx, pipeline)
+	if err != nil {
+		err = errors.Wrap(err, "BackfillCommentLikes aggregate fail")
+		return
+	}
+	defer cur.Close(ctx)
+
+	const batch = 1000
+	updates := make([]mongo.WriteModel, 0, batch)
+	flush := func() (mErr error) {
+		if len(updates) == 0 {
+			return
+		}
+		// 即使一个错了 其他也更新
+		opts := options.BulkWrite().SetOrdered(false)
+		_, mErr = table.BulkWrite(ctx, updates, opts)
+		if mErr != nil {
+			mErr = errors.Wrap(mErr, "BackfillCommentLikes update fail")
+		}
+		count += len(updates)
+		updates = updates[:0]
+		return
+	}
+
+	for cur.Next(ctx) {
+		var result struct {
+			MsgId string `bson:"_id"`
+			Likes int    `bson:"likes"`
+		}
+		err = cur.Decode(&result)
+		if err != nil {
+			err = errors.Wrap(err, "BackfillCommentLikes decode fail")
+			return
+		}
+		cid, mErr := strconv.ParseInt(result.MsgId, 10, 64)
+		if mErr != nil {
+			continue
+		}
+		updates = append(updates, mongo.NewUpdateOneModel().SetFilter(bson.D{{"cid", cid}}).
+			SetUpdate(bson.D{{"$set", bson.M{"likes": result.Likes}}}))
+		if len(updates) == batch {
+			err = flush()
+			if err != nil {
+				return
+			}
+		}
+	}
+	if err = cur.Err(); err != nil {
+		err = errors.Wrap(err, "BackfillCommentLikes cur fail")
+		return
+	}
+	err = flush()
+	return
+}
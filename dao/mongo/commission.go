@@ -12,6 +12,9 @@ import (
 	"time"
 )
 
+// ErrQuoteNotPending 报价已经被回应 或者已被新报价取代
+var ErrQuoteNotPending = errors.New("quote not pending")
+
 // SaveAcceptPlan 保存接稿方案
 func SaveAcceptPlan(plan m.AcceptPlan) (err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -82,6 +85,7 @@ func GetInvitePlanCard(query m.PlanQuery, pType string) (plans []m.InvitePlanCar
 			{"money", 1},
 			{"category", 1},
 			{"file_list", 1},
+			{"waitlist", 1},
 			{"updateAt", 1},
 		},
 	}
@@ -171,7 +175,9 @@ func GetPlanUserInfo(inviteId int64) (userInfo m.PlanUserInfo, err error) {
 			{"_id", 0},
 			{"artist_id", 1},
 			{"user_id", 1},
-			{"status", 1}},
+			{"status", 1},
+			{"quote", 1},
+			{"need_quote", 1}},
 	}
 
 	err = table.FindOne(ctx, filter, &opts).Decode(&userInfo)
@@ -190,6 +196,10 @@ func UpdatePlanStatus(userId string, planNext m.PlanNext) (err error) {
 		"status":   planNext.Status,
 		"updateAt": time.Now(),
 	}
+	// 开始创作 结束排队
+	if planNext.Status == 2 {
+		set["waitlist"] = false
+	}
 	// 如果是关闭/完成方案 添加结束时间
 	if planNext.Status < 0 || planNext.Status == 3 {
 		set = bson.M{
@@ -284,3 +294,125 @@ func BatchGetCommissionNotifyInfo(inviteIds []int64) (commissionMap map[string]m
 	}
 	return
 }
+
+// SaveQuote 保存新的报价 之前等待回应的报价被取代
+func SaveQuote(quote m.CommissionQuote) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	table := Mgo.Collection("commission_quote")
+
+	filter := bson.D{{"invite_id", quote.InviteId}, {"status", 0}}
+	update := bson.D{{"$set", bson.D{{"status", -1}, {"updateAt", time.Now()}}}}
+	_, err = table.UpdateMany(ctx, filter, update)
+	if err != nil {
+		err = errors.Wrap(err, "SaveQuote update old quote mongodb fail")
+		return
+	}
+
+	_, err = table.InsertOne(ctx, quote)
+	if err != nil {
+		err = errors.Wrap(err, "SaveQuote mongodb fail")
+	}
+	return
+}
+
+// GetQuote 获取一条报价
+func GetQuote(quoteId int64) (quote m.CommissionQuote, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	table := Mgo.Collection("commission_quote")
+	filter := bson.D{{"quote_id", quoteId}}
+	opts := options.FindOneOptions{
+		Projection: bson.D{{"_id", 0}},
+	}
+	err = table.FindOne(ctx, filter, &opts).Decode(&quote)
+	return
+}
+
+// GetInviteQuotes 获取约稿的所有报价 按时间倒序
+func GetInviteQuotes(inviteId int64) (quotes []m.CommissionQuote, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	table := Mgo.Collection("commission_quote")
+	filter := bson.D{{"invite_id", inviteId}}
+	opts := options.FindOptions{
+		Sort:       bson.M{"quote_id": -1},
+		Projection: bson.D{{"_id", 0}},
+	}
+
+	var cur *mongo.Cursor
+	cur, err = table.Find(ctx, filter, &opts)
+	if err != nil {
+		return
+	}
+	defer cur.Close(ctx)
+
+	quotes = make([]m.CommissionQuote, 0)
+	for cur.Next(ctx) {
+		var result m.CommissionQuote
+		err = cur.Decode(&result)
+		if err != nil {
+			return
+		}
+		quotes = append(quotes, result)
+	}
+	err = cur.Err()
+	return
+}
+
+// ReplyQuote 接受或拒绝报价 接受时把报价快照保存到约稿方案
+func ReplyQuote(quote m.CommissionQuote, accept bool) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	table := Mgo.Collection("commission_quote")
+
+	var status int8 = -1
+	if accept {
+		status = 1
+	}
+	// 只有等待回应的报价可以修改
+	filter := bson.D{{"quote_id", quote.QuoteId}, {"status", 0}}
+	update := bson.D{{"$set", bson.D{{"status", status}, {"updateAt", time.Now()}}}}
+	res, err := table.UpdateOne(ctx, filter, update)
+	if err != nil {
+		err = errors.Wrap(err, "ReplyQuote mongodb fail")
+		return
+	}
+	if res.ModifiedCount == 0 {
+		err = ErrQuoteNotPending
+		return
+	}
+	if !accept {
+		return
+	}
+
+	quote.Status = 1
+	quote.UpdateAt = time.Now()
+	inviteTable := Mgo.Collection("commission_invite")
+	filter = bson.D{{"invite_id", quote.InviteId}}
+	update = bson.D{{"$set", bson.D{{"quote", quote}, {"updateAt", time.Now()}}}}
+	_, err = inviteTable.UpdateOne(ctx, filter, update)
+	if err != nil {
+		err = errors.Wrap(err, "ReplyQuote update invite mongodb fail")
+	}
+	return
+}
+
+// SavePlanAgreement 保存约稿协议信息
+func SavePlanAgreement(inviteId int64, agreement m.PlanAgreement) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	table := Mgo.Collection("commission_invite")
+	filter := bson.D{{"invite_id", inviteId}}
+	update := bson.D{{"$set", bson.D{{"agreement", agreement}}}}
+	_, err = table.UpdateOne(ctx, filter, update)
+	if err != nil {
+		err = errors.Wrap(err, "SavePlanAgreement mongodb fail")
+	}
+	return
+}
@@ -0,0 +1,159 @@
+package mongo
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	m "onpaper-api-go/models"
+	"time"
+)
+
+// SaveDispute 保存约稿纠纷 并在约稿方案上记录纠纷id
+func SaveDispute(dispute m.CommissionDispute) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	table := Mgo.Collection("commission_dispute")
+	_, err = table.InsertOne(ctx, dispute)
+	if err != nil {
+		err = errors.Wrap(err, "SaveDispute mongodb fail")
+		return
+	}
+
+	inviteTable := Mgo.Collection("commission_invite")
+	filter := bson.D{{"invite_id", dispute.InviteId}}
+	update := bson.D{{"$set", bson.D{{"dispute_id", dispute.DisputeId}}}}
+	_, err = inviteTable.UpdateOne(ctx, filter, update)
+	if err != nil {
+		err = errors.Wrap(err, "SaveDispute update invite mongodb fail")
+	}
+	return
+}
+
+// CheckInviteHaveDispute 查询约稿方案是否有未裁决的纠纷
+func CheckInviteHaveDispute(inviteId int64) (have bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	table := Mgo.Collection("commission_dispute")
+	filter := bson.D{{"invite_id", inviteId}, {"status", bson.M{"$lt": 2}}}
+	count, err := table.CountDocuments(ctx, filter)
+	if err != nil {
+		err = errors.Wrap(err, "CheckInviteHaveDispute mongodb fail")
+		return
+	}
+	have = count > 0
+	return
+}
+
+// GetDispute 获取纠纷详情
+func GetDispute(disputeId int64) (dispute m.CommissionDispute, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	table := Mgo.Collection("commission_dispute")
+	filter := bson.D{{"dispute_id", disputeId}}
+	opts := options.FindOneOptions{
+		Projection: bson.D{{"_id", 0}},
+	}
+	err = table.FindOne(ctx, filter, &opts).Decode(&dispute)
+	return
+}
+
+// SaveDisputeResponse 保存被申诉人的回应
+func SaveDisputeResponse(disputeId int64, response m.DisputeEvidence) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	table := Mgo.Collection("commission_dispute")
+	filter := bson.D{{"dispute_id", disputeId}, {"status", bson.M{"$lt": 2}}}
+	update := bson.D{{"$set", bson.D{
+		{"response", response},
+		{"status", 1},
+		{"updateAt", time.Now()},
+	}}}
+	_, err = table.UpdateOne(ctx, filter, update)
+	if err != nil {
+		err = errors.Wrap(err, "SaveDisputeResponse mongodb fail")
+	}
+	return
+}
+
+// SaveDisputeDecision 保存管理员裁决 已经裁决的不会覆盖
+func SaveDisputeDecision(decision m.DisputeDecision) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	table := Mgo.Collection("commission_dispute")
+	filter := bson.D{{"dispute_id", decision.DisputeId}, {"status", bson.M{"$lt": 2}}}
+	update := bson.D{{"$set", bson.D{
+		{"decision", decision},
+		{"status", 2},
+		{"updateAt", time.Now()},
+	}}}
+	_, err = table.UpdateOne(ctx, filter, update)
+	if err != nil {
+		err = errors.Wrap(err, "SaveDisputeDecision mongodb fail")
+	}
+	return
+}
+
+// SetDisputeRefunded 标记裁决的退款已完成
+func SetDisputeRefunded(disputeId int64) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	table := Mgo.Collection("commission_dispute")
+	filter := bson.D{{"dispute_id", disputeId}, {"status", 2}}
+	update := bson.D{{"$set", bson.D{
+		{"decision.refund_status", m.RefundDone},
+		{"decision.refund_at", time.Now()},
+		{"updateAt", time.Now()},
+	}}}
+	_, err = table.UpdateOne(ctx, filter, update)
+	if err != nil {
+		err = errors.Wrap(err, "SetDisputeRefunded mongodb fail")
+	}
+	return
+}
+
+// GetDisputeList 按状态获取纠纷列表
+func GetDisputeList(query m.DisputeListQuery) (disputes []m.CommissionDispute, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	table := Mgo.Collection("commission_dispute")
+
+	var limit int64 = 20
+	opts := options.FindOptions{
+		Sort:       bson.M{"dispute_id": -1},
+		Limit:      &limit,
+		Projection: bson.D{{"_id", 0}},
+	}
+
+	filter := bson.D{{"status", query.Status}}
+	if *query.NextId != 0 {
+		filter = append(filter, bson.E{Key: "dispute_id", Value: bson.M{"$lt": *query.NextId}})
+	}
+
+	var cur *mongo.Cursor
+	cur, err = table.Find(ctx, filter, &opts)
+	if err != nil {
+		return
+	}
+	defer cur.Close(ctx)
+
+	disputes = make([]m.CommissionDispute, 0)
+	for cur.Next(ctx) {
+		var result m.CommissionDispute
+		err = cur.Decode(&result)
+		if err != nil {
+			return
+		}
+		disputes = append(disputes, result)
+	}
+	err = cur.Err()
+	return
+}
@@ -0,0 +1,173 @@
+package mongo
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	m "onpaper-api-go/models"
+	"time"
+)
+
+// draftClaimTimeout 发布中的草稿超过这个时间没有完成 认为发布的实例已经退出 可以重新认领
+const draftClaimTimeout = 10 * time.Minute
+
+// SaveArtworkDraft 保存草稿 已存在则更新 创建时间不变
+func SaveArtworkDraft(draft m.ArtworkDraft) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	draftTable := Mgo.Collection("artwork_draft")
+	filter := bson.D{{"draft_id", draft.DraftId}, {"user_id", draft.UserId}}
+	update := bson.D{
+		{"$set", bson.D{
+			{"title", draft.Title},
+			{"description", draft.Description},
+			{"file_list", draft.FileList},
+			{"first_pic", draft.FirstPic},
+			{"tags", draft.Tags},
+			{"zone", draft.Zone},
+			{"whoSee", draft.WhoSee},
+			{"adults", draft.Adults},
+			{"cover", draft.Cover},
+			{"comment", draft.Comment},
+			{"copyright", draft.CopyRight},
+			{"allow_reference", draft.AllowReference},
+			{"allow_repost", draft.AllowRepost},
+			{"allow_ai_train", draft.AllowAITrain},
+			{"device", draft.Device},
+			{"publish_at", draft.PublishAt},
+			{"status", draft.Status},
+			{"fail_msg", ""},
+			{"updateAt", draft.UpdateAt},
+		}},
+		{"$setOnInsert", bson.D{{"createAt", draft.CreateAt}}},
+	}
+	_, err = draftTable.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		err = errors.Wrap(err, "SaveArtworkDraft mongodb fail")
+	}
+	return
+}
+
+// GetArtworkDraft 获取一个草稿
+func GetArtworkDraft(draftId int64) (draft m.ArtworkDraft, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	draftTable := Mgo.Collection("artwork_draft")
+	filter := bson.D{{"draft_id", draftId}}
+	opts := options.FindOneOptions{
+		Projection: bson.D{{"_id", 0}},
+	}
+	err = draftTable.FindOne(ctx, filter, &opts).Decode(&draft)
+	return
+}
+
+// GetUserDrafts 分页获取用户的草稿
+func GetUserDrafts(userId string, page int) (drafts []m.ArtworkDraft, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	draftTable := Mgo.Collection("artwork_draft")
+	filter := bson.D{{"user_id", userId}}
+
+	var limit int64 = 20
+	var skip = int64(page) * limit
+	opts := options.FindOptions{
+		Limit:      &limit,
+		Skip:       &skip,
+		Sort:       bson.D{{"updateAt", -1}},
+		Projection: bson.D{{"_id", 0}},
+	}
+
+	cur, err := draftTable.Find(ctx, filter, &opts)
+	if err != nil {
+		return
+	}
+	defer cur.Close(ctx)
+
+	drafts = make([]m.ArtworkDraft, 0)
+	for cur.Next(ctx) {
+		var result m.ArtworkDraft
+		err = cur.Decode(&result)
+		if err != nil {
+			return
+		}
+		drafts = append(drafts, result)
+	}
+	err = cur.Err()
+	return
+}
+
+// DeleteArtworkDraft 删除草稿
+func DeleteArtworkDraft(draftId int64) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	draftTable := Mgo.Collection("artwork_draft")
+	_, err = draftTable.DeleteOne(ctx, bson.D{{"draft_id", draftId}})
+	if err != nil {
+		err = errors.Wrap(err, "DeleteArtworkDraft mongodb fail")
+	}
+	return
+}
+
+// ClaimArtworkDraft 把草稿标记为发布中 防止多个实例重复发布
+// dueOnly 为 true 时只认领已经到发布时间的定时草稿
+// 认领后超过 draftClaimTimeout 还在发布中的草稿 可以被重新认领
+func ClaimArtworkDraft(draftId int64, dueOnly bool) (draft m.ArtworkDraft, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	draftTable := Mgo.Collection("artwork_draft")
+	// 之前的版本没有 claim_at 也当作超时处理
+	expired := bson.D{
+		{"status", m.DraftStatusPublishing},
+		{"claim_at", bson.M{"$not": bson.M{"$gte": time.Now().Add(-draftClaimTimeout)}}},
+	}
+	filter := bson.D{{"$or", bson.A{
+		bson.D{{"status", bson.M{"$ne": m.DraftStatusPublishing}}},
+		expired,
+	}}}
+	if draftId != 0 {
+		filter = append(filter, bson.E{Key: "draft_id", Value: draftId})
+	}
+	if dueOnly {
+		filter = bson.D{{"$or", bson.A{
+			bson.D{{"status", m.DraftStatusSchedule}, {"publish_at", bson.M{"$lte": time.Now()}}},
+			expired,
+		}}}
+	}
+	update := bson.D{{"$set", bson.D{
+		{"status", m.DraftStatusPublishing},
+		{"claim_at", time.Now()},
+		{"updateAt", time.Now()},
+	}}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{"publish_at", 1}}).
+		SetProjection(bson.D{{"_id", 0}}).
+		SetReturnDocument(options.After)
+
+	err = draftTable.FindOneAndUpdate(ctx, filter, update, opts).Decode(&draft)
+	return
+}
+
+// SetDraftPublishFail 发布失败时退回草稿状态 并记录原因
+func SetDraftPublishFail(draftId int64, msg string) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	draftTable := Mgo.Collection("artwork_draft")
+	filter := bson.D{{"draft_id", draftId}}
+	update := bson.D{{"$set", bson.D{
+		{"status", m.DraftStatusSave},
+		{"fail_msg", msg},
+		{"updateAt", time.Now()},
+	}}}
+	_, err = draftTable.UpdateOne(ctx, filter, update)
+	if err != nil {
+		err = errors.Wrap(err, "SetDraftPublishFail mongodb fail")
+	}
+	return
+}
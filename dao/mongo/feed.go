@@ -138,7 +138,7 @@ func SetFansFeed(fans []string, msgId int64, sendId, feedType string) (err error
 	for _, id := range fans {
 		update := bson.D{{"accept_id", id}, {"msg_id", msgId}, {"send_id", sendId}, {"type", feedType}}
 		//不要修改顺序 不然没有索引
-		filter := bson.D{{"accept_id", id}, {"send_id", sendId}, {"msg_id", id}}
+		filter := bson.D{{"accept_id", id}, {"send_id", sendId}, {"msg_id", msgId}}
 		feeds = append(feeds, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(bson.D{{"$set", update}}).SetUpsert(true))
 	}
 
@@ -0,0 +1,148 @@
+package mongo
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	m "onpaper-api-go/models"
+	"time"
+)
+
+// SetOutboxFeed 保存发布者的作品或动态 粉丝多的用户不推送 读取feed时从这里拉取
+func SetOutboxFeed(feeds []m.UploadArtOrTrend) (err error) {
+	if len(feeds) == 0 {
+		return
+	}
+
+	models := make([]mongo.WriteModel, 0, len(feeds))
+	for _, feed := range feeds {
+		update := bson.D{{"send_id", feed.SendId}, {"msg_id", feed.MsgID}, {"type", feed.Type}}
+		//不要修改顺序 不然没有索引
+		filter := bson.D{{"send_id", feed.SendId}, {"msg_id", feed.MsgID}}
+		models = append(models, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(bson.D{{"$set", update}}).SetUpsert(true))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	// 即使一个错了 其他也插入
+	opts := options.BulkWrite().SetOrdered(false)
+	_, err = Mgo.Collection("feed_outbox").BulkWrite(ctx, models, opts)
+	if err != nil {
+		err = errors.Wrap(err, "SetOutboxFeed fail")
+	}
+	return
+}
+
+// GetOutboxFeed 获取多个发布者的作品或动态 翻页和 GetFeed 一致
+func GetOutboxFeed(sendIds []string, msgId int64, feedType string) (msgData []m.MongoFeed, err error) {
+	if len(sendIds) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	//最近30个
+	var limit int64 = 30
+	opts := options.FindOptions{
+		Sort:       bson.M{"msg_id": -1},
+		Limit:      &limit,
+		Projection: bson.D{{"_id", 0}},
+	}
+
+	filter := bson.D{{"send_id", bson.M{"$in": sendIds}}}
+	if msgId != 0 {
+		filter = append(filter, bson.E{Key: "msg_id", Value: bson.M{"$lt": msgId}})
+	}
+	if feedType != "all" {
+		filter = append(filter, bson.E{Key: "type", Value: feedType})
+	}
+
+	cur, err := Mgo.Collection("feed_outbox").Find(ctx, filter, &opts)
+	if err != nil {
+		err = errors.Wrap(err, "GetOutboxFeed find fail")
+		return
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var result m.MongoFeed
+		err = cur.Decode(&result)
+		if err != nil {
+			err = errors.Wrap(err, "GetOutboxFeed decode fail")
+			return
+		}
+		msgData = append(msgData, result)
+	}
+	if err = cur.Err(); err != nil {
+		err = errors.Wrap(err, "GetOutboxFeed cur fail")
+	}
+	return
+}
+
+// DeleteOutboxFeed 删除作品或动态时 删除发布记录
+func DeleteOutboxFeed(msgId int64, sendId string) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.D{{"send_id", sendId}, {"msg_id", msgId}}
+	_, err = Mgo.Collection("feed_outbox").DeleteOne(ctx, filter)
+	if err != nil {
+		err = errors.Wrap(err, "DeleteOutboxFeed fail")
+	}
+	return
+}
+
+// PruneFansFeed 删除已经推送给粉丝的feed 只保留自己的
+func PruneFansFeed(sendId string) (count int64, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	filter := bson.D{{"send_id", sendId}, {"accept_id", bson.M{"$ne": sendId}}}
+	res, err := Mgo.Collection("feed").DeleteMany(ctx, filter)
+	if err != nil {
+		err = errors.Wrap(err, "PruneFansFeed fail")
+		return
+	}
+	count = res.DeletedCount
+	return
+}
+
+// GetTrendFeedAfter 按 trend_id 顺序获取未删除的动态 用于补全发布记录
+func GetTrendFeedAfter(lastId int64, limit int64) (feeds []m.UploadArtOrTrend, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	filter := bson.D{{"is_delete", false}, {"trend_id", bson.M{"$gt": lastId}}}
+	opts := options.FindOptions{
+		Sort:       bson.M{"trend_id": 1},
+		Limit:      &limit,
+		Projection: bson.D{{"_id", 0}, {"trend_id", 1}, {"user_id", 1}},
+	}
+
+	cur, err := Mgo.Collection("trend").Find(ctx, filter, &opts)
+	if err != nil {
+		err = errors.Wrap(err, "GetTrendFeedAfter find fail")
+		return
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var result struct {
+			TrendId int64  `bson:"trend_id"`
+			UserId  string `bson:"user_id"`
+		}
+		err = cur.Decode(&result)
+		if err != nil {
+			err = errors.Wrap(err, "GetTrendFeedAfter decode fail")
+			return
+		}
+		feeds = append(feeds, m.UploadArtOrTrend{MsgID: result.TrendId, SendId: result.UserId, Type: "tr"})
+	}
+	if err = cur.Err(); err != nil {
+		err = errors.Wrap(err, "GetTrendFeedAfter cur fail")
+	}
+	return
+}
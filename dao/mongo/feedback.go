@@ -15,11 +15,12 @@ func SaveReport(report m.PostReport) (err error) {
 	defer cancel()
 
 	reportTable := Mgo.Collection("report")
-	filter := bson.D{{"msg_id", report.MsgId}, {"post_user", report.PostUser}}
+	filter := bson.D{{"msg_id", report.MsgId}, {"post_user", report.PostUser}, {"is_reply", report.IsReply}}
 
 	update := bson.D{
 		{"msg_id", report.MsgId},
 		{"msg_type", report.MsgType},
+		{"is_reply", report.IsReply},
 		{"defendant", report.Defendant},
 		{"post_user", report.PostUser},
 		{"report_type", report.ReportType},
@@ -0,0 +1,516 @@
+package mongo
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	m "onpaper-api-go/models"
+	"onpaper-api-go/utils/snowflake"
+	"sort"
+	"time"
+)
+
+// ErrFolderItemNotFound 要排序的作品不在收藏夹中
+var ErrFolderItemNotFound = errors.New("folder item not found")
+
+// EnsureDefaultFolder 获取用户默认收藏夹 第一次创建时把之前收藏的作品都放进去
+func EnsureDefaultFolder(userId string) (folder m.CollectFolder, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	folderTable := Mgo.Collection("collect_folder")
+	filter := bson.D{{"user_id", userId}, {"is_default", true}}
+	update := bson.D{{"$setOnInsert", bson.D{
+		{"folder_id", snowflake.CreateID()},
+		{"name", "默认收藏夹"},
+		{"intro", ""},
+		{"cover_art", ""},
+		{"is_public", true},
+		{"count", 0},
+		{"updateAt", time.Now()},
+		{"createAt", time.Now()},
+	}}}
+	res, err := folderTable.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	// 并发创建时唯一索引保证只有一个成功 失败的一方直接读取已经创建的
+	if mongo.IsDuplicateKeyError(err) {
+		res, err = &mongo.UpdateResult{}, nil
+	}
+	if err != nil {
+		err = errors.Wrap(err, "EnsureDefaultFolder upsert fail")
+		return
+	}
+
+	err = folderTable.FindOne(ctx, filter).Decode(&folder)
+	if err != nil {
+		err = errors.Wrap(err, "EnsureDefaultFolder find fail")
+		return
+	}
+	if res.UpsertedCount == 0 {
+		return
+	}
+
+	// 新建的默认收藏夹 迁移之前收藏的作品
+	collectTable := Mgo.Collection("user_collect")
+	cFilter := bson.D{{"user_id", userId}, {"type", "aw"}, {"is_cancel", false}}
+	opts := options.FindOptions{
+		Projection: bson.D{{"msg_id", 1}, {"author_id", 1}, {"updateAt", 1}, {"_id", 0}},
+	}
+	cur, err := collectTable.Find(ctx, cFilter, &opts)
+	if err != nil {
+		err = errors.Wrap(err, "EnsureDefaultFolder find collect fail")
+		return
+	}
+	defer cur.Close(ctx)
+
+	var items []m.CollectFolderItem
+	for cur.Next(ctx) {
+		var result struct {
+			MsgId    string    `bson:"msg_id"`
+			AuthorId string    `bson:"author_id"`
+			UpdateAt time.Time `bson:"updateAt"`
+		}
+		err = cur.Decode(&result)
+		if err != nil {
+			return
+		}
+		items = append(items, m.CollectFolderItem{
+			MsgId:    result.MsgId,
+			AuthorId: result.AuthorId,
+			Sort:     result.UpdateAt.UnixMilli(),
+			CreateAt: result.UpdateAt,
+		})
+	}
+	if err = cur.Err(); err != nil {
+		return
+	}
+
+	err = AddFolderItems(folder, items)
+	if err != nil {
+		return
+	}
+	folder.Count = len(items)
+	return
+}
+
+// GetUserFolders 获取用户的收藏夹 默认收藏夹排在最前
+func GetUserFolders(userId string, onlyPublic bool) (folders []m.CollectFolder, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	folderTable := Mgo.Collection("collect_folder")
+	filter := bson.D{{"user_id", userId}}
+	if onlyPublic {
+		filter = append(filter, bson.E{Key: "is_public", Value: true})
+	}
+	opts := options.FindOptions{
+		Sort:       bson.D{{"is_default", -1}, {"createAt", 1}},
+		Projection: bson.D{{"_id", 0}},
+	}
+
+	cur, err := folderTable.Find(ctx, filter, &opts)
+	if err != nil {
+		return
+	}
+	defer cur.Close(ctx)
+
+	folders = make([]m.CollectFolder, 0)
+	for cur.Next(ctx) {
+		var result m.CollectFolder
+		err = cur.Decode(&result)
+		if err != nil {
+			return
+		}
+		folders = append(folders, result)
+	}
+	err = cur.Err()
+	return
+}
+
+// GetFolderCovers 批量获取收藏夹的封面作品 设置的封面还在收藏夹中时优先使用 否则使用排在最前的作品
+func GetFolderCovers(folders []m.CollectFolder) (covers map[int64]m.MsgIdAndUid, err error) {
+	covers = make(map[int64]m.MsgIdAndUid, len(folders))
+	if len(folders) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	itemTable := Mgo.Collection("collect_folder_item")
+
+	// 设置了封面的收藏夹 一次查出封面作品
+	coverFilter := bson.A{}
+	for _, folder := range folders {
+		if folder.CoverArt != "" {
+			coverFilter = append(coverFilter, bson.D{{"folder_id", folder.FolderId}, {"msg_id", folder.CoverArt}})
+		}
+	}
+	if len(coverFilter) > 0 {
+		var cur *mongo.Cursor
+		cur, err = itemTable.Find(ctx, bson.D{{"$or", coverFilter}})
+		if err != nil {
+			err = errors.Wrap(err, "GetFolderCovers find cover fail")
+			return
+		}
+		defer cur.Close(ctx)
+
+		for cur.Next(ctx) {
+			var result m.CollectFolderItem
+			err = cur.Decode(&result)
+			if err != nil {
+				return
+			}
+			covers[result.FolderId] = m.MsgIdAndUid{MsgId: result.MsgId, AuthorId: result.AuthorId}
+		}
+		if err = cur.Err(); err != nil {
+			return
+		}
+	}
+
+	// 其他收藏夹使用排在最前的作品
+	var folderIds []int64
+	for _, folder := range folders {
+		if _, ok := covers[folder.FolderId]; !ok {
+			folderIds = append(folderIds, folder.FolderId)
+		}
+	}
+	if len(folderIds) == 0 {
+		return
+	}
+	pipeline := mongo.Pipeline{
+		{{"$match", bson.D{{"folder_id", bson.M{"$in": folderIds}}}}},
+		{{"$sort", bson.D{{"folder_id", 1}, {"sort", -1}}}},
+		{{"$group", bson.D{
+			{"_id", "$folder_id"},
+			{"msg_id", bson.D{{"$first", "$msg_id"}}},
+			{"author_id", bson.D{{"$first", "$author_id"}}},
+		}}},
+	}
+	cur, err := itemTable.Aggregate(ctx, pipeline)
+	if err != nil {
+		err = errors.Wrap(err, "GetFolderCovers aggregate fail")
+		return
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var result struct {
+			FolderId int64  `bson:"_id"`
+			MsgId    string `bson:"msg_id"`
+			AuthorId string `bson:"author_id"`
+		}
+		err = cur.Decode(&result)
+		if err != nil {
+			return
+		}
+		covers[result.FolderId] = m.MsgIdAndUid{MsgId: result.MsgId, AuthorId: result.AuthorId}
+	}
+	err = cur.Err()
+	return
+}
+
+// GetFolder 获取一个收藏夹
+func GetFolder(folderId int64) (folder m.CollectFolder, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	folderTable := Mgo.Collection("collect_folder")
+	filter := bson.D{{"folder_id", folderId}}
+	opts := options.FindOneOptions{
+		Projection: bson.D{{"_id", 0}},
+	}
+	err = folderTable.FindOne(ctx, filter, &opts).Decode(&folder)
+	return
+}
+
+// CreateFolder 创建收藏夹
+func CreateFolder(folder m.CollectFolder) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	folderTable := Mgo.Collection("collect_folder")
+	_, err = folderTable.InsertOne(ctx, folder)
+	if err != nil {
+		err = errors.Wrap(err, "CreateFolder mongodb fail")
+	}
+	return
+}
+
+// UpdateFolder 编辑收藏夹信息
+func UpdateFolder(post m.PostCollectFolder) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	folderTable := Mgo.Collection("collect_folder")
+	filter := bson.D{{"folder_id", post.FolderId}}
+	update := bson.D{{"$set", bson.D{
+		{"name", post.Name},
+		{"intro", post.Intro},
+		{"cover_art", post.CoverArt},
+		{"is_public", post.IsPublic},
+		{"updateAt", time.Now()},
+	}}}
+	_, err = folderTable.UpdateOne(ctx, filter, update)
+	if err != nil {
+		err = errors.Wrap(err, "UpdateFolder mongodb fail")
+	}
+	return
+}
+
+// DeleteFolder 删除收藏夹 里面的作品移回默认收藏夹
+func DeleteFolder(folder, defaultFolder m.CollectFolder) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	itemTable := Mgo.Collection("collect_folder_item")
+	filter := bson.D{{"folder_id", folder.FolderId}}
+	cur, err := itemTable.Find(ctx, filter)
+	if err != nil {
+		return
+	}
+	defer cur.Close(ctx)
+
+	var items []m.CollectFolderItem
+	for cur.Next(ctx) {
+		var result m.CollectFolderItem
+		err = cur.Decode(&result)
+		if err != nil {
+			return
+		}
+		items = append(items, result)
+	}
+	if err = cur.Err(); err != nil {
+		return
+	}
+
+	err = AddFolderItems(defaultFolder, items)
+	if err != nil {
+		return
+	}
+
+	_, err = itemTable.DeleteMany(ctx, filter)
+	if err != nil {
+		err = errors.Wrap(err, "DeleteFolder delete items fail")
+		return
+	}
+	_, err = Mgo.Collection("collect_folder").DeleteOne(ctx, filter)
+	if err != nil {
+		err = errors.Wrap(err, "DeleteFolder delete folder fail")
+	}
+	return
+}
+
+// GetFolderItems 分页获取收藏夹中的作品
+func GetFolderItems(folderId int64, page int) (items []m.MsgIdAndUid, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	itemTable := Mgo.Collection("collect_folder_item")
+	filter := bson.D{{"folder_id", folderId}}
+
+	var limit int64 = 30
+	var skip = int64(page) * limit
+	opts := options.FindOptions{
+		Limit:      &limit,
+		Skip:       &skip,
+		Sort:       bson.D{{"sort", -1}},
+		Projection: bson.D{{"msg_id", 1}, {"author_id", 1}, {"_id", 0}},
+	}
+
+	cur, err := itemTable.Find(ctx, filter, &opts)
+	if err != nil {
+		return
+	}
+	defer cur.Close(ctx)
+
+	items = make([]m.MsgIdAndUid, 0)
+	for cur.Next(ctx) {
+		var result m.MsgIdAndUid
+		err = cur.Decode(&result)
+		if err != nil {
+			return
+		}
+		items = append(items, result)
+	}
+	err = cur.Err()
+	return
+}
+
+// GetFolderItemsByIds 获取收藏夹中指定的作品
+func GetFolderItemsByIds(folderId int64, msgIds []string) (items []m.CollectFolderItem, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	itemTable := Mgo.Collection("collect_folder_item")
+	filter := bson.D{{"folder_id", folderId}, {"msg_id", bson.M{"$in": msgIds}}}
+
+	cur, err := itemTable.Find(ctx, filter)
+	if err != nil {
+		return
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var result m.CollectFolderItem
+		err = cur.Decode(&result)
+		if err != nil {
+			return
+		}
+		items = append(items, result)
+	}
+	err = cur.Err()
+	return
+}
+
+// AddFolderItems 把作品加入收藏夹 已经存在的不重复加入
+func AddFolderItems(folder m.CollectFolder, items []m.CollectFolderItem) (err error) {
+	if len(items) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	itemTable := Mgo.Collection("collect_folder_item")
+	dataList := make([]mongo.WriteModel, 0, len(items))
+	for _, item := range items {
+		filter := bson.D{{"folder_id", folder.FolderId}, {"msg_id", item.MsgId}}
+		update := bson.D{{"$setOnInsert", bson.D{
+			{"user_id", folder.UserId},
+			{"author_id", item.AuthorId},
+			{"sort", item.Sort},
+			{"createAt", item.CreateAt},
+		}}}
+		dataList = append(dataList, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update).SetUpsert(true))
+	}
+	// 即使一个错了 其他也插入
+	opts := options.BulkWrite().SetOrdered(false)
+	_, err = itemTable.BulkWrite(ctx, dataList, opts)
+	// 同时收藏同一个作品时 唯一索引会让后插入的失败 作品已经在收藏夹中
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		err = errors.Wrap(err, "AddFolderItems mongo set fail")
+		return
+	}
+
+	err = updateFolderCount(ctx, folder.FolderId)
+	return
+}
+
+// RemoveFolderItems 从收藏夹移除作品
+func RemoveFolderItems(folderId int64, msgIds []string) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	itemTable := Mgo.Collection("collect_folder_item")
+	filter := bson.D{{"folder_id", folderId}, {"msg_id", bson.M{"$in": msgIds}}}
+	_, err = itemTable.DeleteMany(ctx, filter)
+	if err != nil {
+		err = errors.Wrap(err, "RemoveFolderItems mongo fail")
+		return
+	}
+
+	err = updateFolderCount(ctx, folderId)
+	return
+}
+
+// SortFolderItems 按上传的顺序重排作品 只交换这些作品原有的排序值 不影响其他作品的位置
+func SortFolderItems(folderId int64, msgIds []string) (err error) {
+	items, err := GetFolderItemsByIds(folderId, msgIds)
+	if err != nil {
+		return
+	}
+	if len(items) != len(msgIds) {
+		return ErrFolderItemNotFound
+	}
+
+	sorts := make([]int64, 0, len(items))
+	for _, item := range items {
+		sorts = append(sorts, item.Sort)
+	}
+	sort.Slice(sorts, func(i, j int) bool { return sorts[i] > sorts[j] })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	itemTable := Mgo.Collection("collect_folder_item")
+	dataList := make([]mongo.WriteModel, 0, len(msgIds))
+	for i, msgId := range msgIds {
+		filter := bson.D{{"folder_id", folderId}, {"msg_id", msgId}}
+		update := bson.D{{"$set", bson.D{{"sort", sorts[i]}}}}
+		dataList = append(dataList, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update))
+	}
+	_, err = itemTable.BulkWrite(ctx, dataList)
+	if err != nil {
+		err = errors.Wrap(err, "SortFolderItems mongo fail")
+	}
+	return
+}
+
+// SyncCollectFolder 收藏作品时放入默认收藏夹 取消收藏时从所有收藏夹移除
+func SyncCollectFolder(userId string, cData m.PostInteractData) (err error) {
+	if cData.Type != "aw" {
+		return
+	}
+
+	if !cData.IsCancel {
+		var folder m.CollectFolder
+		folder, err = EnsureDefaultFolder(userId)
+		if err != nil {
+			return
+		}
+		item := m.CollectFolderItem{
+			MsgId:    cData.MsgId,
+			AuthorId: cData.AuthorId,
+			Sort:     time.Now().UnixMilli(),
+			CreateAt: time.Now(),
+		}
+		err = AddFolderItems(folder, []m.CollectFolderItem{item})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	itemTable := Mgo.Collection("collect_folder_item")
+	filter := bson.D{{"user_id", userId}, {"msg_id", cData.MsgId}}
+	folderIds, err := itemTable.Distinct(ctx, "folder_id", filter)
+	if err != nil {
+		err = errors.Wrap(err, "SyncCollectFolder distinct fail")
+		return
+	}
+	_, err = itemTable.DeleteMany(ctx, filter)
+	if err != nil {
+		err = errors.Wrap(err, "SyncCollectFolder delete fail")
+		return
+	}
+	for _, id := range folderIds {
+		folderId, ok := id.(int64)
+		if !ok {
+			continue
+		}
+		err = updateFolderCount(ctx, folderId)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// updateFolderCount 重新统计收藏夹中的作品数
+func updateFolderCount(ctx context.Context, folderId int64) (err error) {
+	itemTable := Mgo.Collection("collect_folder_item")
+	count, err := itemTable.CountDocuments(ctx, bson.D{{"folder_id", folderId}})
+	if err != nil {
+		err = errors.Wrap(err, "updateFolderCount count fail")
+		return
+	}
+
+	folderTable := Mgo.Collection("collect_folder")
+	filter := bson.D{{"folder_id", folderId}}
+	update := bson.D{{"$set", bson.D{{"count", count}, {"updateAt", time.Now()}}}}
+	_, err = folderTable.UpdateOne(ctx, filter, update)
+	if err != nil {
+		err = errors.Wrap(err, "updateFolderCount update fail")
+	}
+	return
+}
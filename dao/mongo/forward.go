@@ -0,0 +1,141 @@
+package mongo
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	m "onpaper-api-go/models"
+	"strconv"
+	"time"
+)
+
+// GetTrendForwardInfo 获取动态自己的转发信息 不是转发时 Id 为 0
+func GetTrendForwardInfo(trendId int64) (info m.ForwardInfo, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var result struct {
+		ForwardInfo m.ForwardInfo `bson:"forward_info"`
+	}
+	filter := bson.D{{"trend_id", trendId}}
+	opts := options.FindOne().SetProjection(bson.D{{"_id", 0}, {"forward_info", 1}})
+	err = Mgo.Collection("trend").FindOne(ctx, filter, opts).Decode(&result)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return info, nil
+	}
+	if err != nil {
+		err = errors.Wrap(err, "GetTrendForwardInfo fail")
+		return
+	}
+	return result.ForwardInfo, nil
+}
+
+// GetForwardList 获取作品或动态的公开转发 按时间倒序
+// 动态的转发包括转发链经过它的转发
+func GetForwardList(query m.ForwardListQuery) (list []m.ForwardItem, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.D{
+		{"is_delete", false},
+		{"state", 0},
+		{"whoSee", "public"},
+	}
+	direct := bson.D{{"forward_info.id", query.MsgId}, {"forward_info.type", query.Type}}
+	if query.Type == "tr" {
+		chain := bson.D{{"forward_info.chain", strconv.FormatInt(query.MsgId, 10)}}
+		filter = append(filter, bson.E{Key: "$or", Value: bson.A{direct, chain}})
+	} else {
+		filter = append(filter, direct...)
+	}
+	if query.NextId != 0 {
+		filter = append(filter, bson.E{Key: "trend_id", Value: bson.M{"$lt": query.NextId}})
+	}
+
+	var limit int64 = m.ForwardPageSize
+	opts := options.FindOptions{
+		Sort:  bson.M{"trend_id": -1},
+		Limit: &limit,
+		Projection: bson.D{
+			{"_id", 0},
+			{"trend_id", 1},
+			{"user_id", 1},
+			{"text", 1},
+			{"forward_info", 1},
+			{"createAt", 1}},
+	}
+
+	cur, err := Mgo.Collection("trend").Find(ctx, filter, &opts)
+	if err != nil {
+		err = errors.Wrap(err, "GetForwardList find fail")
+		return
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var result m.ForwardItem
+		err = cur.Decode(&result)
+		if err != nil {
+			err = errors.Wrap(err, "GetForwardList decode fail")
+			return
+		}
+		list = append(list, result)
+	}
+	if err = cur.Err(); err != nil {
+		err = errors.Wrap(err, "GetForwardList cur fail")
+	}
+	return
+}
+
+// GetForwardNotify 获取被转发的提醒
+func GetForwardNotify(userId string, nextId string) (notify []m.NotifyBody, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	notifyTable := Mgo.Collection("notify")
+
+	//最近20个
+	var limit int64 = 20
+	opts := options.FindOptions{
+		Sort:  bson.M{"_id": -1},
+		Limit: &limit,
+	}
+	filter := bson.D{{"receiverId", userId}, {"action", "forward"}}
+	if nextId != "0" {
+		id, _err := primitive.ObjectIDFromHex(nextId)
+		if _err != nil {
+			return make([]m.NotifyBody, 0), _err
+		}
+		filter = append(filter, bson.E{Key: "_id", Value: bson.M{"$lt": id}})
+	}
+	cur, err := notifyTable.Find(ctx, filter, &opts)
+	if err != nil {
+		return
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var result m.NotifyBody
+		err = cur.Decode(&result)
+		if err != nil {
+			return
+		}
+		var content m.ForwardNotify
+		if doc, ok := result.Content.(primitive.D); ok {
+			byt, _ := bson.Marshal(doc.Map())
+			err = bson.Unmarshal(byt, &content)
+			if err != nil {
+				return
+			}
+		}
+		result.Content = content
+		notify = append(notify, result)
+	}
+	if err = cur.Err(); err != nil {
+		return
+	}
+	return
+}
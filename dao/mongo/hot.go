@@ -0,0 +1,98 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	m "onpaper-api-go/models"
+	"strconv"
+	"time"
+)
+
+// GetHotInteract 获取一段时间内对作品和动态的点赞或收藏
+// table 为 user_like 或 user_collect
+func GetHotInteract(table string, since time.Time) (data []m.HotInteract, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	filter := bson.D{
+		{"is_cancel", false},
+		{"updateAt", bson.D{{"$gte", since}}},
+	}
+	opts := options.FindOptions{
+		Projection: bson.D{{"user_id", 1}, {"msg_id", 1}, {"type", 1}, {"author_id", 1}, {"_id", 0}},
+	}
+
+	cur, err := Mgo.Collection(table).Find(ctx, filter, &opts)
+	if err != nil {
+		err = errors.Wrap(err, fmt.Sprintf("GetHotInteract %s find fail", table))
+		return
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var result m.HotInteract
+		err = cur.Decode(&result)
+		if err != nil {
+			err = errors.Wrap(err, "GetHotInteract decode fail")
+			return
+		}
+		data = append(data, result)
+	}
+	if err = cur.Err(); err != nil {
+		err = errors.Wrap(err, "GetHotInteract cur fail")
+	}
+	return
+}
+
+// GetHotCandidateTrend 获取一段时间内发布的公开动态 用于计算热度
+func GetHotCandidateTrend(since time.Time) (trends []m.HotCandidate, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	filter := bson.D{
+		{"is_delete", false},
+		{"state", 0},
+		{"whoSee", "public"},
+		{"createAt", bson.D{{"$gte", since}}},
+	}
+	opts := options.FindOptions{
+		Projection: bson.D{{"_id", 0}, {"trend_id", 1}, {"user_id", 1}, {"count", 1}, {"createAt", 1}},
+	}
+
+	cur, err := Mgo.Collection("trend").Find(ctx, filter, &opts)
+	if err != nil {
+		err = errors.Wrap(err, "GetHotCandidateTrend find fail")
+		return
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var result struct {
+			TrendId  int64        `bson:"trend_id"`
+			UserId   string       `bson:"user_id"`
+			Count    m.TrendCount `bson:"count"`
+			CreateAt time.Time    `bson:"createAt"`
+		}
+		err = cur.Decode(&result)
+		if err != nil {
+			err = errors.Wrap(err, "GetHotCandidateTrend decode fail")
+			return
+		}
+		trends = append(trends, m.HotCandidate{
+			MsgId:    strconv.FormatInt(result.TrendId, 10),
+			Type:     "tr",
+			UserId:   result.UserId,
+			Comments: result.Count.Comments,
+			Forwards: result.Count.Forwards,
+			Views:    result.Count.Views,
+			CreateAt: result.CreateAt,
+		})
+	}
+	if err = cur.Err(); err != nil {
+		err = errors.Wrap(err, "GetHotCandidateTrend cur fail")
+	}
+	return
+}
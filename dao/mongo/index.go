@@ -0,0 +1,100 @@
+package mongo
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"onpaper-api-go/logger"
+)
+
+// indexes 启动时需要保证存在的索引 集合名 -> 索引
+var indexes = map[string][]mongo.IndexModel{
+	// 每个用户只能有一个默认收藏夹
+	"collect_folder": {
+		{
+			Keys: bson.D{{"user_id", 1}},
+			Options: options.Index().SetName("uniq_default_folder").SetUnique(true).
+				SetPartialFilterExpression(bson.D{{"is_default", true}}),
+		},
+	},
+	// 同一个作品在一个收藏夹中只能收藏一次
+	"collect_folder_item": {
+		{
+			Keys:    bson.D{{"folder_id", 1}, {"msg_id", 1}},
+			Options: options.Index().SetName("uniq_folder_msg").SetUnique(true),
+		},
+	},
+	// 相似图片按分段查找 同一个作品的同一张图片只保存一个哈希
+	"picture_hash": {
+		{Keys: bson.D{{"bands", 1}}, Options: options.Index().SetName("idx_bands")},
+		{
+			Keys:    bson.D{{"artwork_id", 1}, {"filename", 1}},
+			Options: options.Index().SetName("uniq_artwork_file").SetUnique(true),
+		},
+	},
+	// 每个作品只有一条审核记录
+	"artwork_review": {
+		{
+			Keys:    bson.D{{"artwork_id", 1}},
+			Options: options.Index().SetName("uniq_artwork_review").SetUnique(true),
+		},
+	},
+	// 大用户删除已推送给粉丝的feed时按发布者查找
+	"feed": {
+		{Keys: bson.D{{"send_id", 1}, {"accept_id", 1}}, Options: options.Index().SetName("idx_send_accept")},
+	},
+	// 大用户的发布记录 按发布者拉取最新的feed 同一条作品或动态只保存一次
+	"feed_outbox": {
+		{
+			Keys:    bson.D{{"send_id", 1}, {"msg_id", -1}},
+			Options: options.Index().SetName("uniq_send_msg").SetUnique(true),
+		},
+	},
+	// 每个用户在一个投票中只能投一次
+	"poll_vote": {
+		{
+			Keys:    bson.D{{"trend_id", 1}, {"user_id", 1}},
+			Options: options.Index().SetName("uniq_trend_user").SetUnique(true),
+		},
+	},
+	// 话题动态和关注话题的feed 按正文中的 #话题# 查找
+	// 转发列表按转发的原作和经过的转发链查找
+	"trend": {
+		{Keys: bson.D{{"hashtags.topic_id", 1}}, Options: options.Index().SetName("idx_hashtags_topic")},
+		{
+			Keys:    bson.D{{"forward_info.id", 1}, {"forward_info.type", 1}, {"trend_id", -1}},
+			Options: options.Index().SetName("idx_forward_target"),
+		},
+		{
+			Keys:    bson.D{{"forward_info.chain", 1}, {"trend_id", -1}},
+			Options: options.Index().SetName("idx_forward_chain"),
+		},
+	},
+	// 每个作品或动态只能有一条置顶评论
+	"comment": {
+		{
+			Keys: bson.D{{"own_id", 1}},
+			Options: options.Index().SetName("uniq_top_comment").SetUnique(true).
+				SetPartialFilterExpression(bson.D{{"is_top", true}}),
+		},
+	},
+	// 作品的修改记录版本号不能重复
+	"artwork_revision": {
+		{
+			Keys:    bson.D{{"artwork_id", 1}, {"version", 1}},
+			Options: options.Index().SetName("uniq_artwork_version").SetUnique(true),
+		},
+	},
+}
+
+// ensureIndexes 创建索引 已经存在的不会重复创建
+// 旧数据有重复导致唯一索引创建失败时只记录日志 不影响服务启动
+func ensureIndexes(ctx context.Context) {
+	for name, models := range indexes {
+		_, err := Mgo.Collection(name).Indexes().CreateMany(ctx, models)
+		if err != nil {
+			logger.ErrZapLog(err, "ensureIndexes fail: "+name)
+		}
+	}
+}
@@ -34,6 +34,7 @@ func Init(config *settings.MongodbConfig) (err error) {
 	}
 
 	Mgo = client.Database(config.DbName)
+	ensureIndexes(ctx)
 
 	return
 }
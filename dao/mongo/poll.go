@@ -0,0 +1,125 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	m "onpaper-api-go/models"
+	"time"
+)
+
+// GetTrendPollInfo 获取投票的动态信息 动态不存在或已删除时 Poll 为空
+func GetTrendPollInfo(trendId int64) (info m.TrendPollInfo, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.D{{"trend_id", trendId}, {"is_delete", false}, {"state", 0}}
+	opts := options.FindOne().SetProjection(bson.D{{"_id", 0}, {"user_id", 1}, {"whoSee", 1}, {"poll", 1}})
+	err = Mgo.Collection("trend").FindOne(ctx, filter, opts).Decode(&info)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return info, nil
+	}
+	if err != nil {
+		err = errors.Wrap(err, "GetTrendPollInfo fail")
+	}
+	return
+}
+
+// SavePollVote 保存投票记录 已经投过票时 isNew 为 false
+func SavePollVote(vote m.PollVote) (isNew bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.D{{"trend_id", vote.TrendId}, {"user_id", vote.UserId}}
+	opts := options.Update().SetUpsert(true)
+	res, err := Mgo.Collection("poll_vote").UpdateOne(ctx, filter, bson.D{{"$setOnInsert", vote}}, opts)
+	// 同时投票时 唯一索引保证只有一个插入成功
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		err = errors.Wrap(err, "SavePollVote fail")
+		return
+	}
+	isNew = res.UpsertedCount == 1
+	return
+}
+
+// DeletePollVote 删除投票记录 计数失败时回滚
+func DeletePollVote(trendId int64, userId string) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.D{{"trend_id", trendId}, {"user_id", userId}}
+	_, err = Mgo.Collection("poll_vote").DeleteOne(ctx, filter)
+	if err != nil {
+		err = errors.Wrap(err, "DeletePollVote fail")
+	}
+	return
+}
+
+// GetUserPollVote 获取用户在多个投票中的选择
+func GetUserPollVote(trendIds []int64, userId string) (choices map[int64][]int, err error) {
+	choices = make(map[int64][]int)
+	if len(trendIds) == 0 || userId == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.D{{"trend_id", bson.M{"$in": trendIds}}, {"user_id", userId}}
+	opts := options.FindOptions{
+		Projection: bson.D{{"_id", 0}, {"trend_id", 1}, {"choices", 1}},
+	}
+	cur, err := Mgo.Collection("poll_vote").Find(ctx, filter, &opts)
+	if err != nil {
+		err = errors.Wrap(err, "GetUserPollVote find fail")
+		return
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var result m.PollVote
+		err = cur.Decode(&result)
+		if err != nil {
+			err = errors.Wrap(err, "GetUserPollVote decode fail")
+			return
+		}
+		choices[result.TrendId] = result.Choices
+	}
+	if err = cur.Err(); err != nil {
+		err = errors.Wrap(err, "GetUserPollVote cur fail")
+	}
+	return
+}
+
+// UpdatePollCount 把缓存中的投票统计落盘
+func UpdatePollCount(counts []m.PollCount) (err error) {
+	if len(counts) == 0 {
+		return
+	}
+
+	updates := make([]mongo.WriteModel, 0, len(counts))
+	for _, count := range counts {
+		update := bson.D{{"poll.voters", count.Voters}}
+		for index, votes := range count.Votes {
+			update = append(update, bson.E{Key: fmt.Sprintf("poll.options.%d.votes", index), Value: votes})
+		}
+		filter := bson.D{{"trend_id", count.TrendId}}
+		updates = append(updates, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(bson.D{{"$set", update}}))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// 即使一个错了 其他也更新
+	opts := options.BulkWrite().SetOrdered(false)
+	_, err = Mgo.Collection("trend").BulkWrite(ctx, updates, opts)
+	if err != nil {
+		err = errors.Wrap(err, "UpdatePollCount fail")
+	}
+	return
+}
@@ -0,0 +1,49 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	m "onpaper-api-go/models"
+	"time"
+)
+
+// GetRecentArtInteract 获取一段时间内所有用户对作品的点赞或收藏 按时间倒序
+// table 为 user_like 或 user_collect
+func GetRecentArtInteract(table string, since time.Time) (data []m.RecommendInteract, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	filter := bson.D{
+		{"type", "aw"},
+		{"is_cancel", false},
+		{"updateAt", bson.D{{"$gte", since}}},
+	}
+	opts := options.FindOptions{
+		Sort:       bson.M{"updateAt": -1},
+		Projection: bson.D{{"user_id", 1}, {"msg_id", 1}, {"author_id", 1}, {"updateAt", 1}, {"_id", 0}},
+	}
+
+	cur, err := Mgo.Collection(table).Find(ctx, filter, &opts)
+	if err != nil {
+		err = errors.Wrap(err, fmt.Sprintf("GetRecentArtInteract %s find fail", table))
+		return
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var result m.RecommendInteract
+		err = cur.Decode(&result)
+		if err != nil {
+			err = errors.Wrap(err, "GetRecentArtInteract decode fail")
+			return
+		}
+		data = append(data, result)
+	}
+	if err = cur.Err(); err != nil {
+		err = errors.Wrap(err, "GetRecentArtInteract cur fail")
+	}
+	return
+}
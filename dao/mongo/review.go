@@ -0,0 +1,226 @@
+package mongo
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	m "onpaper-api-go/models"
+	"onpaper-api-go/utils/phash"
+	"strconv"
+	"time"
+)
+
+// SavePictureHashes 保存作品图片的哈希索引 同一个作品的同一张图片重复保存时覆盖
+func SavePictureHashes(hashes []m.PictureHash) (err error) {
+	if len(hashes) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	dataList := make([]mongo.WriteModel, 0, len(hashes))
+	for _, hash := range hashes {
+		filter := bson.D{{"artwork_id", hash.ArtworkId}, {"filename", hash.FileName}}
+		dataList = append(dataList, mongo.NewReplaceOneModel().SetFilter(filter).SetReplacement(hash).SetUpsert(true))
+	}
+
+	table := Mgo.Collection("picture_hash")
+	_, err = table.BulkWrite(ctx, dataList)
+	if err != nil {
+		err = errors.Wrap(err, "SavePictureHashes mongodb fail")
+	}
+	return
+}
+
+// GetPictureHashFiles 获取作品已经保存哈希的图片文件名
+func GetPictureHashFiles(artworkId int64) (fileNames []string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	values, err := Mgo.Collection("picture_hash").Distinct(ctx, "filename", bson.D{{"artwork_id", artworkId}})
+	if err != nil {
+		err = errors.Wrap(err, "GetPictureHashFiles distinct fail")
+		return
+	}
+	fileNames = make([]string, 0, len(values))
+	for _, value := range values {
+		if name, ok := value.(string); ok {
+			fileNames = append(fileNames, name)
+		}
+	}
+	return
+}
+
+// DeletePictureHashes 删除作品图片的哈希索引 fileNames 为空时删除作品的全部图片
+func DeletePictureHashes(artworkId int64, fileNames []string) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.D{{"artwork_id", artworkId}}
+	if len(fileNames) > 0 {
+		filter = append(filter, bson.E{Key: "filename", Value: bson.M{"$in": fileNames}})
+	}
+	table := Mgo.Collection("picture_hash")
+	_, err = table.DeleteMany(ctx, filter)
+	if err != nil {
+		err = errors.Wrap(err, "DeletePictureHashes mongodb fail")
+	}
+	return
+}
+
+// FindSimilarPictures 查找其他用户作品中相似的图片
+// hashes 是上传的 文件名 -> 哈希 先用分段索引筛选 再计算汉明距离
+func FindSimilarPictures(userId string, hashes map[string]uint64) (similar []m.SimilarPicture, err error) {
+	similar = make([]m.SimilarPicture, 0)
+	if len(hashes) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	bands := make([]string, 0, len(hashes)*phash.BandCount)
+	for _, hash := range hashes {
+		bands = append(bands, phash.Bands(hash)...)
+	}
+
+	table := Mgo.Collection("picture_hash")
+	var limit int64 = 500
+	opts := options.FindOptions{
+		Limit:      &limit,
+		Projection: bson.D{{"_id", 0}, {"bands", 0}},
+	}
+	filter := bson.D{
+		{"bands", bson.M{"$in": bands}},
+		{"user_id", bson.M{"$ne": userId}},
+	}
+
+	var cur *mongo.Cursor
+	cur, err = table.Find(ctx, filter, &opts)
+	if err != nil {
+		err = errors.Wrap(err, "FindSimilarPictures mongodb fail")
+		return
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var result m.PictureHash
+		err = cur.Decode(&result)
+		if err != nil {
+			return
+		}
+		for fileName, hash := range hashes {
+			distance := phash.Distance(hash, uint64(result.Hash))
+			if distance > m.SimilarDistance {
+				continue
+			}
+			similar = append(similar, m.SimilarPicture{
+				FileName:  fileName,
+				ArtworkId: strconv.FormatInt(result.ArtworkId, 10),
+				UserId:    result.UserId,
+				MatchFile: result.FileName,
+				Distance:  distance,
+			})
+		}
+	}
+	err = cur.Err()
+	return
+}
+
+// CreateArtworkReview 创建作品审核 作品已经有审核记录时重新进入等待审核
+func CreateArtworkReview(review m.ArtworkReview) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	table := Mgo.Collection("artwork_review")
+	filter := bson.D{{"artwork_id", review.ArtworkId}}
+	_, err = table.ReplaceOne(ctx, filter, review, options.Replace().SetUpsert(true))
+	if err != nil {
+		err = errors.Wrap(err, "CreateArtworkReview mongodb fail")
+	}
+	return
+}
+
+// DeleteArtworkReview 删除等待审核的作品审核 作品保存失败时使用
+func DeleteArtworkReview(artworkId int64) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	table := Mgo.Collection("artwork_review")
+	_, err = table.DeleteOne(ctx, bson.D{{"artwork_id", artworkId}, {"status", 0}})
+	if err != nil {
+		err = errors.Wrap(err, "DeleteArtworkReview mongodb fail")
+	}
+	return
+}
+
+// GetArtworkReview 获取作品的审核
+func GetArtworkReview(artworkId int64) (review m.ArtworkReview, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	table := Mgo.Collection("artwork_review")
+	err = table.FindOne(ctx, bson.D{{"artwork_id", artworkId}}).Decode(&review)
+	return
+}
+
+// SaveReviewDecision 保存管理员的审核结果
+func SaveReviewDecision(artworkId int64, status int8, adminId, remark string) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	table := Mgo.Collection("artwork_review")
+	filter := bson.D{{"artwork_id", artworkId}}
+	update := bson.D{{"$set", bson.D{
+		{"status", status},
+		{"admin_id", adminId},
+		{"remark", remark},
+		{"updateAt", time.Now()},
+	}}}
+	_, err = table.UpdateOne(ctx, filter, update)
+	if err != nil {
+		err = errors.Wrap(err, "SaveReviewDecision mongodb fail")
+	}
+	return
+}
+
+// GetArtworkReviewList 按状态获取审核列表
+func GetArtworkReviewList(query m.ReviewListQuery) (reviews []m.ArtworkReview, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	table := Mgo.Collection("artwork_review")
+
+	var limit int64 = 20
+	opts := options.FindOptions{
+		Sort:       bson.M{"artwork_id": -1},
+		Limit:      &limit,
+		Projection: bson.D{{"_id", 0}},
+	}
+
+	filter := bson.D{{"status", query.Status}}
+	if *query.NextId != 0 {
+		filter = append(filter, bson.E{Key: "artwork_id", Value: bson.M{"$lt": *query.NextId}})
+	}
+
+	var cur *mongo.Cursor
+	cur, err = table.Find(ctx, filter, &opts)
+	if err != nil {
+		err = errors.Wrap(err, "GetArtworkReviewList mongodb fail")
+		return
+	}
+	defer cur.Close(ctx)
+
+	reviews = make([]m.ArtworkReview, 0)
+	for cur.Next(ctx) {
+		var result m.ArtworkReview
+		err = cur.Decode(&result)
+		if err != nil {
+			return
+		}
+		reviews = append(reviews, result)
+	}
+	err = cur.Err()
+	return
+}
@@ -0,0 +1,125 @@
+package mongo
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	m "onpaper-api-go/models"
+	"time"
+)
+
+// SaveArtworkRevision 保存作品的修改记录 第一次修改时先保存修改前的原始版本
+// (artwork_id, version) 有唯一索引 并发修改时版本号冲突的重新读取最新版本
+func SaveArtworkRevision(before m.ArtworkSnapshot, revision m.ArtworkRevision) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	revisionTable := Mgo.Collection("artwork_revision")
+	filter := bson.D{{"artwork_id", revision.ArtworkId}}
+	opts := options.FindOneOptions{
+		Sort:       bson.D{{"version", -1}},
+		Projection: bson.D{{"version", 1}},
+	}
+
+	for i := 0; i < 3; i++ {
+		var last m.ArtworkRevision
+		err = revisionTable.FindOne(ctx, filter, &opts).Decode(&last)
+		if err == mongo.ErrNoDocuments {
+			origin := m.ArtworkRevision{
+				ArtworkId: revision.ArtworkId,
+				UserId:    revision.UserId,
+				Version:   0,
+				Snapshot:  before,
+				Changes:   make([]m.RevisionChange, 0),
+				CreateAt:  time.Now(),
+			}
+			// 原始版本已经被并发的修改保存了
+			_, err = revisionTable.InsertOne(ctx, origin)
+			if err != nil && !mongo.IsDuplicateKeyError(err) {
+				err = errors.Wrap(err, "SaveArtworkRevision insert origin fail")
+				return
+			}
+		} else if err != nil {
+			err = errors.Wrap(err, "SaveArtworkRevision find last fail")
+			return
+		}
+
+		revision.Version = last.Version + 1
+		_, err = revisionTable.InsertOne(ctx, revision)
+		if mongo.IsDuplicateKeyError(err) {
+			continue
+		}
+		if err != nil {
+			err = errors.Wrap(err, "SaveArtworkRevision insert fail")
+		}
+		return
+	}
+	err = errors.Wrap(err, "SaveArtworkRevision version conflict")
+	return
+}
+
+// GetArtworkRevisions 获取作品的修改记录 不返回每个版本的内容
+func GetArtworkRevisions(artworkId string) (revisions []m.ArtworkRevision, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	revisionTable := Mgo.Collection("artwork_revision")
+	filter := bson.D{{"artwork_id", artworkId}}
+	opts := options.FindOptions{
+		Sort:       bson.D{{"version", -1}},
+		Projection: bson.D{{"_id", 0}, {"snapshot", 0}},
+	}
+
+	cur, err := revisionTable.Find(ctx, filter, &opts)
+	if err != nil {
+		return
+	}
+	defer cur.Close(ctx)
+
+	revisions = make([]m.ArtworkRevision, 0)
+	for cur.Next(ctx) {
+		var result m.ArtworkRevision
+		err = cur.Decode(&result)
+		if err != nil {
+			return
+		}
+		revisions = append(revisions, result)
+	}
+	err = cur.Err()
+	return
+}
+
+// GetArtworkRevision 获取作品某个版本
+func GetArtworkRevision(artworkId string, version int) (revision m.ArtworkRevision, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	revisionTable := Mgo.Collection("artwork_revision")
+	filter := bson.D{{"artwork_id", artworkId}, {"version", version}}
+	opts := options.FindOneOptions{
+		Projection: bson.D{{"_id", 0}},
+	}
+	err = revisionTable.FindOne(ctx, filter, &opts).Decode(&revision)
+	return
+}
+
+// GetRevisedArtworkIds 获取有修改记录的作品id
+func GetRevisedArtworkIds() (ids []string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	values, err := Mgo.Collection("artwork_revision").Distinct(ctx, "artwork_id", bson.D{})
+	if err != nil {
+		err = errors.Wrap(err, "GetRevisedArtworkIds distinct fail")
+		return
+	}
+	ids = make([]string, 0, len(values))
+	for _, value := range values {
+		if id, ok := value.(string); ok {
+			ids = append(ids, id)
+		}
+	}
+	return
+}
@@ -79,6 +79,8 @@ func GetMoreTrendInfo(trendIds []int64) (trendData m.TrendList, err error) {
 			{"count", 1},
 			{"text", 1},
 			{"topic", 1},
+			{"hashtags", 1},
+			{"poll", 1},
 			{"createAt", 1},
 			{"user_id", 1},
 			{"is_delete", 1}},
@@ -207,8 +209,9 @@ func GetTopicTrend(topicId string, sortType string, page uint8) (result []m.Tren
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	// 选择的话题和正文中 #话题# 都算
 	filter := bson.D{
-		{"topic.topic_id", topicId},
+		{"$or", bson.A{bson.M{"topic.topic_id": topicId}, bson.M{"hashtags.topic_id": topicId}}},
 		{"is_delete", false},
 		{"state", 0},
 		{"whoSee", "public"},
@@ -288,3 +291,51 @@ func GetNewTrend(nextId int64) (msgData []m.MongoFeed, err error) {
 	}
 	return
 }
+
+// GetInterestTrendFeed 按关注的话题拉取公开动态 nextId 为 0 时从最新开始
+func GetInterestTrendFeed(topicIds []string, userId string, nextId int64) (feeds []m.MongoFeed, err error) {
+	if len(topicIds) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.D{
+		{"$or", bson.A{bson.M{"topic.topic_id": bson.M{"$in": topicIds}}, bson.M{"hashtags.topic_id": bson.M{"$in": topicIds}}}},
+		{"is_delete", false},
+		{"state", 0},
+		{"whoSee", "public"},
+		{"user_id", bson.M{"$ne": userId}},
+	}
+	if nextId != 0 {
+		filter = append(filter, bson.E{Key: "trend_id", Value: bson.M{"$lt": nextId}})
+	}
+
+	var limit int64 = m.InterestFeedLimit
+	opts := options.FindOptions{
+		Sort:       bson.M{"trend_id": -1},
+		Limit:      &limit,
+		Projection: bson.D{{"_id", 0}, {"trend_id", 1}, {"user_id", 1}},
+	}
+
+	trendTable := Mgo.Collection("trend")
+	cur, err := trendTable.Find(ctx, filter, &opts)
+	if err != nil {
+		err = errors.Wrap(err, "GetInterestTrendFeed fail")
+		return
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var temp m.TrendIdAndUserId
+		err = cur.Decode(&temp)
+		if err != nil {
+			return
+		}
+		feeds = append(feeds, m.MongoFeed{MsgID: temp.TrendId, SendId: temp.UserId, Type: "tr"})
+	}
+	if err = cur.Err(); err != nil {
+		return
+	}
+	return
+}
@@ -11,11 +11,14 @@ import (
 	"time"
 )
 
-// GetUserHomeArtwork 数据库中查询 用户作品信息
-func GetUserHomeArtwork(userId string, pageNum int, sortType string) (artworkCounts []models.ArtworkCount, err error) {
+// GetUserHomeArtwork 数据库中查询 用户作品信息 审核中和下架的作品只有自己可以看到
+func GetUserHomeArtwork(userId string, pageNum int, sortType string, isOwner bool) (artworkCounts []models.ArtworkCount, err error) {
 	var sqlStr1 string
 
 	baseSql := `SELECT artwork_id,user_id,likes,whoSee from artwork_count WHERE user_id = ? and is_delete = 0 `
+	if !isOwner {
+		baseSql += `and state = 0 `
+	}
 	switch sortType {
 	case "now":
 		sqlStr1 = baseSql + `ORDER BY artwork_id DESC LIMIT ?,30;`
@@ -42,7 +45,7 @@ func GetUserRecentlyArtworkId(userId string) (artIds []int64, err error) {
 	start := time.Now().AddDate(-1, 0, 0).Format("2006-01-02")
 
 	sqlStr := `SELECT artwork_id from artwork 
-              WHERE user_id = ? and is_delete = 0 and whoSee != 'privacy'  
+              WHERE user_id = ? and is_delete = 0 and whoSee != 'privacy' and state = 0
               and createAT between ? and ?
 			  ORDER BY artwork_id DESC
 			  `
@@ -62,8 +65,9 @@ func GetOneArtwork(artworkId string) (artwork models.ShowArtworkInfo, err error)
 
 	eg.Go(func() error {
 		//获取作品信息
-		sqlStr1 := `SELECT a.artwork_id,a.user_id,title,pic_count,cover,zone,a.whoSee,adults,a.is_delete,
-       				views,likes,collects,comments,forwards,comment,copyright,a.createAT
+		sqlStr1 := `SELECT a.artwork_id,a.user_id,title,pic_count,cover,zone,a.whoSee,adults,a.is_delete,a.state,
+       				views,likes,collects,comments,forwards,downloads,comment,copyright,allow_reference,allow_repost,allow_ai_train,
+       				a.edit_at,a.createAT
 					from artwork as a
 					INNER JOIN artwork_count as ac 
 					on a.artwork_id = ac.artwork_id
@@ -107,10 +111,25 @@ func GetOneArtwork(artworkId string) (artwork models.ShowArtworkInfo, err error)
 		return mErr
 	})
 
+	var colors []models.PictureColor
+	eg.Go(func() (mErr error) {
+		//获取图片主色
+		colors, mErr = GetArtworkColors(artworkId)
+		return
+	})
+
 	err = eg.Wait()
 	if err != nil {
 		return
 	}
+	artwork.Copyright = artwork.CopyRight
+	for i := range artwork.Picture {
+		for _, color := range colors {
+			if color.FileName == artwork.Picture[i].FileName {
+				artwork.Picture[i].Palette = append(artwork.Picture[i].Palette, color)
+			}
+		}
+	}
 	author := new(models.UserSimpleInfoCount)
 	eg.Go(func() error {
 		//获取作者信息
@@ -129,11 +148,11 @@ func GetOneArtwork(artworkId string) (artwork models.ShowArtworkInfo, err error)
 
 	eg.Go(func() error {
 		//获取单作品展示时 作者其他作品信息
-		sqlStr7 := `(SELECT artwork_id,cover
+		sqlStr7 := `(SELECT artwork_id,cover,adults
 				from artwork WHERE user_id = ? AND artwork_id >= ? and is_delete =0 and whoSee != 'privacy' and state = 0
 				LIMIT 15)
 				UNION ALL
-				(SELECT artwork_id,cover
+				(SELECT artwork_id,cover,adults
 				from artwork WHERE user_id = ? AND artwork_id < ? and is_delete =0 and whoSee != 'privacy' and state = 0
 				ORDER BY artwork_id DESC
 				LIMIT 15)
@@ -211,7 +230,7 @@ func GetChannelArtwork(query models.QueryChanelType) (dataList []models.ArtIdAnd
 	var oderStr string
 	baseStr := `SELECT a.artwork_id,a.user_id from artwork_count as ac
                 left join artwork a on ac.artwork_id = a.artwork_id          
-                where a.is_delete = 0 and a.whoSee ='public'
+                where a.is_delete = 0 and a.whoSee ='public' and a.state = 0
 				`
 	var args []interface{}
 	// 是否单独查询分区
@@ -219,6 +238,14 @@ func GetChannelArtwork(query models.QueryChanelType) (dataList []models.ArtIdAnd
 		baseStr = baseStr + " and zone = ?"
 		args = append(args, query.Zone)
 	}
+	// 按授权筛选
+	licenseStr, licenseArgs := licenseFilterSql(query.LicenseFilter)
+	baseStr = baseStr + licenseStr
+	args = append(args, licenseArgs...)
+	// 按颜色筛选
+	colorStr, colorArgs := colorFilterSql(query.ColorFilter)
+	baseStr = baseStr + colorStr
+	args = append(args, colorArgs...)
 	//是否翻页
 	if query.NextId != "0" {
 		baseStr = baseStr + " and a.artwork_id < ? "
@@ -271,9 +298,11 @@ func UpdateArtInfo(info models.UpdateArtInfo) (err error) {
 			mErr = errors.Wrap(mErr, "UpdateArtInfo: sql1 get fail")
 			return
 		}
-		sql2 := `UPDATE artwork SET title = ?,zone = ?,whoSee=?,adults=?,comment=?,copyright=?
+		sql2 := `UPDATE artwork SET title = ?,zone = ?,whoSee=?,adults=?,comment=?,copyright=?,
+			allow_reference = ?,allow_repost = ?,allow_ai_train = ?,edit_at = IF(?, NOW(), edit_at)
 			WHERE artwork_id = ? `
-		_, mErr = tx.Exec(sql2, info.Title, info.Zone, info.WhoSee, info.Adult, info.Comment, info.CopyRight, info.ArtworkId)
+		_, mErr = tx.Exec(sql2, info.Title, info.Zone, info.WhoSee, info.Adult, info.Comment, info.CopyRight,
+			info.AllowReference, info.AllowRepost, info.AllowAITrain, info.ShowUpdate, info.ArtworkId)
 		if mErr != nil {
 			mErr = errors.Wrap(mErr, "UpdateArtInfo: sql2 get fail")
 		}
@@ -389,6 +418,13 @@ func GetArtCount[T int64 | string](artworkIds []T) (count []models.ArtworkCount,
 	return
 }
 
+// GetArtworkState 获取作品状态 作品不存在时返回 sql.ErrNoRows
+func GetArtworkState(artId int64) (state uint8, err error) {
+	sqlStr := `select state from artwork where artwork_id = ?`
+	err = db.Get(&state, sqlStr, artId)
+	return
+}
+
 // VerifyArtOwner 验证作品所有权
 func VerifyArtOwner(userId, artId string) (isOwner bool, err error) {
 	var authorId string
@@ -452,3 +488,20 @@ func DeleteArtwork(artId, userId string) (err error) {
 
 	return
 }
+
+// licenseFilterSql 按授权筛选作品的sql条件 表别名必须是 a
+func licenseFilterSql(filter models.LicenseFilter) (sqlStr string, args []interface{}) {
+	if filter.License != "" {
+		sqlStr += " and a.copyright = ? "
+		args = append(args, filter.License)
+	}
+	switch filter.Allow {
+	case "reference":
+		sqlStr += " and a.allow_reference = 1 "
+	case "repost":
+		sqlStr += " and a.allow_repost = 1 "
+	case "aiTrain":
+		sqlStr += " and a.allow_ai_train = 1 "
+	}
+	return
+}
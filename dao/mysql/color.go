@@ -0,0 +1,119 @@
+package mysql
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	m "onpaper-api-go/models"
+	"onpaper-api-go/utils/palette"
+	"strings"
+)
+
+const (
+	ColorRadius    = 48 // 按颜色筛选时 RGB 距离在这个范围内算相似
+	ColorMinWeight = 10 // 占比小于这个值的主色不参与筛选
+)
+
+// colorFilterSql 按颜色筛选作品的sql条件 表别名必须是 a
+// 先用颜色分桶缩小范围 再计算 RGB 距离
+func colorFilterSql(filter m.ColorFilter) (sqlStr string, args []interface{}) {
+	color, ok := palette.Parse(filter.Color)
+	if !ok {
+		return
+	}
+
+	buckets := palette.Buckets(color, ColorRadius)
+	holders := make([]string, 0, len(buckets))
+	for _, bucket := range buckets {
+		holders = append(holders, "?")
+		args = append(args, bucket)
+	}
+
+	sqlStr = fmt.Sprintf(` and EXISTS (SELECT 1 FROM artwork_color as c
+				WHERE c.artwork_id = a.artwork_id and c.bucket IN (%s) and c.weight >= ?
+				and (c.r - ?) * (c.r - ?) + (c.g - ?) * (c.g - ?) + (c.b - ?) * (c.b - ?) <= ?) `,
+		strings.Join(holders, ","))
+	args = append(args, ColorMinWeight,
+		int(color.R), int(color.R), int(color.G), int(color.G), int(color.B), int(color.B),
+		ColorRadius*ColorRadius)
+	return
+}
+
+// insertPictureColors 保存图片的主色 没有主色的图片跳过
+func insertPictureColors(tx *sql.Tx, artworkId interface{}, fileList []*m.PicsType) (err error) {
+	colorStrings := make([]string, 0, len(fileList)*5)
+	colorArgs := make([]interface{}, 0, len(fileList)*5*8)
+	for _, file := range fileList {
+		for _, pc := range file.Palette {
+			c, ok := palette.Parse(pc.Color)
+			if !ok {
+				continue
+			}
+			colorStrings = append(colorStrings, "(?,?,?,?,?,?,?,?)")
+			colorArgs = append(colorArgs, artworkId, file.FileName, pc.Color, c.R, c.G, c.B, c.Bucket(), pc.Weight)
+		}
+	}
+	if len(colorStrings) == 0 {
+		return
+	}
+
+	sqlStr := fmt.Sprintf("INSERT INTO artwork_color (artwork_id,filename,color,r,g,b,bucket,weight) VALUES %s",
+		strings.Join(colorStrings, ","))
+	_, err = tx.Exec(sqlStr, colorArgs...)
+	if err != nil {
+		err = errors.Wrap(err, "insertPictureColors fail")
+	}
+	return
+}
+
+// SaveArtworkColors 保存作品图片的主色 先删除这些图片已经保存的主色 重复保存时覆盖
+func SaveArtworkColors(artworkId int64, fileList []*m.PicsType) (err error) {
+	if len(fileList) == 0 {
+		return
+	}
+	// 开启一个事务
+	tx, err := db.Begin()
+	if err != nil {
+		err = errors.Wrap(err, "transaction begin failed")
+		return
+	}
+	// 函数关闭时 如果出错 则回滚，没出错则 提交
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+		} else if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+			return
+		}
+	}()
+
+	names := make([]string, 0, len(fileList))
+	for _, file := range fileList {
+		names = append(names, file.FileName)
+	}
+	query, args, err := sqlx.In("DELETE FROM artwork_color WHERE artwork_id = ? and filename IN (?)", artworkId, names)
+	if err != nil {
+		err = errors.Wrap(err, "SaveArtworkColors sqlx in fail")
+		return
+	}
+	_, err = tx.Exec(db.Rebind(query), args...)
+	if err != nil {
+		err = errors.Wrap(err, "SaveArtworkColors delete fail")
+		return
+	}
+	err = insertPictureColors(tx, artworkId, fileList)
+	return
+}
+
+// GetArtworkColors 获取作品图片的主色 按占比从大到小
+func GetArtworkColors(artworkId string) (colors []m.PictureColor, err error) {
+	sqlStr := `SELECT filename,color,weight FROM artwork_color WHERE artwork_id = ? ORDER BY weight DESC`
+	err = db.Select(&colors, sqlStr, artworkId)
+	if err != nil {
+		err = errors.Wrap(err, "GetArtworkColors fail")
+	}
+	return
+}
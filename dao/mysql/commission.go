@@ -1,7 +1,9 @@
 package mysql
 
 import (
+	"database/sql"
 	"fmt"
+	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
 	m "onpaper-api-go/models"
 	"onpaper-api-go/utils/formatTools"
@@ -10,10 +12,10 @@ import (
 )
 
 func GetUserCommissionScore(sender, artist string) (plan m.UserInvitePlan, err error) {
-	sqlStr := `SELECT cc.user_id,username,avatar_name,SUM(send_finish + receive_finish) as finish ,rating FROM commission_count as cc
+	sqlStr := `SELECT cc.user_id,username,avatar_name,SUM(send_finish + receive_finish) as finish ,rating,dispute_lose FROM commission_count as cc
                left join user_profile as up on cc.user_id = up.user_id
                WHERE cc.user_id in (?,?)
-			   GROUP BY cc.user_id,username,avatar_name,rating;`
+			   GROUP BY cc.user_id,username,avatar_name,rating,dispute_lose;`
 
 	var userInfo []m.UserScoreInfo
 	err = db.Select(&userInfo, sqlStr, sender, artist)
@@ -99,14 +101,35 @@ func UpdateCommissionCuntAndEvaluate(senderId, receiveId string, nextStatus, now
 			values (?,?,?,?,?,?,?,?,?,?)`
 
 		_, err = tx.Exec(sql1, e.EvaluateId, e.InviteId, e.InviteOwn, e.Receiver, e.Sender, e.Text, e.Rate1, e.Rate2, e.Rate3, e.Score)
+		if err != nil {
+			return
+		}
+		err = recomputeUserRating(tx, e.Receiver)
 	}
 
 	return
 }
 
+// recomputeUserRating 按评价权重重新计算用户的约稿评分 纠纷裁决调整的权重在这里生效
+// 所有评分更新都要用这个 否则会丢掉裁决的调整
+func recomputeUserRating(e sqlx.Execer, userIds ...string) (err error) {
+	sqlStr := `UPDATE commission_count AS cc
+				SET rating = IFNULL((SELECT SUM(total_rating * weight) / SUM(weight) FROM commission_evaluate
+				                     WHERE receiver = cc.user_id AND is_delete = 0 AND weight > 0), rating)
+				WHERE user_id = ?`
+	for _, userId := range userIds {
+		_, err = e.Exec(sqlStr, userId)
+		if err != nil {
+			err = errors.Wrap(err, "recomputeUserRating fail")
+			return
+		}
+	}
+	return
+}
+
 // GetPlanEvaluate 获取方案评论
 func GetPlanEvaluate(planId string) (evaluate []m.Evaluate, err error) {
-	sql1 := `SELECT invite_id,invite_own,receiver,sender,total_rating,rate_1,rate_2,rate_3,text,is_delete,createAT FROM commission_evaluate WHERE invite_id = ?`
+	sql1 := `SELECT invite_id,invite_own,receiver,sender,total_rating,rate_1,rate_2,rate_3,text,reply,is_delete,createAT FROM commission_evaluate WHERE invite_id = ?`
 	err = db.Select(&evaluate, sql1, planId)
 
 	return
@@ -150,14 +173,16 @@ func SaveOneEvaluate(e *m.Evaluate) (err error) {
 	_, err = db.Exec(sql1, e.EvaluateId, e.InviteId, e.InviteOwn, e.Receiver, e.Sender, e.Text, e.Rate1, e.Rate2, e.Rate3, e.Score)
 	if err != nil {
 		err = errors.Wrap(err, "mysql SaveOneEvaluate fail")
+		return
 	}
+	err = recomputeUserRating(db, e.Receiver)
 	return
 }
 
 // GetUserReceiveEvaluate 获取用户收到的评论
 func GetUserReceiveEvaluate(userId string, page uint8) (evaluate []m.EvaluateShow, err error) {
 	// 查找已经相互评价且没有上传的评价
-	sqlStr := ` SELECT a.evaluate_id, a.invite_id,a.sender,a.text,a.total_rating,a.createAT
+	sqlStr := ` SELECT a.evaluate_id, a.invite_id,a.sender,a.text,a.total_rating,a.reply,a.reply_at,a.createAT
 				FROM commission_evaluate a
 				JOIN commission_evaluate b
 				ON a.invite_id = b.invite_id AND a.receiver = b.sender AND a.sender = b.receiver
@@ -174,6 +199,36 @@ func GetUserReceiveEvaluate(userId string, page uint8) (evaluate []m.EvaluateSho
 	return
 }
 
+// GetEvaluateReplyInfo 获取回复评价需要校验的信息
+func GetEvaluateReplyInfo(evaluateId int64) (info m.EvaluateReplyInfo, err error) {
+	sqlStr := `SELECT invite_id,receiver,is_delete,reply_at FROM commission_evaluate WHERE evaluate_id = ?`
+	err = db.Get(&info, sqlStr, evaluateId)
+	return
+}
+
+// SaveEvaluateReply 保存被评价人的回复 首次回复记录回复时间
+func SaveEvaluateReply(reply m.EvaluateReply) (err error) {
+	sqlStr := `UPDATE commission_evaluate SET reply = ?, reply_at = IFNULL(reply_at, NOW()) WHERE evaluate_id = ?`
+	_, err = db.Exec(sqlStr, reply.Text, reply.EvaluateId)
+	if err != nil {
+		err = errors.Wrap(err, "mysql SaveEvaluateReply fail")
+	}
+	return
+}
+
+// GetArtistQueue 获取画师进行中的约稿数
+func GetArtistQueue(userId string) (queue int, err error) {
+	sqlStr := `SELECT receive_ing FROM commission_count WHERE user_id = ?`
+	err = db.Get(&queue, sqlStr, userId)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return 0, nil
+		}
+		err = errors.Wrap(err, "mysql GetArtistQueue fail")
+	}
+	return
+}
+
 // UpdateCommissionStatus 更新约稿状态
 func UpdateCommissionStatus(isOpen bool, userId string) (err error) {
 	sqlStr := `update user_profile set commission = ?,have_plan = 1 where user_id = ?`
@@ -0,0 +1,98 @@
+package mysql
+
+import (
+	"github.com/pkg/errors"
+	m "onpaper-api-go/models"
+)
+
+// ApplyDisputeDecision 根据纠纷裁决调整评价权重 过错方计数 并重新计算双方约稿评分
+// fault: artist 画师过错 sender 约稿方过错 both 双方都有过错 none 双方无过错
+// 同一个纠纷只会生效一次 已经生效过的返回当时的裁决 可以安全重试
+func ApplyDisputeDecision(disputeId, inviteId int64, senderId, artistId, fault string, refund uint32) (appliedFault string, err error) {
+	// 开启一个事务
+	tx, err := db.Begin()
+	if err != nil {
+		err = errors.Wrap(err, "transaction begin failed")
+		return
+	}
+	// 函数关闭时 如果出错 则回滚，没出错则 提交
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+		} else if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+			return
+		}
+	}()
+
+	// 记录裁决 已经存在说明生效过了
+	refundStatus := m.RefundNone
+	if refund > 0 {
+		refundStatus = m.RefundWait
+	}
+	sqlStr0 := `INSERT IGNORE INTO commission_dispute_decision (dispute_id, fault, refund_amount, refund_status) VALUES (?,?,?,?)`
+	result, err := tx.Exec(sqlStr0, disputeId, fault, refund, refundStatus)
+	if err != nil {
+		err = errors.Wrap(err, "ApplyDisputeDecision sql0 fail")
+		return
+	}
+	count, _ := result.RowsAffected()
+	if count == 0 {
+		err = tx.QueryRow(`SELECT fault FROM commission_dispute_decision WHERE dispute_id = ?`, disputeId).Scan(&appliedFault)
+		if err != nil {
+			err = errors.Wrap(err, "ApplyDisputeDecision select applied fail")
+		}
+		return
+	}
+	appliedFault = fault
+
+	// 过错方发出的评价不再计入对方评分 双方都有过错时各自减半
+	var faultUsers []string
+	var weight float64
+	switch fault {
+	case "artist":
+		faultUsers, weight = []string{artistId}, 0
+	case "sender":
+		faultUsers, weight = []string{senderId}, 0
+	case "both":
+		faultUsers, weight = []string{artistId, senderId}, 0.5
+	}
+
+	sqlStr1 := `UPDATE commission_evaluate SET weight = ? WHERE invite_id = ? AND sender = ?`
+	sqlStr2 := `UPDATE commission_count SET dispute_lose = dispute_lose + 1 WHERE user_id = ?`
+	for _, uid := range faultUsers {
+		_, err = tx.Exec(sqlStr1, weight, inviteId, uid)
+		if err != nil {
+			err = errors.Wrap(err, "ApplyDisputeDecision sql1 fail")
+			return
+		}
+		_, err = tx.Exec(sqlStr2, uid)
+		if err != nil {
+			err = errors.Wrap(err, "ApplyDisputeDecision sql2 fail")
+			return
+		}
+	}
+
+	// 按权重重新计算双方评分
+	err = recomputeUserRating(tx, senderId, artistId)
+	if err != nil {
+		err = errors.Wrap(err, "ApplyDisputeDecision recompute fail")
+	}
+
+	return
+}
+
+// SetDisputeRefunded 标记裁决的退款已完成 只有等待退款的可以标记
+func SetDisputeRefunded(disputeId int64) (isChange bool, err error) {
+	sqlStr := `UPDATE commission_dispute_decision SET refund_status = ? WHERE dispute_id = ? AND refund_status = ?`
+	result, err := db.Exec(sqlStr, m.RefundDone, disputeId, m.RefundWait)
+	if err != nil {
+		err = errors.Wrap(err, "SetDisputeRefunded fail")
+		return
+	}
+	count, _ := result.RowsAffected()
+	isChange = count == 1
+	return
+}
@@ -0,0 +1,31 @@
+package mysql
+
+import (
+	"github.com/pkg/errors"
+	m "onpaper-api-go/models"
+)
+
+// GetArtworkDownloadInfo 查询下载原图需要验证的作品信息 图片必须属于这个作品
+func GetArtworkDownloadInfo(artworkId, fileName string) (info m.ArtworkDownloadInfo, err error) {
+	sqlStr := `SELECT a.artwork_id,a.user_id,up.username,a.whoSee,a.adults,a.copyright,a.allow_reference,a.allow_repost,a.allow_ai_train
+				FROM artwork as a
+				INNER JOIN artwork_picture as ap on ap.artwork_id = a.artwork_id
+				INNER JOIN user_profile as up on up.user_id = a.user_id
+				WHERE a.artwork_id = ? and ap.filename = ? and a.is_delete = 0 and a.state = 0
+				LIMIT 1`
+	err = db.Get(&info, sqlStr, artworkId, fileName)
+	if err != nil {
+		err = errors.Wrap(err, "GetArtworkDownloadInfo get fail")
+	}
+	return
+}
+
+// IncrArtworkDownload 作品下载数 +1
+func IncrArtworkDownload(artworkId string) (err error) {
+	sqlStr := `UPDATE artwork_count SET downloads = downloads + 1 WHERE artwork_id = ?`
+	_, err = db.Exec(sqlStr, artworkId)
+	if err != nil {
+		err = errors.Wrap(err, "IncrArtworkDownload fail")
+	}
+	return
+}
@@ -10,4 +10,5 @@ var (
 	ErrorPhoneExist        = errors.New("手机已存在")
 	ErrorPhoneNotExist     = errors.New("手机不存在")
 	ErrorInviteCodeInvalid = errors.New("邀请码无效")
+	ErrorBirthdayLocked    = errors.New("生日已填写")
 )
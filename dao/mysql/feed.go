@@ -0,0 +1,48 @@
+package mysql
+
+import (
+	"github.com/pkg/errors"
+	m "onpaper-api-go/models"
+)
+
+// GetFocusCelebrity 获取用户关注的 粉丝数达到 minFans 的用户
+func GetFocusCelebrity(userId string, minFans int) (userIds []string, err error) {
+	sqlStr := `SELECT uf.focus_id FROM user_focus as uf
+			left join user_count as uc on uc.user_id = uf.focus_id
+			WHERE uf.user_id = ? and uf.is_cancel = 0 and uc.fans >= ?`
+	err = db.Select(&userIds, sqlStr, userId, minFans)
+	if err != nil {
+		err = errors.Wrap(err, "GetFocusCelebrity fail")
+	}
+	return
+}
+
+// GetCelebrityUser 获取所有粉丝数达到 minFans 的用户
+func GetCelebrityUser(minFans int) (userIds []string, err error) {
+	sqlStr := `SELECT user_id FROM user_count WHERE fans >= ?`
+	err = db.Select(&userIds, sqlStr, minFans)
+	if err != nil {
+		err = errors.Wrap(err, "GetCelebrityUser fail")
+	}
+	return
+}
+
+// GetArtworkFeedAfter 按 artwork_id 顺序获取正常状态的作品 用于补全发布记录
+func GetArtworkFeedAfter(lastId int64, limit int) (feeds []m.UploadArtOrTrend, err error) {
+	sqlStr := `SELECT artwork_id,user_id FROM artwork_count
+			WHERE is_delete = 0 and state = 0 and artwork_id > ?
+			ORDER BY artwork_id LIMIT ?`
+	var list []struct {
+		ArtworkId int64  `db:"artwork_id"`
+		UserId    string `db:"user_id"`
+	}
+	err = db.Select(&list, sqlStr, lastId, limit)
+	if err != nil {
+		err = errors.Wrap(err, "GetArtworkFeedAfter fail")
+		return
+	}
+	for _, art := range list {
+		feeds = append(feeds, m.UploadArtOrTrend{MsgID: art.ArtworkId, SendId: art.UserId, Type: "aw"})
+	}
+	return
+}
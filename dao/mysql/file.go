@@ -184,11 +184,13 @@ func CreateArtworkInfo(info *m.SaveArtworkInfo) (err error) {
 
 	eg.Go(func() error {
 		// 创建作品信息
-		sqlStr1 := `INSERT INTO artwork (artwork_id,title,user_id,cover,zone,whoSee,pic_count,adults,comment,copyright,first_pic,device) 
-				VALUES (?,?,?,?,?,?,?,?,?,?,?,?);`
+		sqlStr1 := `INSERT INTO artwork (artwork_id,title,user_id,cover,zone,whoSee,pic_count,adults,comment,copyright,
+                     allow_reference,allow_repost,allow_ai_train,first_pic,device,state,publish_at) 
+				VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,IF(? = 0, NOW(), NULL));`
 		_, mErr := tx.Exec(sqlStr1,
 			info.ArtworkId, info.Title, info.UserId, info.Cover, info.Zone, info.WhoSee, picCount,
-			info.Adults, info.Comment, info.CopyRight, info.FirstPic, info.Device)
+			info.Adults, info.Comment, info.CopyRight, info.AllowReference, info.AllowRepost, info.AllowAITrain,
+			info.FirstPic, info.Device, info.State, info.State)
 		if mErr != nil {
 			mErr = errors.Wrap(mErr, "CreateArtworkInfo sqlStr1 into fail")
 		}
@@ -225,10 +227,15 @@ func CreateArtworkInfo(info *m.SaveArtworkInfo) (err error) {
 		return mErr
 	})
 
+	eg.Go(func() error {
+		// 保存图片主色
+		return insertPictureColors(tx, info.ArtworkId, info.FileList)
+	})
+
 	eg.Go(func() error {
 		// 创建作品统计信息
-		sqlStr3 := `INSERT INTO artwork_count (artwork_id,user_id,whoSee) VALUES (?,?,?);`
-		_, mErr := tx.Exec(sqlStr3, info.ArtworkId, info.UserId, info.WhoSee)
+		sqlStr3 := `INSERT INTO artwork_count (artwork_id,user_id,whoSee,state) VALUES (?,?,?,?);`
+		_, mErr := tx.Exec(sqlStr3, info.ArtworkId, info.UserId, info.WhoSee, info.State)
 		if mErr != nil {
 			mErr = errors.Wrap(mErr, "CreateArtworkInfo sqlStr3 into fail")
 		}
@@ -341,37 +348,49 @@ func SaveTrendInfo(trend *m.SaveTrendInfo) (err error) {
 		return
 	}
 
-	// 如果没有填写话题
-	if trend.Topic.Text == "" {
-		return
+	// 如果填写了话题
+	if trend.Topic.Text != "" {
+		trend.Topic, err = getOrCreateTopic(tx, trend.Topic.Text, trend.UserId)
+		if err != nil {
+			return
+		}
+	}
+
+	// 正文中的 #话题# 同名的只查询一次
+	topicMap := map[string]m.TopicType{trend.Topic.Text: trend.Topic}
+	for i, tag := range trend.Hashtags {
+		topic, ok := topicMap[tag.Text]
+		if !ok {
+			topic, err = getOrCreateTopic(tx, tag.Text, trend.UserId)
+			if err != nil {
+				return
+			}
+			topicMap[tag.Text] = topic
+		}
+		trend.Hashtags[i].TopicType = topic
 	}
+	return
+}
 
+// getOrCreateTopic 查找话题 不存在则创建
+func getOrCreateTopic(tx *sql.Tx, text, userId string) (topic m.TopicType, err error) {
 	// 查看话题是否已经存在
-	var needCrate bool
-	var topic m.TopicType
 	sql2 := `select topic_id,text from topic where text = ?`
-	err = db.Get(&topic, sql2, trend.Topic.Text)
-	if err != nil {
-		// 如果不存在 创建话题信息
-		if errors.Cause(err) == sql.ErrNoRows {
-			topic.TopicId = strconv.FormatInt(snowflake.CreateID(), 10)
-			topic.Text = trend.Topic.Text
-			err = nil
-			needCrate = true
-		} else {
-			err = errors.Wrap(err, "SaveTrendInfo sqlStr2 select fail")
-			return
-		}
+	err = db.Get(&topic, sql2, text)
+	if err == nil {
+		return
 	}
-	trend.Topic = topic
-	// 已经存在 不需要插入
-	if !needCrate {
+	if errors.Cause(err) != sql.ErrNoRows {
+		err = errors.Wrap(err, "SaveTrendInfo sqlStr2 select fail")
 		return
 	}
 
+	// 如果不存在 创建话题信息
+	topic.TopicId = strconv.FormatInt(snowflake.CreateID(), 10)
+	topic.Text = text
 	// 插入到话题表 如果话题存在 不会插入成功
 	sql3 := `INSERT IGNORE INTO topic (topic_id, text,user_id) VALUES (?,?,?)`
-	_, err = tx.Exec(sql3, topic.TopicId, topic.Text, trend.UserId)
+	_, err = tx.Exec(sql3, topic.TopicId, topic.Text, userId)
 	if err != nil {
 		err = errors.Wrap(err, "SaveTrendInfo sqlStr3 into fail")
 		return
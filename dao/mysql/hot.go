@@ -0,0 +1,48 @@
+package mysql
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	m "onpaper-api-go/models"
+	"time"
+)
+
+// GetHotCandidateArtwork 获取一段时间内发布的公开作品 用于计算热度
+func GetHotCandidateArtwork(since time.Time) (artworks []m.HotCandidate, err error) {
+	sqlStr := `SELECT a.artwork_id,a.user_id,a.zone,ac.comments,ac.forwards,ac.views,a.createAT
+			FROM artwork a JOIN artwork_count ac ON a.artwork_id = ac.artwork_id
+			WHERE a.createAT >= ? and a.is_delete = 0 and a.whoSee = 'public' and a.state = 0`
+	err = db.Select(&artworks, sqlStr, since)
+	if err != nil {
+		err = errors.Wrap(err, "GetHotCandidateArtwork fail")
+		return
+	}
+	for i := range artworks {
+		artworks[i].Type = "aw"
+	}
+	return
+}
+
+// GetBatchHotArtwork 批量获取热门作品展示的数据
+func GetBatchHotArtwork(artIds []string) (artData []m.HotArtworkData, err error) {
+	if len(artIds) == 0 {
+		return
+	}
+	sqlStr := `SELECT a.artwork_id,ac.likes,ac.collects,a.user_id,up.username AS user_name,a.cover,
+			up.avatar_name AS avatar,a.title,a.pic_count,IFNULL(a.first_pic,'') AS first_pic,
+			IFNULL(ap.width,0) AS width,IFNULL(ap.height,0) AS height,a.adults
+			FROM artwork a JOIN artwork_count ac ON a.artwork_id = ac.artwork_id
+			JOIN user_profile up ON a.user_id = up.user_id
+			LEFT JOIN artwork_picture ap ON a.artwork_id = ap.artwork_id and ap.sort = 0
+			WHERE a.artwork_id IN (?)`
+	query, args, err := sqlx.In(sqlStr, artIds)
+	if err != nil {
+		err = errors.Wrap(err, "GetBatchHotArtwork sqlx in fail")
+		return
+	}
+	err = db.Select(&artData, db.Rebind(query), args...)
+	if err != nil {
+		err = errors.Wrap(err, "GetBatchHotArtwork fail")
+	}
+	return
+}
@@ -0,0 +1,113 @@
+package mysql
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	m "onpaper-api-go/models"
+)
+
+// CheckInterestTarget 检查要关注的标签或话题是否存在
+func CheckInterestTarget(targetType, targetId string) (exists bool, err error) {
+	sqlStr := `SELECT COUNT(*) FROM tag WHERE tag_id = ? and merged_to = 0`
+	if targetType == "topic" {
+		sqlStr = `SELECT COUNT(*) FROM topic WHERE topic_id = ?`
+	}
+	var count int
+	err = db.Get(&count, sqlStr, targetId)
+	if err != nil {
+		err = errors.Wrap(err, "CheckInterestTarget fail")
+		return
+	}
+	exists = count != 0
+	return
+}
+
+// CountUserInterest 统计用户关注的标签或话题数量
+func CountUserInterest(userId, targetType string) (count int, err error) {
+	sqlStr := `SELECT COUNT(*) FROM user_interest WHERE user_id = ? and type = ? and is_cancel = 0`
+	err = db.Get(&count, sqlStr, userId, targetType)
+	if err != nil {
+		err = errors.Wrap(err, "CountUserInterest fail")
+	}
+	return
+}
+
+// SaveUserInterest 关注或取消关注标签 话题
+func SaveUserInterest(userId string, data m.PostInterest) (isChange bool, err error) {
+	sqlStr := `INSERT INTO user_interest (user_id,type,target_id,is_cancel) VALUES (?,?,?,?)
+			ON DUPLICATE KEY UPDATE is_cancel = VALUES(is_cancel)`
+	result, err := db.Exec(sqlStr, userId, data.Type, data.TargetId, data.IsCancel)
+	if err != nil {
+		err = errors.Wrap(err, "SaveUserInterest fail")
+		return
+	}
+	// 影响的行数 如果没有影响行数 说明请求没有实际变化
+	count, _ := result.RowsAffected()
+	isChange = count != 0
+	return
+}
+
+// GetUserInterestIds 获取用户关注的标签或话题id
+func GetUserInterestIds(userId, targetType string) (ids []string, err error) {
+	sqlStr := `SELECT target_id FROM user_interest WHERE user_id = ? and type = ? and is_cancel = 0`
+	err = db.Select(&ids, sqlStr, userId, targetType)
+	if err != nil {
+		err = errors.Wrap(err, "GetUserInterestIds fail")
+	}
+	return
+}
+
+// GetUserInterestList 获取用户关注的标签或话题 最近关注的在前
+func GetUserInterestList(userId, targetType string) (list []m.InterestItem, err error) {
+	sqlStr := `SELECT ui.target_id,t.tag_name as name,ui.createAt FROM user_interest as ui
+			left join tag as t on t.tag_id = ui.target_id
+			WHERE ui.user_id = ? and ui.type = 'tag' and ui.is_cancel = 0 and t.merged_to = 0
+			ORDER BY ui.createAt DESC`
+	if targetType == "topic" {
+		sqlStr = `SELECT ui.target_id,t.text as name,ui.createAt FROM user_interest as ui
+			left join topic as t on t.topic_id = ui.target_id
+			WHERE ui.user_id = ? and ui.type = 'topic' and ui.is_cancel = 0 and t.text is not null
+			ORDER BY ui.createAt DESC`
+	}
+	err = db.Select(&list, sqlStr, userId)
+	if err != nil {
+		err = errors.Wrap(err, "GetUserInterestList fail")
+		return
+	}
+	if list == nil {
+		list = make([]m.InterestItem, 0)
+	}
+	return
+}
+
+// GetInterestArtworkFeed 按关注的标签拉取公开作品 nextId 为 0 时从最新开始
+func GetInterestArtworkFeed(tagIds []string, userId string, nextId int64) (feeds []m.MongoFeed, err error) {
+	if len(tagIds) == 0 {
+		return
+	}
+	cursor := ""
+	args := []interface{}{tagIds, userId}
+	if nextId != 0 {
+		cursor = "and ta.artwork_id < ?"
+		args = append(args, nextId)
+	}
+	args = append(args, m.InterestFeedLimit)
+
+	// 多个关注的标签对应同一个作品时去重
+	sqlStr := `SELECT DISTINCT ta.artwork_id as msg_id,ac.user_id as send_id,'aw' as type FROM tag_artwork as ta
+			left join artwork_count as ac on ac.artwork_id = ta.artwork_id
+			WHERE ta.tag_id IN (?) and ta.is_delete = 0 and ac.user_id != ?
+			and ac.is_delete = 0 and ac.whoSee = 'public' and ac.state = 0 ` + cursor + `
+			ORDER BY ta.artwork_id DESC
+			LIMIT ?`
+	query, args, err := sqlx.In(sqlStr, args...)
+	if err != nil {
+		err = errors.Wrap(err, "GetInterestArtworkFeed sqlx in fail")
+		return
+	}
+	err = db.Select(&feeds, db.Rebind(query), args...)
+	if err != nil {
+		err = errors.Wrap(err, "GetInterestArtworkFeed fail")
+	}
+	return
+}
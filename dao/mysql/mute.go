@@ -0,0 +1,76 @@
+package mysql
+
+import (
+	"github.com/pkg/errors"
+	m "onpaper-api-go/models"
+)
+
+// GetUserMute 获取用户对关注用户的屏蔽 和屏蔽的关键词
+func GetUserMute(userId string) (mute m.UserMute, err error) {
+	sqlStr1 := `SELECT focus_id,mute FROM user_focus WHERE user_id = ? and is_cancel = 0 and mute != 'none'`
+	err = db.Select(&mute.Users, sqlStr1, userId)
+	if err != nil {
+		err = errors.Wrap(err, "GetUserMute sql1 fail")
+		return
+	}
+
+	sqlStr2 := `SELECT word FROM user_mute_word WHERE user_id = ? ORDER BY createAt`
+	err = db.Select(&mute.Words, sqlStr2, userId)
+	if err != nil {
+		err = errors.Wrap(err, "GetUserMute sql2 fail")
+		return
+	}
+
+	if mute.Users == nil {
+		mute.Users = make([]m.FocusMute, 0)
+	}
+	if mute.Words == nil {
+		mute.Words = make([]string, 0)
+	}
+	return
+}
+
+// UpdateFocusMute 设置关注用户的屏蔽 没有关注时 isFocus 为 false
+func UpdateFocusMute(userId string, data m.PostFocusMute) (isFocus bool, err error) {
+	sqlStr1 := `SELECT COUNT(*) FROM user_focus WHERE user_id = ? and focus_id = ? and is_cancel = 0`
+	var count int
+	err = db.Get(&count, sqlStr1, userId, data.FocusId)
+	if err != nil {
+		err = errors.Wrap(err, "UpdateFocusMute sql1 fail")
+		return
+	}
+	if count == 0 {
+		return
+	}
+	isFocus = true
+
+	sqlStr2 := `UPDATE user_focus SET mute = ? WHERE user_id = ? and focus_id = ?`
+	_, err = db.Exec(sqlStr2, data.Mute, userId, data.FocusId)
+	if err != nil {
+		err = errors.Wrap(err, "UpdateFocusMute sql2 fail")
+	}
+	return
+}
+
+// CountMuteWord 统计用户屏蔽的关键词数量
+func CountMuteWord(userId string) (count int, err error) {
+	sqlStr := `SELECT COUNT(*) FROM user_mute_word WHERE user_id = ?`
+	err = db.Get(&count, sqlStr, userId)
+	if err != nil {
+		err = errors.Wrap(err, "CountMuteWord fail")
+	}
+	return
+}
+
+// SaveMuteWord 添加或删除屏蔽关键词
+func SaveMuteWord(userId string, data m.PostMuteWord) (err error) {
+	sqlStr := `INSERT IGNORE INTO user_mute_word (user_id,word) VALUES (?,?)`
+	if data.IsCancel {
+		sqlStr = `DELETE FROM user_mute_word WHERE user_id = ? and word = ?`
+	}
+	_, err = db.Exec(sqlStr, userId, data.Word)
+	if err != nil {
+		err = errors.Wrap(err, "SaveMuteWord fail")
+	}
+	return
+}
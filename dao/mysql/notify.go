@@ -20,6 +20,8 @@ func SetNotifyUnread(userId, uType string) (err error) {
 		sqlStr = "UPDATE notify_unread_count SET comment = comment + 1 WHERE user_id = ?;"
 	case "commission":
 		sqlStr = "UPDATE notify_unread_count SET commission = commission + 1 WHERE user_id = ?;"
+	case "forward":
+		sqlStr = "UPDATE notify_unread_count SET forward = forward + 1 WHERE user_id = ?;"
 	}
 
 	_, err = db.Exec(sqlStr, userId)
@@ -31,7 +33,7 @@ func SetNotifyUnread(userId, uType string) (err error) {
 
 // GetNotifyUnreadCount 获取通知未读数
 func GetNotifyUnreadCount(userId string) (count m.NotifyUnreadCount, err error) {
-	sqlStr := "select `like`,follow,comment,at,collect,commission from notify_unread_count WHERE user_id = ?;"
+	sqlStr := "select `like`,follow,comment,at,collect,commission,forward from notify_unread_count WHERE user_id = ?;"
 	err = db.Get(&count, sqlStr, userId)
 	if err != nil {
 		if errors.Cause(err) == sql.ErrNoRows {
@@ -54,6 +56,8 @@ func AckNotifyUnread(userId, uType string) (err error) {
 		sqlStr = "UPDATE notify_unread_count SET comment = 0 WHERE user_id = ?;"
 	case "commission":
 		sqlStr = "UPDATE notify_unread_count SET commission = 0 WHERE user_id = ?;"
+	case "forward":
+		sqlStr = "UPDATE notify_unread_count SET forward = 0 WHERE user_id = ?;"
 	}
 
 	_, err = db.Exec(sqlStr, userId)
@@ -65,7 +69,7 @@ func AckNotifyUnread(userId, uType string) (err error) {
 
 // GetNotifySetting 获取通知设置
 func GetNotifySetting(userId string) (config m.NotifyConfig, err error) {
-	sqlStr := "select collect,`like`,follow,at,comment,message from notify_config where user_id = ?"
+	sqlStr := "select collect,`like`,follow,at,comment,message,forward from notify_config where user_id = ?"
 
 	err = db.Get(&config, sqlStr, userId)
 	if err != nil {
@@ -76,8 +80,8 @@ func GetNotifySetting(userId string) (config m.NotifyConfig, err error) {
 
 // SettingNotifySetting 更新通知设置
 func SettingNotifySetting(userId string, config m.NotifyConfig) (err error) {
-	sqlStr := "UPDATE notify_config SET `like` = ?,comment=?,collect=?,message=?,follow=?,at=? WHERE user_id = ?;"
-	_, err = db.Exec(sqlStr, config.Like, config.Comment, config.Collect, config.Message, config.Follow, config.At, userId)
+	sqlStr := "UPDATE notify_config SET `like` = ?,comment=?,collect=?,message=?,follow=?,at=?,forward=? WHERE user_id = ?;"
+	_, err = db.Exec(sqlStr, config.Like, config.Comment, config.Collect, config.Message, config.Follow, config.At, config.Forward, userId)
 	if err != nil {
 		err = errors.Wrap(err, "SettingNotifySetting  fail")
 	}
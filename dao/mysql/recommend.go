@@ -0,0 +1,48 @@
+package mysql
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	m "onpaper-api-go/models"
+)
+
+// GetAllFocusEdge 获取所有有效的关注关系
+func GetAllFocusEdge() (edges []m.FocusEdge, err error) {
+	sqlStr := `SELECT user_id,focus_id FROM user_focus WHERE is_cancel = 0`
+	err = db.Select(&edges, sqlStr)
+	if err != nil {
+		err = errors.Wrap(err, "GetAllFocusEdge fail")
+	}
+	return
+}
+
+// FilterPublicArtwork 过滤出公开可展示的作品 并按传入的顺序返回
+func FilterPublicArtwork(artIds []string) (dataList []m.ArtIdAndUid, err error) {
+	if len(artIds) == 0 {
+		return
+	}
+	sqlStr := `SELECT artwork_id,user_id FROM artwork_count
+			WHERE artwork_id IN (?) and is_delete = 0 and whoSee = 'public' and state = 0`
+	query, args, err := sqlx.In(sqlStr, artIds)
+	if err != nil {
+		err = errors.Wrap(err, "FilterPublicArtwork sqlx in fail")
+		return
+	}
+	var list []m.ArtIdAndUid
+	err = db.Select(&list, db.Rebind(query), args...)
+	if err != nil {
+		err = errors.Wrap(err, "FilterPublicArtwork fail")
+		return
+	}
+
+	artMap := make(map[string]m.ArtIdAndUid, len(list))
+	for _, art := range list {
+		artMap[art.ArtworkId] = art
+	}
+	for _, id := range artIds {
+		if art, ok := artMap[id]; ok {
+			dataList = append(dataList, art)
+		}
+	}
+	return
+}
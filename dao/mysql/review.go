@@ -0,0 +1,110 @@
+package mysql
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	m "onpaper-api-go/models"
+)
+
+// GetArtworkStates 查询未删除作品的状态
+func GetArtworkStates(artworkIds []string) (states []m.ArtworkState, err error) {
+	if len(artworkIds) == 0 {
+		return
+	}
+	query, args, err := sqlx.In("SELECT artwork_id,state FROM artwork WHERE artwork_id IN (?) and is_delete = 0", artworkIds)
+	if err != nil {
+		err = errors.Wrap(err, "GetArtworkStates sqlx in fail")
+		return
+	}
+	query = db.Rebind(query)
+	err = db.Select(&states, query, args...)
+	if err != nil {
+		err = errors.Wrap(err, "GetArtworkStates select fail")
+	}
+	return
+}
+
+// SetArtworkState 修改作品状态 作品表和统计表一起修改
+// 作品第一次变为正常状态时记录公开时间 first 为 true
+func SetArtworkState(artworkId int64, state uint8) (first bool, err error) {
+	// 开启一个事务
+	tx, err := db.Begin()
+	if err != nil {
+		err = errors.Wrap(err, "transaction begin failed")
+		return
+	}
+	// 函数关闭时 如果出错 则回滚，没出错则 提交
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+		} else if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+			return
+		}
+	}()
+
+	sqlStr1 := `UPDATE artwork SET state = ? WHERE artwork_id = ?`
+	_, err = tx.Exec(sqlStr1, state, artworkId)
+	if err != nil {
+		err = errors.Wrap(err, "SetArtworkState sqlStr1 fail")
+		return
+	}
+
+	sqlStr2 := `UPDATE artwork_count SET state = ? WHERE artwork_id = ?`
+	_, err = tx.Exec(sqlStr2, state, artworkId)
+	if err != nil {
+		err = errors.Wrap(err, "SetArtworkState sqlStr2 fail")
+		return
+	}
+
+	if state != m.ArtStateNormal {
+		return
+	}
+	sqlStr3 := `UPDATE artwork SET publish_at = NOW() WHERE artwork_id = ? and publish_at IS NULL`
+	res, err := tx.Exec(sqlStr3, artworkId)
+	if err != nil {
+		err = errors.Wrap(err, "SetArtworkState sqlStr3 fail")
+		return
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		err = errors.Wrap(err, "SetArtworkState sqlStr3 rows fail")
+		return
+	}
+	first = rows == 1
+	return
+}
+
+// BackfillArtworkPublish 补全已经公开过的作品的公开时间
+// 正常状态的作品 和修改过图片的作品 按发布时间补全
+func BackfillArtworkPublish(revisedIds []string) (count int64, err error) {
+	sqlStr := `UPDATE artwork SET publish_at = createAT WHERE publish_at IS NULL and state = ?`
+	args := []interface{}{m.ArtStateNormal}
+	if len(revisedIds) != 0 {
+		sqlStr, args, err = sqlx.In(`UPDATE artwork SET publish_at = createAT
+				WHERE publish_at IS NULL and (state = ? or artwork_id IN (?))`, m.ArtStateNormal, revisedIds)
+		if err != nil {
+			err = errors.Wrap(err, "BackfillArtworkPublish sqlx in fail")
+			return
+		}
+	}
+	res, err := db.Exec(db.Rebind(sqlStr), args...)
+	if err != nil {
+		err = errors.Wrap(err, "BackfillArtworkPublish fail")
+		return
+	}
+	count, err = res.RowsAffected()
+	return
+}
+
+// GetArtworkFileNames 获取作品的图片文件名
+func GetArtworkFileNames(artworkId int64) (fileNames []string, err error) {
+	sqlStr := `SELECT filename FROM artwork_picture WHERE artwork_id = ? ORDER BY sort`
+	err = db.Select(&fileNames, sqlStr, artworkId)
+	if err != nil {
+		err = errors.Wrap(err, "GetArtworkFileNames fail")
+	}
+	return
+}
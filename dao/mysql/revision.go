@@ -0,0 +1,144 @@
+package mysql
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+	m "onpaper-api-go/models"
+	"strings"
+)
+
+// GetArtworkSnapshot 查询作品当前版本的内容 用于保存修改记录
+func GetArtworkSnapshot(artworkId string) (snapshot m.ArtworkSnapshot, err error) {
+	var eg errgroup.Group
+
+	eg.Go(func() error {
+		sqlStr1 := `SELECT title,zone,whoSee,adults,comment,copyright,allow_reference,allow_repost,allow_ai_train,
+       				cover,IFNULL(first_pic,'') as first_pic
+					from artwork WHERE artwork_id = ? and is_delete = 0 LIMIT 1`
+		var info struct {
+			Title   string `db:"title"`
+			Zone    string `db:"zone"`
+			WhoSee  string `db:"whoSee"`
+			Adults  bool   `db:"adults"`
+			Comment string `db:"comment"`
+			m.ArtLicense
+			Cover    string `db:"cover"`
+			FirstPic string `db:"first_pic"`
+		}
+		mErr := db.Get(&info, sqlStr1, artworkId)
+		if mErr != nil {
+			return errors.Wrap(mErr, "GetArtworkSnapshot: sql1 get fail")
+		}
+		snapshot.Title = info.Title
+		snapshot.Zone = info.Zone
+		snapshot.WhoSee = info.WhoSee
+		snapshot.Adults = info.Adults
+		snapshot.Comment = info.Comment
+		snapshot.License = info.ArtLicense
+		snapshot.Cover = info.Cover
+		snapshot.FirstPic = info.FirstPic
+		return nil
+	})
+
+	eg.Go(func() error {
+		sqlStr2 := `SELECT description from art_intro WHERE artwork_id = ? limit 1`
+		mErr := db.Get(&snapshot.Intro, sqlStr2, artworkId)
+		if mErr != nil {
+			mErr = errors.Wrap(mErr, "GetArtworkSnapshot: sql2 get fail")
+		}
+		return mErr
+	})
+
+	eg.Go(func() error {
+		sqlStr3 := `SELECT filename,sort,size,width,height from artwork_picture WHERE artwork_id = ? ORDER BY sort`
+		mErr := db.Select(&snapshot.Pictures, sqlStr3, artworkId)
+		if mErr != nil {
+			mErr = errors.Wrap(mErr, "GetArtworkSnapshot: sql3 get fail")
+		}
+		return mErr
+	})
+
+	eg.Go(func() error {
+		sqlStr4 := `SELECT tag_name FROM tag_artwork WHERE artwork_id = ? and is_delete = 0`
+		mErr := db.Select(&snapshot.Tags, sqlStr4, artworkId)
+		if mErr != nil {
+			mErr = errors.Wrap(mErr, "GetArtworkSnapshot: sql4 get fail")
+		}
+		return mErr
+	})
+
+	err = eg.Wait()
+	return
+}
+
+// ReplaceArtworkPictures 替换作品的图片 作品id不变 点赞评论等数据保留
+func ReplaceArtworkPictures(info m.ReplaceArtPicture, fileList []*m.PicsType, firstPic string) (err error) {
+	// 开启一个事务
+	tx, err := db.Begin()
+	if err != nil {
+		err = errors.Wrap(err, "transaction begin failed")
+		return
+	}
+	// 函数关闭时 如果出错 则回滚，没出错则 提交
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+		} else if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+			return
+		}
+	}()
+
+	sqlStr1 := `DELETE FROM artwork_picture WHERE artwork_id = ?`
+	_, err = tx.Exec(sqlStr1, info.ArtworkId)
+	if err != nil {
+		err = errors.Wrap(err, "ReplaceArtworkPictures sqlStr1 delete fail")
+		return
+	}
+
+	fileStrings := make([]string, 0, len(fileList))
+	fileArgs := make([]interface{}, 0, len(fileList)*7)
+	for _, file := range fileList {
+		fileStrings = append(fileStrings, "(?,?,?,?,?,?,?)")
+		fileArgs = append(fileArgs, info.ArtworkId, file.FileName, file.Mimetype, file.Size, file.Sort, file.Width, file.Height)
+	}
+	sqlStr2 := fmt.Sprintf("INSERT INTO artwork_picture (artwork_id,filename,mimetype,size,sort,width,height) VALUES %s",
+		strings.Join(fileStrings, ","))
+	_, err = tx.Exec(sqlStr2, fileArgs...)
+	if err != nil {
+		err = errors.Wrap(err, "ReplaceArtworkPictures sqlStr2 into fail")
+		return
+	}
+
+	// 删除不再使用的图片主色 保存新图片的主色
+	names := make([]string, 0, len(fileList))
+	for _, file := range fileList {
+		names = append(names, file.FileName)
+	}
+	query, args, err := sqlx.In("DELETE FROM artwork_color WHERE artwork_id = ? and filename NOT IN (?)", info.ArtworkId, names)
+	if err != nil {
+		err = errors.Wrap(err, "ReplaceArtworkPictures sqlx in fail")
+		return
+	}
+	_, err = tx.Exec(db.Rebind(query), args...)
+	if err != nil {
+		err = errors.Wrap(err, "ReplaceArtworkPictures delete color fail")
+		return
+	}
+	err = insertPictureColors(tx, info.ArtworkId, fileList)
+	if err != nil {
+		return
+	}
+
+	sqlStr3 := `UPDATE artwork SET pic_count = ?,cover = ?,first_pic = ?,edit_at = IF(?, NOW(), edit_at)
+				WHERE artwork_id = ?`
+	_, err = tx.Exec(sqlStr3, len(fileList), info.Cover, firstPic, info.ShowUpdate, info.ArtworkId)
+	if err != nil {
+		err = errors.Wrap(err, "ReplaceArtworkPictures sqlStr3 update fail")
+	}
+	return
+}
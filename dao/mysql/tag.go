@@ -7,24 +7,36 @@ import (
 )
 
 // GetTagArtworkId 获取tag 对应的作品id
-func GetTagArtworkId(tagId string, sort string, page uint16) (data []m.ArtIdAndUid, err error) {
+func GetTagArtworkId(tagId string, sort string, page uint16, filter m.LicenseFilter, color m.ColorFilter) (data []m.ArtIdAndUid, err error) {
 	var sqlStr1 string
 
+	// 按授权和颜色筛选时需要关联作品表
+	licenseStr, args := licenseFilterSql(filter)
+	colorStr, colorArgs := colorFilterSql(color)
+	licenseStr += colorStr
+	args = append(args, colorArgs...)
+	joinStr := ""
+	if licenseStr != "" {
+		joinStr = "left join artwork as a on a.artwork_id = ta.artwork_id"
+	}
+
 	if sort == "score" {
 		sqlStr1 = `SELECT ac.artwork_id,ac.user_id FROM tag_artwork  as ta
-				left join artwork_count as ac on ac.artwork_id = ta.artwork_id
-				WHERE tag_id = ? and ac.is_delete = 0 and ac.whoSee = 'public' and ac.state = 0
+				left join artwork_count as ac on ac.artwork_id = ta.artwork_id ` + joinStr + `
+				WHERE tag_id = ? and ac.is_delete = 0 and ac.whoSee = 'public' and ac.state = 0 ` + licenseStr + `
 				ORDER BY ac.score DESC
 				LIMIT ?,36`
 	} else {
 		sqlStr1 = `SELECT ac.artwork_id,ac.user_id FROM tag_artwork  as ta
-				left join artwork_count as ac on ac.artwork_id = ta.artwork_id
-				WHERE tag_id = ?  and ac.is_delete = 0  and ac.whoSee = 'public' and ac.state = 0
+				left join artwork_count as ac on ac.artwork_id = ta.artwork_id ` + joinStr + `
+				WHERE tag_id = ?  and ac.is_delete = 0  and ac.whoSee = 'public' and ac.state = 0 ` + licenseStr + `
 				ORDER BY ta.createAt DESC
 				LIMIT ?,36`
 	}
 
-	err = db.Select(&data, sqlStr1, tagId, page*36)
+	args = append([]interface{}{tagId}, args...)
+	args = append(args, page*36)
+	err = db.Select(&data, sqlStr1, args...)
 	if err != nil {
 		err = errors.Wrap(err, fmt.Sprintf("GetTagArtworkId fail sort: %s ", sort))
 	}
@@ -84,7 +96,7 @@ func GetRelevantUser(tagId string) (userData []m.UserBigCard, err error) {
 
 // GetTagArtCount 获取tag 作品统计数
 func GetTagArtCount(tagId string) (res m.TagRelevant, err error) {
-	sqlStr1 := `select tag_name,art_count from tag where tag_id = ?`
+	sqlStr1 := `select tag_name,art_count,merged_to from tag where tag_id = ?`
 
 	err = db.Get(&res, sqlStr1, tagId)
 	if err != nil {
@@ -111,7 +123,7 @@ func GetTagHotRank() (tagData []m.HotTagRank, err error) {
 
 // GetTopUseTag 获取最多使用的tag
 func GetTopUseTag() (tagData []m.SearchTagResult, err error) {
-	sqlStr1 := `SELECT tag_id,tag_name,art_count from tag order by art_count desc  limit 20`
+	sqlStr1 := `SELECT tag_id,tag_name,art_count from tag where merged_to = 0 order by art_count desc  limit 20`
 	// 查询 数据库
 	err = db.Select(&tagData, sqlStr1)
 	if err != nil {
@@ -122,7 +134,7 @@ func GetTopUseTag() (tagData []m.SearchTagResult, err error) {
 
 // SearchTagName 按tagName 查找 like%
 func SearchTagName(searchText string) (likeData, searchData []m.SearchTagResult, err error) {
-	sqlStr1 := `SELECT tag_id,tag_name,art_count from tag where tag_name like ? and tag_name != ?
+	sqlStr1 := `SELECT tag_id,tag_name,art_count from tag where tag_name like ? and tag_name != ? and merged_to = 0
                 order by art_count desc 
                 limit 10 `
 	// 查询 数据库
@@ -131,7 +143,7 @@ func SearchTagName(searchText string) (likeData, searchData []m.SearchTagResult,
 		err = errors.Wrap(err, "GetTagHotRank: sql1 get fail")
 	}
 
-	sqlStr2 := `SELECT tag_id,tag_name,art_count from tag where tag_name =? limit 1`
+	sqlStr2 := `SELECT tag_id,tag_name,art_count from tag where tag_name =? and merged_to = 0 limit 1`
 	err = db.Select(&searchData, sqlStr2, searchText)
 	if err != nil {
 		err = errors.Wrap(err, "GetTagHotRank: sql2 get fail")
@@ -0,0 +1,380 @@
+package mysql
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	m "onpaper-api-go/models"
+	"onpaper-api-go/utils/formatTools"
+)
+
+// ErrTagCycle 设置父标签会形成环
+var ErrTagCycle = errors.New("tag parent cycle")
+
+// ErrTagMergeSelf 合并的两个标签是同一个
+var ErrTagMergeSelf = errors.New("tag merge self")
+
+// tagMaxDepth 父标签链的最大深度
+const tagMaxDepth = 16
+
+// CanonicalTags 把上传的标签换成规范标签名 依次查找别名和写法相同的已有标签 找不到的保持原样
+func CanonicalTags(tags []string) (res []string, err error) {
+	if len(tags) == 0 {
+		return tags, nil
+	}
+	keys := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		keys = append(keys, formatTools.TagKey(formatTools.CleanTagName(tag)))
+	}
+
+	type keyName struct {
+		Key  string `db:"tag_key"`
+		Name string `db:"tag_name"`
+	}
+	canonical := make(map[string]string, len(keys))
+
+	// 1. 先查找已有的同写法标签 作品数多的优先
+	var tagRows []keyName
+	query, args, err := sqlx.In(`SELECT tag_key,tag_name FROM tag WHERE tag_key IN (?) and merged_to = 0
+			ORDER BY art_count`, keys)
+	if err != nil {
+		err = errors.Wrap(err, "CanonicalTags sqlx in fail")
+		return
+	}
+	err = db.Select(&tagRows, db.Rebind(query), args...)
+	if err != nil {
+		err = errors.Wrap(err, "CanonicalTags tag select fail")
+		return
+	}
+	for _, row := range tagRows {
+		canonical[row.Key] = row.Name
+	}
+
+	// 2. 别名优先于同写法的标签
+	var aliasRows []keyName
+	query, args, err = sqlx.In(`SELECT ta.alias_key as tag_key,t.tag_name FROM tag_alias as ta
+			left join tag as t on t.tag_id = ta.tag_id
+			WHERE ta.alias_key IN (?) and t.tag_name is not null`, keys)
+	if err != nil {
+		err = errors.Wrap(err, "CanonicalTags sqlx in fail")
+		return
+	}
+	err = db.Select(&aliasRows, db.Rebind(query), args...)
+	if err != nil {
+		err = errors.Wrap(err, "CanonicalTags alias select fail")
+		return
+	}
+	for _, row := range aliasRows {
+		canonical[row.Key] = row.Name
+	}
+
+	res = make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for i, tag := range tags {
+		name, ok := canonical[keys[i]]
+		if !ok {
+			name = formatTools.CleanTagName(tag)
+		}
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		res = append(res, name)
+	}
+	return
+}
+
+// GetTagGraph 查询标签的父标签 子标签和别名
+func GetTagGraph(tagId string) (parent *m.ArtworkTag, children []m.ArtworkTag, aliases []string, err error) {
+	var parents []m.ArtworkTag
+	sqlStr1 := `SELECT p.tag_name,p.tag_id FROM tag as t
+			left join tag as p on p.tag_id = t.parent_id
+			WHERE t.tag_id = ? and p.tag_id is not null`
+	err = db.Select(&parents, sqlStr1, tagId)
+	if err != nil {
+		err = errors.Wrap(err, "GetTagGraph sqlStr1 fail")
+		return
+	}
+	if len(parents) != 0 {
+		parent = &parents[0]
+	}
+
+	sqlStr2 := `SELECT tag_name,tag_id FROM tag WHERE parent_id = ? and merged_to = 0
+			ORDER BY art_count DESC LIMIT 30`
+	err = db.Select(&children, sqlStr2, tagId)
+	if err != nil {
+		err = errors.Wrap(err, "GetTagGraph sqlStr2 fail")
+		return
+	}
+	if children == nil {
+		children = make([]m.ArtworkTag, 0)
+	}
+
+	sqlStr3 := `SELECT alias_name FROM tag_alias WHERE tag_id = ? ORDER BY createAt`
+	err = db.Select(&aliases, sqlStr3, tagId)
+	if err != nil {
+		err = errors.Wrap(err, "GetTagGraph sqlStr3 fail")
+		return
+	}
+	if aliases == nil {
+		aliases = make([]string, 0)
+	}
+	return
+}
+
+// getActiveTag 查询没有被合并的标签
+func getActiveTag(q sqlx.Queryer, tagId string) (tag m.ArtworkTag, err error) {
+	sqlStr := `SELECT tag_name,tag_id FROM tag WHERE tag_id = ? and merged_to = 0`
+	err = sqlx.Get(q, &tag, sqlStr, tagId)
+	if err != nil {
+		err = errors.Wrap(err, fmt.Sprintf("getActiveTag fail: %s", tagId))
+	}
+	return
+}
+
+// SaveTagAlias 给标签添加别名 已存在的别名会指向新的标签
+func SaveTagAlias(tagId, alias string) (err error) {
+	_, err = getActiveTag(db, tagId)
+	if err != nil {
+		return
+	}
+	alias = formatTools.CleanTagName(alias)
+	sqlStr := `INSERT INTO tag_alias (alias_key,alias_name,tag_id) VALUES (?,?,?)
+			ON DUPLICATE KEY UPDATE alias_name = VALUES(alias_name),tag_id = VALUES(tag_id)`
+	_, err = db.Exec(sqlStr, formatTools.TagKey(alias), alias, tagId)
+	if err != nil {
+		err = errors.Wrap(err, "SaveTagAlias fail")
+	}
+	return
+}
+
+// DeleteTagAlias 删除标签别名
+func DeleteTagAlias(alias string) (err error) {
+	sqlStr := `DELETE FROM tag_alias WHERE alias_key = ?`
+	_, err = db.Exec(sqlStr, formatTools.TagKey(formatTools.CleanTagName(alias)))
+	if err != nil {
+		err = errors.Wrap(err, "DeleteTagAlias fail")
+	}
+	return
+}
+
+// checkTagCycle 检查把 parentId 作为 tagId 的父标签是否会形成环
+// 沿着父标签往上找 不能出现自己 也不能超过最大深度
+func checkTagCycle(q sqlx.Queryer, tagId, parentId string) (err error) {
+	current := parentId
+	for i := 0; i < tagMaxDepth && current != "0"; i++ {
+		if current == tagId {
+			return ErrTagCycle
+		}
+		err = sqlx.Get(q, &current, `SELECT parent_id FROM tag WHERE tag_id = ?`, current)
+		if err != nil {
+			err = errors.Wrap(err, "checkTagCycle get parent fail")
+			return
+		}
+	}
+	if current != "0" {
+		return ErrTagCycle
+	}
+	return
+}
+
+// SetTagParent 设置父标签 parentId 为 0 时取消父标签
+func SetTagParent(tagId, parentId string) (err error) {
+	_, err = getActiveTag(db, tagId)
+	if err != nil {
+		return
+	}
+	if parentId != "0" {
+		_, err = getActiveTag(db, parentId)
+		if err != nil {
+			return
+		}
+		err = checkTagCycle(db, tagId, parentId)
+		if err != nil {
+			return
+		}
+	}
+
+	_, err = db.Exec(`UPDATE tag SET parent_id = ? WHERE tag_id = ?`, parentId, tagId)
+	if err != nil {
+		err = errors.Wrap(err, "SetTagParent update fail")
+	}
+	return
+}
+
+// MergeTag 把 fromId 标签合并到 toId
+// 作品关系改到新标签 旧标签名变成别名 子标签和别名一起转移 旧标签只保留跳转记录
+func MergeTag(fromId, toId string) (res m.TagMergeResult, err error) {
+	// 开启一个事务
+	tx, err := db.Beginx()
+	if err != nil {
+		err = errors.Wrap(err, "transaction begin failed")
+		return
+	}
+	// 函数关闭时 如果出错 则回滚，没出错则 提交
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+		} else if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+			return
+		}
+	}()
+
+	res.From, err = getActiveTag(tx, fromId)
+	if err != nil {
+		return
+	}
+	res.To, err = getActiveTag(tx, toId)
+	if err != nil {
+		return
+	}
+	// 上传的 id 可能写法不同 "01" 和 "1" 是同一个标签 后面统一使用查到的 id
+	if res.From.TagId == res.To.TagId {
+		err = ErrTagMergeSelf
+		return
+	}
+	fromId, toId = res.From.TagId, res.To.TagId
+
+	// 旧标签的子标签会转到新标签下 新标签是旧标签的后代时会形成环
+	// 新标签的父标签是旧标签时 下面会改为旧标签的父标签 从那里开始检查
+	var fromParent, toParent string
+	err = tx.Get(&fromParent, `SELECT parent_id FROM tag WHERE tag_id = ?`, fromId)
+	if err != nil {
+		err = errors.Wrap(err, "MergeTag get parent fail")
+		return
+	}
+	err = tx.Get(&toParent, `SELECT parent_id FROM tag WHERE tag_id = ?`, toId)
+	if err != nil {
+		err = errors.Wrap(err, "MergeTag get parent fail")
+		return
+	}
+	if toParent == fromId {
+		toParent = fromParent
+	}
+	err = checkTagCycle(tx, fromId, toParent)
+	if err != nil {
+		return
+	}
+
+	// 1. 记录受影响的作品
+	sqlStr1 := `SELECT artwork_id FROM tag_artwork WHERE tag_id = ? FOR UPDATE`
+	err = tx.Select(&res.ArtworkIds, sqlStr1, fromId)
+	if err != nil {
+		err = errors.Wrap(err, "MergeTag sqlStr1 fail")
+		return
+	}
+	res.Moved = len(res.ArtworkIds)
+
+	// 2. 作品同时有两个标签时 保留新标签的关系 只要有一个没删除就算没删除
+	sqlStr2 := `UPDATE tag_artwork as t JOIN tag_artwork as f
+			ON f.artwork_id = t.artwork_id and f.tag_id = ?
+			SET t.is_delete = LEAST(t.is_delete, f.is_delete)
+			WHERE t.tag_id = ?`
+	_, err = tx.Exec(sqlStr2, fromId, toId)
+	if err != nil {
+		err = errors.Wrap(err, "MergeTag sqlStr2 fail")
+		return
+	}
+	sqlStr3 := `DELETE f FROM tag_artwork as f JOIN tag_artwork as t
+			ON t.artwork_id = f.artwork_id and t.tag_id = ?
+			WHERE f.tag_id = ?`
+	_, err = tx.Exec(sqlStr3, toId, fromId)
+	if err != nil {
+		err = errors.Wrap(err, "MergeTag sqlStr3 fail")
+		return
+	}
+
+	// 3. 其余的关系改成新标签
+	sqlStr4 := `UPDATE tag_artwork SET tag_id = ?, tag_name = ? WHERE tag_id = ?`
+	_, err = tx.Exec(sqlStr4, toId, res.To.TagName, fromId)
+	if err != nil {
+		err = errors.Wrap(err, "MergeTag sqlStr4 fail")
+		return
+	}
+
+	// 4. 旧标签的别名和旧标签名 都指向新标签
+	sqlStr5 := `UPDATE tag_alias SET tag_id = ? WHERE tag_id = ?`
+	_, err = tx.Exec(sqlStr5, toId, fromId)
+	if err != nil {
+		err = errors.Wrap(err, "MergeTag sqlStr5 fail")
+		return
+	}
+	fromKey := formatTools.TagKey(res.From.TagName)
+	if fromKey != formatTools.TagKey(res.To.TagName) {
+		sqlStr6 := `INSERT INTO tag_alias (alias_key,alias_name,tag_id) VALUES (?,?,?)
+			ON DUPLICATE KEY UPDATE tag_id = VALUES(tag_id)`
+		_, err = tx.Exec(sqlStr6, fromKey, res.From.TagName, toId)
+		if err != nil {
+			err = errors.Wrap(err, "MergeTag sqlStr6 fail")
+			return
+		}
+	}
+
+	// 5. 子标签转移 新标签原来的父标签是旧标签时 改为旧标签的父标签
+	sqlStr7 := `UPDATE tag SET parent_id = ? WHERE tag_id = ? and parent_id = ?`
+	_, err = tx.Exec(sqlStr7, fromParent, toId, fromId)
+	if err != nil {
+		err = errors.Wrap(err, "MergeTag sqlStr7 fail")
+		return
+	}
+	sqlStr8 := `UPDATE tag SET parent_id = ? WHERE parent_id = ? and tag_id != ?`
+	_, err = tx.Exec(sqlStr8, toId, fromId, toId)
+	if err != nil {
+		err = errors.Wrap(err, "MergeTag sqlStr8 fail")
+		return
+	}
+
+	// 6. 重新统计新标签的作品数 旧标签标记为已合并
+	sqlStr9 := `UPDATE tag SET art_count = (SELECT COUNT(*) FROM tag_artwork WHERE tag_id = ? and is_delete = 0)
+			WHERE tag_id = ?`
+	_, err = tx.Exec(sqlStr9, toId, toId)
+	if err != nil {
+		err = errors.Wrap(err, "MergeTag sqlStr9 fail")
+		return
+	}
+	sqlStr10 := `UPDATE tag SET merged_to = ?, parent_id = 0, art_count = 0 WHERE tag_id = ?`
+	_, err = tx.Exec(sqlStr10, toId, fromId)
+	if err != nil {
+		err = errors.Wrap(err, "MergeTag sqlStr10 fail")
+		return
+	}
+	// 之前合并到旧标签的 也跳转到新标签
+	sqlStr11 := `UPDATE tag SET merged_to = ? WHERE merged_to = ?`
+	_, err = tx.Exec(sqlStr11, toId, fromId)
+	if err != nil {
+		err = errors.Wrap(err, "MergeTag sqlStr11 fail")
+		return
+	}
+
+	// 关注旧标签的用户 改为关注新标签
+	sqlStr14 := `INSERT INTO user_interest (user_id,type,target_id)
+			SELECT user_id,'tag',? FROM user_interest WHERE type = 'tag' and target_id = ? and is_cancel = 0
+			ON DUPLICATE KEY UPDATE is_cancel = 0`
+	_, err = tx.Exec(sqlStr14, toId, fromId)
+	if err != nil {
+		err = errors.Wrap(err, "MergeTag sqlStr14 fail")
+		return
+	}
+	_, err = tx.Exec(`UPDATE user_interest SET is_cancel = 1 WHERE type = 'tag' and target_id = ?`, fromId)
+	if err != nil {
+		err = errors.Wrap(err, "MergeTag sqlStr15 fail")
+		return
+	}
+
+	// 7. 排行榜中的旧标签 同一期已有新标签的直接删除
+	sqlStr12 := `UPDATE IGNORE rank_tag_day SET tag_id = ?, tag_name = ? WHERE tag_id = ?`
+	_, err = tx.Exec(sqlStr12, toId, res.To.TagName, fromId)
+	if err != nil {
+		err = errors.Wrap(err, "MergeTag sqlStr12 fail")
+		return
+	}
+	_, err = tx.Exec(`DELETE FROM rank_tag_day WHERE tag_id = ?`, fromId)
+	if err != nil {
+		err = errors.Wrap(err, "MergeTag sqlStr13 fail")
+		return
+	}
+	return
+}
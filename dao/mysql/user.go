@@ -318,17 +318,26 @@ func UpdateUserWorkEmail(workEmail string, userId string) (err error) {
 	return
 }
 
-// UpdateUserBirthday 保存用户的生日
+// UpdateUserBirthday 保存用户的生日 生日是自行填写的 只能填写一次 避免未成年随意改成成年
 func UpdateUserBirthday(birthday string, userId string) (err error) {
 	sqlStr := `
 		UPDATE user_profile
-		SET birthday=?
-		WHERE user_id=?;`
-	_, err = db.Exec(sqlStr, birthday, userId)
+		SET birthday=?, adult_pref=IF(TIMESTAMPDIFF(YEAR, ?, CURDATE()) >= ?, adult_pref, 'hide')
+		WHERE user_id=? AND birthday IS NULL;`
+	// 未成年的生日 敏感内容偏好重置为隐藏
+	res, err := db.Exec(sqlStr, birthday, birthday, models.AdultAge, userId)
 	if err != nil {
 		err = errors.Wrap(err, "UpdateUserBirthday sqlStr into fail")
 		return
 	}
+	row, err := res.RowsAffected()
+	if err != nil {
+		err = errors.Wrap(err, "UpdateUserBirthday get RowsAffected fail")
+		return
+	}
+	if row == 0 {
+		err = ErrorBirthdayLocked
+	}
 
 	return
 }
@@ -450,8 +459,9 @@ func SaveUserFocus(focusData models.VerifyUserFocus, userId string) (isChange bo
 		}
 	}()
 
+	// 取消关注时 同时重置屏蔽设置
 	sqlStr1 := `INSERT INTO user_focus (user_id,focus_id,is_cancel) VALUES (?,?,?)  
-  				ON DUPLICATE KEY UPDATE is_cancel=?; `
+  				ON DUPLICATE KEY UPDATE is_cancel=?, mute = IF(VALUES(is_cancel) = 1, 'none', mute); `
 	result, err := tx.Exec(sqlStr1, userId, focusData.FocusId, focusData.IsCancel, focusData.IsCancel)
 	if err != nil {
 		err = errors.Wrap(err, "SaveUserFocus: sql1 get fail")
@@ -758,3 +768,26 @@ func GetAllUserShowId(query models.AllUserShowQuery) (userData []models.BigCardU
 
 	return
 }
+
+// GetUserAdultSetting 查询用户的生日和敏感内容偏好
+func GetUserAdultSetting(userId string) (setting models.AdultSetting, err error) {
+	sqlStr := `SELECT birthday, adult_pref FROM user_profile WHERE user_id = ?`
+	err = db.Get(&setting, sqlStr, userId)
+	if err != nil {
+		err = errors.Wrap(err, "GetUserAdultSetting sqlStr get fail")
+	}
+	return
+}
+
+// UpdateUserAdultPref 保存用户的敏感内容偏好
+func UpdateUserAdultPref(pref string, userId string) (err error) {
+	sqlStr := `
+		UPDATE user_profile
+		SET adult_pref=?
+		WHERE user_id=?;`
+	_, err = db.Exec(sqlStr, pref, userId)
+	if err != nil {
+		err = errors.Wrap(err, "UpdateUserAdultPref sqlStr into fail")
+	}
+	return
+}
@@ -248,7 +248,7 @@ func BatchGetTrendArtInfo(artIds []int64) (trendArt m.TrendList, err error) {
 	artIds, _ = tools.RemoveSliceDuplicate(artIds)
 	sqlStr1 := `(SELECT artwork_id, description from art_intro WHERE artwork_id = ? limit 1 )`
 	sqlStr2 := `(SELECT artwork_id, filename,sort,width,height from artwork_picture WHERE artwork_id = ? limit 15 )`
-	sqlStr4 := `(SELECT artwork_id,user_id,comment,whoSee,is_delete,createAt from artwork WHERE artwork_id = ? limit 1 )`
+	sqlStr4 := `(SELECT artwork_id,user_id,title,comment,whoSee,is_delete,adults,createAt from artwork WHERE artwork_id = ? limit 1 )`
 
 	artCount := len(artIds)
 	// 存放 select语句的 切片
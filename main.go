@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"onpaper-api-go/app"
 	"onpaper-api-go/cache"
+	ctl "onpaper-api-go/controller"
 	"onpaper-api-go/dao/mongo"
 	"onpaper-api-go/dao/mysql"
 	"onpaper-api-go/settings"
@@ -15,6 +16,9 @@ import (
 func main() {
 	// 初始化服务
 	router := app.Init()
+	if router == nil {
+		log.Fatalf("app init fail\n")
+	}
 
 	// 启动服务
 	//定义 server 结构体
@@ -30,6 +34,17 @@ func main() {
 		}
 	}()
 
+	// 定时发布作品草稿
+	go ctl.RunDraftPublisher()
+	// 定时计算推荐作品和用户
+	go ctl.RunRecommendBuilder()
+	// 定时计算热门动态和作品
+	go ctl.RunHotScoreJob()
+	// 定时落盘动态投票统计
+	go ctl.RunPollFlusher()
+	// 计算作品图片的哈希和主色 查重后公开作品
+	go ctl.RunPictureAnalyzer()
+
 	// 平滑关机
 	quite.SmoothQuite(server)
 	// 退出时关闭数据库链接
@@ -19,7 +19,15 @@ func SetRootComment(ctx *gin.Context) {
 	dataCtx, _ = ctx.Get("rootComment")
 	rootComment := dataCtx.(m.ReturnComments)
 
-	err := c.SetRootComment(rootComment, queryData.OwnId, queryData.Type)
+	var err error
+	if queryData.Sort == "hot" {
+		// 第一页会重新生成热度排序快照
+		dataCtx, _ = ctx.Get("hotRank")
+		ranks, _ := dataCtx.([]m.CommentHotRank)
+		err = c.SetHotRootComment(rootComment, ranks, queryData.OwnId)
+	} else {
+		err = c.SetRootComment(rootComment, queryData.OwnId, queryData.Type)
+	}
 	if err != nil {
 		logger.ErrZapLog(err, queryData.OwnId)
 	}
@@ -32,6 +40,11 @@ func GetRootComment(ctx *gin.Context) {
 	var data m.VerifyGetCommentQuery
 	_ = ctx.ShouldBindQuery(&data)
 
+	if data.Sort == "hot" {
+		getHotRootComment(ctx, data)
+		return
+	}
+
 	count := 21
 	// 如果 lastCid = 0 说明是第一页
 	if data.LastCid == "0" {
@@ -88,6 +101,56 @@ func GetRootComment(ctx *gin.Context) {
 	ctx.Set("noComment", false)
 }
 
+// getHotRootComment 获取按热度排序的根评论缓存 翻页按上一页最后一条在快照中的位置
+func getHotRootComment(ctx *gin.Context, data m.VerifyGetCommentQuery) {
+	var cacheValue c.CtxCacheVale
+	var tempList m.ReturnComments
+
+	commentList, scores, isEnd, err := c.GetHotRootCommentId(data.OwnId, data.LastCid, 20)
+	if err != nil {
+		//快照或评论缓存不存在 交给数据库查询
+		if !errors.Is(err, redis.Nil) {
+			logger.ErrZapLog(err, data.OwnId)
+		}
+		ctx.Set("comment", cacheValue)
+		ctx.Set("noComment", false)
+		return
+	}
+
+	// 说明有缓存 没有评论
+	if isEnd && len(commentList) == 0 {
+		ctx.Set("comment", cacheValue)
+		ctx.Set("noComment", true)
+		return
+	}
+	// 超过了快照的范围 交给数据库查询
+	if !isEnd && len(commentList) < 20 {
+		ctx.Set("comment", cacheValue)
+		ctx.Set("noComment", false)
+		return
+	}
+
+	for i, cmder := range commentList {
+		var temp m.ReturnComment
+		val := c.GetCmderStringResult(cmder)
+		err = json.Unmarshal([]byte(val), &temp)
+		if err != nil {
+			err = errors.Wrap(err, "getHotRootComment Unmarshal fail ")
+			logger.ErrZapLog(err, val)
+			ctx.Set("comment", cacheValue)
+			ctx.Set("noComment", false)
+			return
+		}
+		// 热度排序时 sore 是热度 用于翻页
+		temp.Sore = int64(scores[i])
+		tempList = append(tempList, temp)
+	}
+	cacheValue.Val = tempList
+	cacheValue.HaveCache = true
+	ctx.Set("comment", cacheValue)
+	ctx.Set("noComment", false)
+}
+
 // AddComment 添加新的评论到缓存中
 func AddComment(ctx *gin.Context) {
 	// 1. 获取传递的数据
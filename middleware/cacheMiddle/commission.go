@@ -91,3 +91,26 @@ func DelInviteStatus(ctx *gin.Context) {
 		logger.ErrZapLog(err, inviteId)
 	}
 }
+
+// DelDisputeCache 删除纠纷相关的约稿计划缓存 裁决后删除双方资料缓存
+func DelDisputeCache(ctx *gin.Context) {
+	if ctxData, ok := ctx.Get("inviteId"); ok {
+		inviteId := ctxData.(int64)
+		err := c.DelInvitePlanStatus(inviteId)
+		if err != nil {
+			logger.ErrZapLog(err, inviteId)
+		}
+	}
+
+	ctxData, ok := ctx.Get("planUser")
+	if !ok {
+		return
+	}
+	planUser := ctxData.(m.PlanUserInfo)
+	for _, userId := range []string{planUser.Sender, planUser.ArtistId} {
+		err := c.DelCommissionStatus(userId)
+		if err != nil {
+			logger.ErrZapLog(err, fmt.Sprintf("DelDisputeCache cache fail userId:%s ", userId))
+		}
+	}
+}
@@ -84,7 +84,7 @@ func SetTagArtwork(ctx *gin.Context) {
 	queryData := ctxData.(m.TagQueryArtParam)
 
 	// 设置缓存
-	err := c.SetTagArtId(queryData.TagId, strconv.Itoa(int(queryData.Page)), queryData.Sort, dataList)
+	err := c.SetTagArtId(queryData.TagId, strconv.Itoa(int(queryData.Page)), queryData.Sort+queryData.CacheKey()+queryData.ColorKey(), dataList)
 	if err != nil {
 		logger.ErrZapLog(err, queryData.TagName)
 	}
@@ -95,7 +95,7 @@ func GetTagArtwork(ctx *gin.Context) {
 	ctxData, _ := ctx.Get("queryData")
 	queryData := ctxData.(m.TagQueryArtParam)
 
-	end := fmt.Sprintf("%d&%s", queryData.Page, queryData.Sort)
+	end := fmt.Sprintf("%d&%s%s%s", queryData.Page, queryData.Sort, queryData.CacheKey(), queryData.ColorKey())
 	key := fmt.Sprintf(c.TagArtworkAndPage, queryData.TagId, end)
 
 	var temp []m.ArtIdAndUid
@@ -319,6 +319,14 @@ func SetAboutTrendCache(ctx *gin.Context) {
 		if err != nil {
 			logger.ErrZapLog(err, trendInfo.TrendId)
 		}
+		// 转发链经过的动态 转发数也 + 1 和转发列表中包含经过它的转发一致
+		for _, id := range trendInfo.ForwardInfo.Chain {
+			chainId, _ := strconv.ParseInt(id, 10, 64)
+			err = c.SetTrendForwards(m.ForwardInfo{Id: chainId, Type: "tr"})
+			if err != nil {
+				logger.ErrZapLog(err, trendInfo.TrendId)
+			}
+		}
 	}
 
 	if trendInfo.Topic.Text != "" {
@@ -327,6 +335,18 @@ func SetAboutTrendCache(ctx *gin.Context) {
 			logger.ErrZapLog(err, trendInfo.TrendId)
 		}
 	}
+	// 正文中的话题 同一个话题只加一次
+	hotDone := map[string]bool{trendInfo.Topic.Text: true}
+	for _, tag := range trendInfo.Hashtags {
+		if hotDone[tag.Text] {
+			continue
+		}
+		hotDone[tag.Text] = true
+		err = c.SetHotTopicIncr(tag.Text)
+		if err != nil {
+			logger.ErrZapLog(err, trendInfo.TrendId)
+		}
+	}
 }
 
 // GetUserPanel 获取用户面板资料
@@ -386,6 +406,7 @@ func SetUserNotifyConfig(ctx *gin.Context) {
 		"Message": config.Message,
 		"At":      config.At,
 		"Follow":  config.Follow,
+		"Forward": config.Forward,
 	}
 	err := c.SetHashKeyValue(key, mapConfig, 3*24)
 	if err != nil {
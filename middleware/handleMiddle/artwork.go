@@ -6,6 +6,7 @@ import (
 	ctl "onpaper-api-go/controller"
 	"onpaper-api-go/dao/mysql"
 	"onpaper-api-go/models"
+	"onpaper-api-go/utils/palette"
 	"onpaper-api-go/utils/verify"
 	"strconv"
 	"unicode/utf8"
@@ -75,6 +76,16 @@ func HandleQueryArtworkShow(ctx *gin.Context) {
 		ctl.ResponseError(ctx, ctl.CodeParamsError)
 		return
 	}
+	// 筛选的授权协议
+	if data.License != "" && !verify.License(data.License) {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+	// 筛选的颜色
+	if !normalizeColorFilter(&data.ColorFilter) {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
 
 	// 把它传递到上下文
 	ctx.Set("query", data)
@@ -113,6 +124,11 @@ func HandleUpdateArtInfo(ctx *gin.Context) {
 		ctl.ResponseError(ctx, ctl.CodeParamsError)
 		return
 	}
+	//验证授权协议
+	if !verify.License(data.CopyRight) {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
 
 	isOwner, err := mysql.VerifyArtOwner(userInfo.Id, data.ArtworkId)
 	if err != nil {
@@ -125,7 +141,15 @@ func HandleUpdateArtInfo(ctx *gin.Context) {
 		return
 	}
 
+	// 修改前的版本 用于保存修改记录
+	snapshot, err := mysql.GetArtworkSnapshot(data.ArtworkId)
+	if err != nil {
+		ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, err)
+		return
+	}
+
 	ctx.Set("artInfo", data)
+	ctx.Set("artSnapshot", snapshot)
 }
 
 func HandleQueryZone(ctx *gin.Context) {
@@ -139,3 +163,24 @@ func HandleQueryZone(ctx *gin.Context) {
 
 	ctx.Set("zone", query.Zone)
 }
+
+// normalizeColorFilter 验证筛选的颜色 并对齐颜色减少缓存数量
+func normalizeColorFilter(filter *models.ColorFilter) bool {
+	if filter.Color == "" {
+		return true
+	}
+	color, ok := palette.Normalize(filter.Color)
+	filter.Color = color
+	return ok
+}
+
+// VerifyRecommendQuery 验证获取推荐作品的参数
+func VerifyRecommendQuery(ctx *gin.Context) {
+	var query models.RecommendQuery
+	err := ctx.ShouldBindQuery(&query)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+	ctx.Set("query", query)
+}
@@ -7,6 +7,7 @@ import (
 	ctl "onpaper-api-go/controller"
 	"onpaper-api-go/dao/mysql"
 	m "onpaper-api-go/models"
+	"onpaper-api-go/settings"
 	"onpaper-api-go/utils/encrypt"
 	"onpaper-api-go/utils/jwt"
 	"onpaper-api-go/utils/verify"
@@ -180,6 +181,30 @@ func VerifyAuthMust(ctx *gin.Context) {
 	ctx.Set("userInfo", userInfo)
 }
 
+// VerifyAdmin 验证登陆用户是否是管理员 需要在 VerifyAuthMust 之后使用
+func VerifyAdmin(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("userInfo")
+	loginUser := ctxData.(m.UserTokenPayload)
+
+	if !IsAdmin(loginUser.Id) {
+		ctl.ResponseError(ctx, ctl.CodeUnPermission)
+		return
+	}
+}
+
+// IsAdmin 判断用户是否是配置中的管理员
+func IsAdmin(userId string) bool {
+	if settings.Conf.Admin == nil || userId == "" {
+		return false
+	}
+	for _, id := range settings.Conf.AdminUsers {
+		if id == userId {
+			return true
+		}
+	}
+	return false
+}
+
 // VerifyAuth 通过验证token 查看是哪个用户登录，没有token 也不会拒绝请求
 func VerifyAuth(ctx *gin.Context) {
 	//初始化 payload 游客都为空
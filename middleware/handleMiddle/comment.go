@@ -6,6 +6,7 @@ import (
 	ctl "onpaper-api-go/controller"
 	m "onpaper-api-go/models"
 	"strconv"
+	"unicode/utf8"
 )
 
 // HandleCommentQuery 处理根评论查询参数
@@ -82,3 +83,46 @@ func HandleCommentDelete(ctx *gin.Context) {
 	// 把它传递到上下文
 	ctx.Set("delete", data)
 }
+
+// HandleCommentEdit 验证修改评论的参数 和发布评论的检查一致
+// 发布评论只检查长度 项目里没有内容过滤和 @提及 修改时也不做这两项
+func HandleCommentEdit(ctx *gin.Context) {
+	var data m.EditComment
+	err := ctx.ShouldBindJSON(&data)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeJsonFormatError)
+		return
+	}
+	//如果文本长度大于140 则返回错误
+	if utf8.RuneCountInString(data.Text) > 140 {
+		ctl.ResponseError(ctx, ctl.CodeJsonFormatError)
+		return
+	}
+
+	// 把它传递到上下文
+	ctx.Set("edit", data)
+}
+
+// HandleCommentHistory 验证查询评论修改历史的参数
+func HandleCommentHistory(ctx *gin.Context) {
+	var data m.QueryCommentHistory
+	err := ctx.ShouldBindQuery(&data)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+
+	ctx.Set("query", data)
+}
+
+// HandleCommentTop 验证置顶评论的参数
+func HandleCommentTop(ctx *gin.Context) {
+	var data m.PostCommentTop
+	err := ctx.ShouldBindJSON(&data)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeJsonFormatError)
+		return
+	}
+
+	ctx.Set("top", data)
+}
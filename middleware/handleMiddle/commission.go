@@ -1,19 +1,23 @@
 package handleMiddle
 
 import (
+	"database/sql"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
 	mongodb "go.mongodb.org/mongo-driver/mongo"
 	ctl "onpaper-api-go/controller"
 	"onpaper-api-go/dao/mongo"
+	"onpaper-api-go/dao/mysql"
 	m "onpaper-api-go/models"
-	"onpaper-api-go/settings"
-	"onpaper-api-go/utils/oss"
 	"onpaper-api-go/utils/verify"
-	"strconv"
+	"strings"
+	"time"
 )
 
+// EvaluateReplyEditTime 首次回复评价后可以修改回复的时间
+const EvaluateReplyEditTime = 7 * 24 * time.Hour
+
 // VerifyPostContract 验证上传的 接稿计划
 func VerifyPostContract(ctx *gin.Context) {
 	var data m.AcceptPlan
@@ -29,6 +33,20 @@ func VerifyPostContract(ctx *gin.Context) {
 		ctl.ResponseError(ctx, ctl.CodeParamsError)
 		return
 	}
+
+	// 不接稿日期 开始日期不能大于结束日期
+	for _, period := range data.Blackout {
+		okStart, _ := verify.DateRule(period.Start)
+		okEnd, _ := verify.DateRule(period.End)
+		if !okStart || !okEnd || period.Start > period.End {
+			ctl.ResponseError(ctx, ctl.CodeParamsError)
+			return
+		}
+	}
+	if data.Blackout == nil {
+		data.Blackout = make([]m.Period, 0)
+	}
+
 	// 把它传递到上下文
 	ctx.Set("contractPlan", data)
 }
@@ -62,36 +80,50 @@ func VerifyInvitePlan(ctx *gin.Context) {
 		return
 	}
 
+	// 画师满额或者在不接稿日期内 允许排队则进入排队 否则拒绝
+	plan, err := mongo.GetAcceptPlan(data.ArtistId)
+	if err != nil {
+		if err == mongodb.ErrNoDocuments {
+			ctl.ResponseError(ctx, ctl.CodeUserNoAcceptPlan)
+			return
+		}
+		err = errors.Wrap(err, "GetAcceptPlan mongodb fail")
+		ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, err)
+		return
+	}
+	queue, err := mysql.GetArtistQueue(data.ArtistId)
+	if err != nil {
+		ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, err)
+		return
+	}
+	// 保存发出邀请时的接稿条款 生成协议时使用
+	data.PlanTerms = &m.PlanTerms{Change: plan.Change, Refuse: plan.Refuse}
+	data.Waitlist = false
+	if plan.IsFull(queue) || plan.InBlackout(time.Now()) {
+		if !plan.WaitList {
+			ctl.ResponseError(ctx, ctl.CodeArtistQueueFull)
+			return
+		}
+		data.Waitlist = true
+	}
+
 	// cos验证文件是否存在
 	for i, file := range data.FileList {
 		key := "commission/" + userInfo.Id + "/" + file.FileName
-		mErr := oss.MoveTempToOriginal(key)
+		//验证图片 文件信息以图片内容为准
+		info, mErr := moveTempImage(key, ImageMaxSize)
 		if mErr != nil {
-			mErr = errors.Wrap(mErr, "MoveTempToOriginal fail")
-			ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, mErr)
-			return
-		}
-		//到cos中查询文件信息
-		fInfo, fErr := oss.SelectOssFileInfo(settings.Conf.OriginalBucket, key)
-		if fErr != nil {
-			fErr = errors.Wrap(fErr, "cos 查询错误")
-			ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, fErr)
+			responseImageError(ctx, mErr)
 			return
 		}
-		// 文件类型
-		contentType := fInfo.Get("Content-Type")
-		// 文件大小
-		contentLength := fInfo.Get("Content-Length")
-		//字符串 -> int64
-		size, _ := strconv.ParseInt(contentLength, 10, 64)
 
 		data.FileList[i] = m.PicsType{
 			FileName: file.FileName,
-			Mimetype: contentType,
-			Size:     size,
+			Mimetype: info.Mimetype,
+			Size:     info.Size,
 			Sort:     file.Sort,
-			Width:    file.Width,
-			Height:   file.Height,
+			Width:    uint16(info.Width),
+			Height:   uint16(info.Height),
 		}
 
 	}
@@ -126,6 +158,19 @@ func VerifyPlanQueryId(ctx *gin.Context) {
 	ctx.Set("inviteId", data.InviteId)
 }
 
+// VerifyAgreementQuery 验证校验约稿协议的参数
+func VerifyAgreementQuery(ctx *gin.Context) {
+	var data m.AgreementVerifyQuery
+	err := ctx.ShouldBindQuery(&data)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+	data.Hash = strings.ToLower(data.Hash)
+
+	ctx.Set("query", data)
+}
+
 // VerifyEvaluate 验证约稿评价
 func VerifyEvaluate(ctx *gin.Context) {
 	var data m.Evaluate
@@ -199,6 +244,25 @@ func VerifyPlanNext(ctx *gin.Context) {
 		return
 	}
 
+	// 开始创作前 检查画师进行中的约稿是否已满
+	if planNext.Status == 2 {
+		plan, _err := mongo.GetAcceptPlan(userInfo.ArtistId)
+		if _err != nil && _err != mongodb.ErrNoDocuments {
+			_err = errors.Wrap(_err, "GetAcceptPlan mongodb fail")
+			ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, _err)
+			return
+		}
+		queue, _err := mysql.GetArtistQueue(userInfo.ArtistId)
+		if _err != nil {
+			ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, _err)
+			return
+		}
+		if plan.IsFull(queue) {
+			ctl.ResponseError(ctx, ctl.CodeArtistQueueFull)
+			return
+		}
+	}
+
 	ctx.Set("planNext", planNext)
 	ctx.Set("planUser", userInfo)
 }
@@ -227,3 +291,152 @@ func VerifyCommissionStatus(ctx *gin.Context) {
 
 	ctx.Set("status", status.Status)
 }
+
+// VerifyEvaluateReply 验证被评价人回复评价
+func VerifyEvaluateReply(ctx *gin.Context) {
+	var data m.EvaluateReply
+	err := ctx.ShouldBindJSON(&data)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeJsonFormatError)
+		return
+	}
+	data.Text = strings.TrimSpace(data.Text)
+	if data.Text == "" {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+
+	ctxData, _ := ctx.Get("userInfo")
+	loginUser := ctxData.(m.UserTokenPayload)
+
+	info, err := mysql.GetEvaluateReplyInfo(data.EvaluateId)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			ctl.ResponseError(ctx, ctl.CodeParamsError)
+			return
+		}
+		err = errors.Wrap(err, "GetEvaluateReplyInfo mysql fail")
+		ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, err)
+		return
+	}
+
+	// 只有被评价人可以回复 已删除的评价不能回复
+	if info.Receiver != loginUser.Id || info.IsDelete {
+		ctl.ResponseError(ctx, ctl.CodeUnPermission)
+		return
+	}
+
+	// 已经回复过 超过修改时间不能再修改
+	if info.ReplyAt != nil && time.Since(*info.ReplyAt) > EvaluateReplyEditTime {
+		ctl.ResponseError(ctx, ctl.CodeEditTimeout)
+		return
+	}
+
+	ctx.Set("evaluateReply", data)
+	ctx.Set("inviteId", info.InviteId)
+}
+
+// VerifyPostQuote 验证约稿报价 只能在待接受和沟通阶段报价
+func VerifyPostQuote(ctx *gin.Context) {
+	var data m.CommissionQuote
+	err := ctx.ShouldBindJSON(&data)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeJsonFormatError)
+		return
+	}
+
+	ok, _ := verify.DateRule(data.Deadline)
+	if !ok {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+
+	ctxData, _ := ctx.Get("userInfo")
+	loginUser := ctxData.(m.UserTokenPayload)
+
+	// 查找约稿方案的两个用户
+	userInfo, err := mongo.GetPlanUserInfo(data.InviteId)
+	if err != nil {
+		if err == mongodb.ErrNoDocuments {
+			ctl.ResponseError(ctx, ctl.CodeParamsError)
+			return
+		}
+		err = errors.Wrap(err, "GetPlanUserInfo mongodb fail")
+		ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, err)
+		return
+	}
+
+	if userInfo.Sender != loginUser.Id && userInfo.ArtistId != loginUser.Id {
+		ctl.ResponseError(ctx, ctl.CodeUnPermission)
+		return
+	}
+	if userInfo.NowStatus != 0 && userInfo.NowStatus != 1 {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+
+	// 第一次报价必须由画师发起 之后约稿方可以还价
+	if loginUser.Id != userInfo.ArtistId {
+		quotes, _err := mongo.GetInviteQuotes(data.InviteId)
+		if _err != nil {
+			_err = errors.Wrap(_err, "GetInviteQuotes mongodb fail")
+			ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, _err)
+			return
+		}
+		if len(quotes) == 0 {
+			ctl.ResponseError(ctx, ctl.CodeUnPermission)
+			return
+		}
+	}
+
+	ctx.Set("quote", data)
+	ctx.Set("planUser", userInfo)
+}
+
+// VerifyReplyQuote 验证回应报价 只有报价的另一方可以接受或拒绝
+func VerifyReplyQuote(ctx *gin.Context) {
+	var data m.QuoteReply
+	err := ctx.ShouldBindJSON(&data)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeJsonFormatError)
+		return
+	}
+
+	ctxData, _ := ctx.Get("userInfo")
+	loginUser := ctxData.(m.UserTokenPayload)
+
+	quote, err := mongo.GetQuote(data.QuoteId)
+	if err != nil {
+		if err == mongodb.ErrNoDocuments {
+			ctl.ResponseError(ctx, ctl.CodeParamsError)
+			return
+		}
+		err = errors.Wrap(err, "GetQuote mongodb fail")
+		ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, err)
+		return
+	}
+	if quote.Status != 0 {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+
+	userInfo, err := mongo.GetPlanUserInfo(quote.InviteId)
+	if err != nil {
+		err = errors.Wrap(err, "GetPlanUserInfo mongodb fail")
+		ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, err)
+		return
+	}
+
+	if (userInfo.Sender != loginUser.Id && userInfo.ArtistId != loginUser.Id) || quote.Proposer == loginUser.Id {
+		ctl.ResponseError(ctx, ctl.CodeUnPermission)
+		return
+	}
+	if userInfo.NowStatus != 0 && userInfo.NowStatus != 1 {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+
+	ctx.Set("quoteReply", data)
+	ctx.Set("quote", quote)
+	ctx.Set("planUser", userInfo)
+}
@@ -0,0 +1,214 @@
+package handleMiddle
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	mongodb "go.mongodb.org/mongo-driver/mongo"
+	ctl "onpaper-api-go/controller"
+	"onpaper-api-go/dao/mongo"
+	m "onpaper-api-go/models"
+)
+
+// VerifyPostDispute 验证发起的约稿纠纷
+func VerifyPostDispute(ctx *gin.Context) {
+	var data m.DisputePost
+	err := ctx.ShouldBindJSON(&data)
+	if err != nil || data.InviteId == 0 {
+		ctl.ResponseError(ctx, ctl.CodeJsonFormatError)
+		return
+	}
+
+	ctxData, _ := ctx.Get("userInfo")
+	loginUser := ctxData.(m.UserTokenPayload)
+
+	// 查找约稿方案的两个用户
+	planUser, err := mongo.GetPlanUserInfo(data.InviteId)
+	if err != nil {
+		if err == mongodb.ErrNoDocuments {
+			ctl.ResponseError(ctx, ctl.CodeParamsError)
+			return
+		}
+		err = errors.Wrap(err, "GetPlanUserInfo mongodb fail")
+		ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, err)
+		return
+	}
+
+	// 如果 不是计划中的两个用户不能发起纠纷
+	if planUser.Sender != loginUser.Id && planUser.ArtistId != loginUser.Id {
+		ctl.ResponseError(ctx, ctl.CodeUnPermission)
+		return
+	}
+
+	// 只有进入创作阶段的约稿才能发起纠纷 2 创作中 3 已完成 -2 创作中散伙
+	if planUser.NowStatus != 2 && planUser.NowStatus != 3 && planUser.NowStatus != -2 {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+
+	// 同一个约稿同时只能有一个未裁决的纠纷
+	have, err := mongo.CheckInviteHaveDispute(data.InviteId)
+	if err != nil {
+		ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, err)
+		return
+	}
+	if have {
+		ctl.ResponseError(ctx, ctl.CodeDisputeExists)
+		return
+	}
+
+	data.FileList, err = moveDisputeFiles(loginUser.Id, data.FileList)
+	if err != nil {
+		responseImageError(ctx, err)
+		return
+	}
+
+	ctx.Set("dispute", data)
+	ctx.Set("planUser", planUser)
+}
+
+// VerifyDisputeResponse 验证被申诉人的回应
+func VerifyDisputeResponse(ctx *gin.Context) {
+	var data m.DisputePost
+	err := ctx.ShouldBindJSON(&data)
+	if err != nil || data.DisputeId == 0 {
+		ctl.ResponseError(ctx, ctl.CodeJsonFormatError)
+		return
+	}
+
+	ctxData, _ := ctx.Get("userInfo")
+	loginUser := ctxData.(m.UserTokenPayload)
+
+	dispute, err := mongo.GetDispute(data.DisputeId)
+	if err != nil {
+		if err == mongodb.ErrNoDocuments {
+			ctl.ResponseError(ctx, ctl.CodeParamsError)
+			return
+		}
+		err = errors.Wrap(err, "GetDispute mongodb fail")
+		ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, err)
+		return
+	}
+
+	// 只有被申诉人在裁决前可以回应
+	if dispute.Respondent != loginUser.Id || dispute.Status == 2 {
+		ctl.ResponseError(ctx, ctl.CodeUnPermission)
+		return
+	}
+
+	data.FileList, err = moveDisputeFiles(loginUser.Id, data.FileList)
+	if err != nil {
+		responseImageError(ctx, err)
+		return
+	}
+
+	ctx.Set("disputeResponse", data)
+	ctx.Set("dispute", dispute)
+}
+
+// VerifyDisputeDecision 验证管理员的裁决
+func VerifyDisputeDecision(ctx *gin.Context) {
+	var data m.DisputeDecision
+	err := ctx.ShouldBindJSON(&data)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeJsonFormatError)
+		return
+	}
+
+	dispute, err := mongo.GetDispute(data.DisputeId)
+	if err != nil {
+		if err == mongodb.ErrNoDocuments {
+			ctl.ResponseError(ctx, ctl.CodeParamsError)
+			return
+		}
+		err = errors.Wrap(err, "GetDispute mongodb fail")
+		ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, err)
+		return
+	}
+
+	// 已经裁决的纠纷不能重复裁决
+	if dispute.Status == 2 {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+
+	ctx.Set("decision", data)
+	ctx.Set("dispute", dispute)
+}
+
+// VerifyDisputeRefund 验证管理员标记退款完成
+func VerifyDisputeRefund(ctx *gin.Context) {
+	var data m.DisputeRefund
+	err := ctx.ShouldBindJSON(&data)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeJsonFormatError)
+		return
+	}
+
+	ctx.Set("refund", data)
+}
+
+// VerifyDisputeQuery 验证查看纠纷详情 只有双方和管理员可以查看
+func VerifyDisputeQuery(ctx *gin.Context) {
+	var query m.DisputeQuery
+	err := ctx.ShouldBindQuery(&query)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+
+	ctxData, _ := ctx.Get("userInfo")
+	loginUser := ctxData.(m.UserTokenPayload)
+
+	dispute, err := mongo.GetDispute(query.DisputeId)
+	if err != nil {
+		if err == mongodb.ErrNoDocuments {
+			ctl.ResponseError(ctx, ctl.CodeParamsError)
+			return
+		}
+		err = errors.Wrap(err, "GetDispute mongodb fail")
+		ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, err)
+		return
+	}
+
+	if dispute.Sponsor != loginUser.Id && dispute.Respondent != loginUser.Id && !IsAdmin(loginUser.Id) {
+		ctl.ResponseError(ctx, ctl.CodeUnPermission)
+		return
+	}
+
+	ctx.Set("dispute", dispute)
+}
+
+// VerifyDisputeListQuery 验证管理员查询纠纷列表
+func VerifyDisputeListQuery(ctx *gin.Context) {
+	var query m.DisputeListQuery
+	err := ctx.ShouldBindQuery(&query)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+
+	ctx.Set("query", query)
+}
+
+// moveDisputeFiles 验证证据图片后从临时桶移动到原始桶 并用图片内容中的信息替换上传的信息
+func moveDisputeFiles(userId string, files []m.PicsType) (res []m.PicsType, err error) {
+	res = make([]m.PicsType, 0, len(files))
+	for _, file := range files {
+		key := "dispute/" + userId + "/" + file.FileName
+		info, mErr := moveTempImage(key, ImageMaxSize)
+		if mErr != nil {
+			err = mErr
+			return
+		}
+
+		res = append(res, m.PicsType{
+			FileName: file.FileName,
+			Mimetype: info.Mimetype,
+			Size:     info.Size,
+			Sort:     file.Sort,
+			Width:    uint16(info.Width),
+			Height:   uint16(info.Height),
+		})
+	}
+	return
+}
@@ -0,0 +1,78 @@
+package handleMiddle
+
+import (
+	"database/sql"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	ctl "onpaper-api-go/controller"
+	"onpaper-api-go/dao/mysql"
+	m "onpaper-api-go/models"
+)
+
+// VerifyArtworkDownload 验证下载原图的权限
+// 作者本人可以随意下载 其他人需要能查看作品 且授权协议允许转载 敏感作品还需要查看者允许显示
+// 需要署名的协议强制加水印 只有 CC0 可以下载无水印原图
+func VerifyArtworkDownload(ctx *gin.Context) {
+	var query m.DownloadQuery
+	err := ctx.ShouldBindQuery(&query)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+
+	ctxData, _ := ctx.Get("userInfo")
+	loginUser := ctxData.(m.UserTokenPayload)
+
+	info, err := mysql.GetArtworkDownloadInfo(query.ArtworkId, query.FileName)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			ctl.ResponseError(ctx, ctl.CodeArtworkNoExists)
+			return
+		}
+		ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, err)
+		return
+	}
+
+	if loginUser.Id != info.UserId {
+		switch info.WhoSee {
+		case "privacy":
+			ctl.ResponseError(ctx, ctl.CodeUnPermission)
+			return
+		case "onlyFans":
+			focus, mErr := mysql.CheckUserFocus([]string{loginUser.Id}, info.UserId)
+			if mErr != nil {
+				ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, mErr)
+				return
+			}
+			if len(focus) == 0 || focus[0].IsFocus == 0 {
+				ctl.ResponseError(ctx, ctl.CodeUnPermission)
+				return
+			}
+		}
+
+		// 敏感作品 查看者的偏好是隐藏时不能下载
+		if info.Adults {
+			pref, mErr := ctl.GetUserAdultPref(loginUser.Id)
+			if mErr != nil {
+				ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, mErr)
+				return
+			}
+			if pref == m.AdultPrefHide {
+				ctl.ResponseError(ctx, ctl.CodeNeedAdult)
+				return
+			}
+		}
+
+		// 保留所有权利且不允许转载的作品不能下载
+		if info.CopyRight == m.LicenseOwner && !info.AllowRepost {
+			ctl.ResponseError(ctx, ctl.CodeUnPermission)
+			return
+		}
+		if info.CopyRight != m.LicenseCC0 {
+			query.Watermark = true
+		}
+	}
+
+	ctx.Set("query", query)
+	ctx.Set("downloadInfo", info)
+}
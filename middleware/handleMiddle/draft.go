@@ -0,0 +1,120 @@
+package handleMiddle
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	mongodb "go.mongodb.org/mongo-driver/mongo"
+	ctl "onpaper-api-go/controller"
+	"onpaper-api-go/dao/mongo"
+	m "onpaper-api-go/models"
+	"onpaper-api-go/utils/snowflake"
+	"time"
+)
+
+// DraftMaxScheduleTime 定时发布最多可以设置到多久之后
+const DraftMaxScheduleTime = 30 * 24 * time.Hour
+
+// HandleArtworkDraft 验证保存的作品草稿 草稿和发布作品的验证规则相同
+func HandleArtworkDraft(ctx *gin.Context) {
+	ctxData, _ := ctx.Get("userInfo")
+	userInfo := ctxData.(m.UserTokenPayload)
+
+	var data m.CallBackArtworkDraft
+	err := ctx.ShouldBindJSON(&data)
+	if err != nil {
+		ctl.ResponseErrorAndLog(ctx, ctl.CodeJsonFormatError, err)
+		return
+	}
+
+	// 定时发布的时间 必须在将来 且不超过30天
+	if data.PublishAt != nil {
+		now := time.Now()
+		if data.PublishAt.Before(now.Add(time.Minute)) || data.PublishAt.After(now.Add(DraftMaxScheduleTime)) {
+			ctl.ResponseError(ctx, ctl.CodeParamsError)
+			return
+		}
+	}
+
+	// 编辑已有草稿时 已经保存过的图片不需要再移动
+	var saved map[string]m.PicsType
+	if data.DraftId != 0 {
+		draft, ok := getOwnerDraft(ctx, userInfo.Id, data.DraftId)
+		if !ok {
+			return
+		}
+		saved = make(map[string]m.PicsType, len(draft.FileList)+1)
+		for _, file := range draft.FileList {
+			saved[file.FileName] = file
+		}
+		if _, have := saved[draft.Cover]; !have {
+			saved[draft.Cover] = m.PicsType{FileName: draft.Cover}
+		}
+	}
+
+	artworkInfo, ok := verifyArtworkInfo(ctx, userInfo.Id, data.CallBackArtworkInfo, saved)
+	if !ok {
+		return
+	}
+	artworkInfo.ArtworkId = data.DraftId
+	if artworkInfo.ArtworkId == 0 {
+		artworkInfo.ArtworkId = snowflake.CreateID()
+	}
+
+	ctx.Set("draft", m.NewArtworkDraft(artworkInfo, data.PublishAt))
+}
+
+// VerifyDraftOwner 验证草稿是否是登录用户的
+func VerifyDraftOwner(ctx *gin.Context) {
+	var query m.DraftQuery
+	err := ctx.ShouldBind(&query)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+
+	ctxData, _ := ctx.Get("userInfo")
+	userInfo := ctxData.(m.UserTokenPayload)
+
+	draft, ok := getOwnerDraft(ctx, userInfo.Id, query.DraftId)
+	if !ok {
+		return
+	}
+
+	ctx.Set("draft", draft)
+}
+
+// VerifyDraftList 验证查询草稿列表
+func VerifyDraftList(ctx *gin.Context) {
+	var query m.DraftListQuery
+	err := ctx.ShouldBindQuery(&query)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+
+	ctx.Set("query", query)
+}
+
+// getOwnerDraft 查询草稿 验证是否是登录用户的 发布中的草稿不能操作
+func getOwnerDraft(ctx *gin.Context, userId string, draftId int64) (draft m.ArtworkDraft, ok bool) {
+	draft, err := mongo.GetArtworkDraft(draftId)
+	if err != nil {
+		if err == mongodb.ErrNoDocuments {
+			ctl.ResponseError(ctx, ctl.CodeParamsError)
+			return
+		}
+		err = errors.Wrap(err, "GetArtworkDraft mongodb fail")
+		ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, err)
+		return
+	}
+	if draft.UserId != userId {
+		ctl.ResponseError(ctx, ctl.CodeUnPermission)
+		return
+	}
+	if draft.Status == m.DraftStatusPublishing {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+	ok = true
+	return
+}
@@ -19,6 +19,11 @@ func HandlePostReport(ctx *gin.Context) {
 		ctl.ResponseError(ctx, ctl.CodeJsonFormatError)
 		return
 	}
+	// 只有评价有回复
+	if data.IsReply && data.MsgType != "ev" {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
 
 	ctx.Set("report", data)
 }
@@ -1,16 +1,18 @@
 package handleMiddle
 
 import (
-	"fmt"
 	"github.com/pkg/errors"
 	ctl "onpaper-api-go/controller"
+	"onpaper-api-go/dao/mongo"
 	"onpaper-api-go/models"
 	"onpaper-api-go/settings"
 	"onpaper-api-go/utils/formatTools"
+	"onpaper-api-go/utils/imgcheck"
 	"onpaper-api-go/utils/oss"
 	"onpaper-api-go/utils/snowflake"
 	"onpaper-api-go/utils/verify"
 	"strconv"
+	"strings"
 	"time"
 	"unicode/utf8"
 
@@ -32,29 +34,20 @@ func HandleAvatarInfo(ctx *gin.Context) {
 	userInfo := ctxData.(models.UserTokenPayload)
 
 	path := "avatars/" + userInfo.Id + "/" + data.FileName
-	// 查询文件是否存在
-	fileInfo, err := oss.SelectOssFileInfo(settings.Conf.TempBucket, path)
+	// 验证头像 不能大于 5M 并移动图片到原始库
+	info, err := moveTempImage(path, AvatarMaxSize)
 	if err != nil {
-		err = errors.Wrap(err, fmt.Sprintf("头像不存在,%s", path))
-		ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, err)
-		return
-	}
-
-	hSize := fileInfo.Get("Content-Length")
-	size, _ := strconv.ParseInt(hSize, 10, 64)
-	// 如果图片大于 5M 报错
-	if size > 5*1024*1024 {
-		ctl.ResponseErrorAndLog(ctx, ctl.CodeJsonFormatError, err)
+		responseImageError(ctx, err)
 		return
 	}
-	data.Size = size
-	data.Type = fileInfo.Get("Content-Type")
+	data.Size = info.Size
+	data.Type = info.Mimetype
 
-	//移动图片到原始库
-	err = oss.MoveTempToOriginal(path)
+	// 小头像之后会从临时桶移动到阅览桶 也需要验证
+	sAvatar := strings.Join(strings.Split(data.FileName, "."), "_s.")
+	_, err = cleanTempImage("avatars/"+userInfo.Id+"/"+sAvatar, AvatarMaxSize)
 	if err != nil {
-		err = errors.Wrap(err, "MoveTempToOriginal fail")
-		ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, err)
+		responseImageError(ctx, err)
 		return
 	}
 	// 把它传递到上下文
@@ -76,31 +69,15 @@ func HandleBannerInfo(ctx *gin.Context) {
 	userInfo := ctxData.(models.UserTokenPayload)
 
 	path := "banners/" + userInfo.Id + "/" + data.FileName
-	// 查询文件是否存在
-	fileInfo, err := oss.SelectOssFileInfo(settings.Conf.TempBucket, path)
+	// 验证背景 不能大于 15M 并移动图片到原始库
+	info, err := moveTempImage(path, BannerMaxSize)
 	if err != nil {
-		err = errors.Wrap(err, fmt.Sprintf("背景不存在,%s", path))
-		ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, err)
+		responseImageError(ctx, err)
 		return
 	}
+	data.Size = info.Size
+	data.Type = info.Mimetype
 
-	hSize := fileInfo.Get("Content-Length")
-	size, _ := strconv.ParseInt(hSize, 10, 64)
-	// 如果图片大于 15M 报错
-	if size > 15*1024*1024 {
-		ctl.ResponseErrorAndLog(ctx, ctl.CodeJsonFormatError, err)
-		return
-	}
-	data.Size = size
-	data.Type = fileInfo.Get("Content-Type")
-
-	//移动图片到原始库
-	err = oss.MoveTempToOriginal(path)
-	if err != nil {
-		err = errors.Wrap(err, "MoveTempToOriginal fail")
-		ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, err)
-		return
-	}
 	// 把它传递到上下文
 	ctx.Set("fileInfo", data)
 }
@@ -133,6 +110,19 @@ func HandleArtworkInfo(ctx *gin.Context) {
 		ctl.ResponseErrorAndLog(ctx, ctl.CodeJsonFormatError, err)
 		return
 	}
+
+	artworkInfo, ok := verifyArtworkInfo(ctx, userInfo.Id, data, nil)
+	if !ok {
+		return
+	}
+	artworkInfo.ArtworkId = snowflake.CreateID()
+	// 把它传递到上下文
+	ctx.Set("artworkInfo", artworkInfo)
+}
+
+// verifyArtworkInfo 验证上传的作品信息 并把图片移动到原始桶
+// saved 是草稿中已经保存过的图片 不需要再从临时桶移动
+func verifyArtworkInfo(ctx *gin.Context, userId string, data models.CallBackArtworkInfo, saved map[string]models.PicsType) (artworkInfo *models.SaveArtworkInfo, ok bool) {
 	// -----------------2.验证文本 ---------------
 	//标体不超过15个字 描述不超过350个字
 	isPass := verify.ArtTextInfo(data.Title, data.Description, data.Tags)
@@ -162,14 +152,20 @@ func HandleArtworkInfo(ctx *gin.Context) {
 		ctl.ResponseError(ctx, ctl.CodeParamsError)
 		return
 	}
+	//验证授权协议
+	if !verify.License(data.CopyRight) {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
 
 	//验证封面信息是否正确
-	key := "artworks/" + userInfo.Id + "/" + data.Cover
-	err = oss.MoveTempToOriginal(key)
-	if err != nil {
-		err = errors.Wrap(err, "MoveTempToOriginal fail")
-		ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, err)
-		return
+	key := "artworks/" + userId + "/" + data.Cover
+	if _, isSaved := saved[data.Cover]; !isSaved {
+		_, err := moveTempImage(key, ImageMaxSize)
+		if err != nil {
+			responseImageError(ctx, err)
+			return
+		}
 	}
 
 	// -----------------3.cos验证文件是否存在 ---------------
@@ -177,49 +173,42 @@ func HandleArtworkInfo(ctx *gin.Context) {
 	var fileList []*models.PicsType
 	var firstPic string
 	for _, file := range data.FileList {
-		key = "artworks/" + userInfo.Id + "/" + file.FileName
-		mErr := oss.MoveTempToOriginal(key)
-		if mErr != nil {
-			mErr = errors.Wrap(mErr, "MoveTempToOriginal fail: "+key)
-			ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, mErr)
-			return
+		//  保持首张图片名
+		if file.Sort == 0 {
+			firstPic = file.FileName
+		}
+		// 草稿中保存过的图片 直接使用保存的文件信息
+		if savedFile, isSaved := saved[file.FileName]; isSaved {
+			savedFile.Sort = file.Sort
+			fileList = append(fileList, &savedFile)
+			continue
 		}
-		//到cos中查询文件信息
-		fInfo, fErr := oss.SelectOssFileInfo(settings.Conf.OriginalBucket, key)
-		if fErr != nil {
-			fErr = errors.Wrap(fErr, "cos 查询错误")
-			ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, fErr)
+
+		key = "artworks/" + userId + "/" + file.FileName
+		//验证图片 文件信息以图片内容为准
+		info, mErr := moveTempImage(key, ImageMaxSize)
+		if mErr != nil {
+			responseImageError(ctx, mErr)
 			return
 		}
-		// 文件类型
-		contentType := fInfo.Get("Content-Type")
-		// 文件大小
-		contentLength := fInfo.Get("Content-Length")
-		//字符串 -> int64
-		size, _ := strconv.ParseInt(contentLength, 10, 64)
 
 		var fileInfo = &models.PicsType{
 			FileName: file.FileName,
-			Mimetype: contentType,
-			Size:     size,
+			Mimetype: info.Mimetype,
+			Size:     info.Size,
 			Sort:     file.Sort,
-			Width:    file.Width,
-			Height:   file.Height,
+			Width:    uint16(info.Width),
+			Height:   uint16(info.Height),
 		}
 		fileList = append(fileList, fileInfo)
-		//  保持首张图片名
-		if file.Sort == 0 {
-			firstPic = file.FileName
-		}
 	}
 
 	// 去重
 	tags, _ := formatTools.RemoveSliceDuplicate(data.Tags)
 
 	// 构建需要保存的作品信息
-	var artworkInfo = &models.SaveArtworkInfo{
-		ArtworkId:   snowflake.CreateID(),
-		UserId:      userInfo.Id,
+	artworkInfo = &models.SaveArtworkInfo{
+		UserId:      userId,
 		FileList:    fileList,
 		FirstPic:    firstPic,
 		Title:       data.Title,
@@ -230,11 +219,11 @@ func HandleArtworkInfo(ctx *gin.Context) {
 		Adults:      data.Adult,
 		Cover:       data.Cover,
 		Comment:     data.Comment,
-		CopyRight:   data.CopyRight,
+		ArtLicense:  data.ArtLicense,
 		Device:      data.Device,
 	}
-	// 把它传递到上下文
-	ctx.Set("artworkInfo", artworkInfo)
+	ok = true
+	return
 }
 
 // HandleTrendInfo 验证trend 信息
@@ -258,6 +247,11 @@ func HandleTrendInfo(ctx *gin.Context) {
 		ctl.ResponseError(ctx, ctl.CodeJsonFormatError)
 		return
 	}
+	// 投票需要有问题
+	if data.Poll != nil && data.Text == "" {
+		ctl.ResponseError(ctx, ctl.CodeJsonFormatError)
+		return
+	}
 
 	// 不能超过9张图片
 	if len(data.FileList) > 9 {
@@ -295,38 +289,55 @@ func HandleTrendInfo(ctx *gin.Context) {
 		return
 	}
 
+	//验证投票
+	var poll *models.TrendPoll
+	if data.Poll != nil {
+		poll, isPass = verifyTrendPoll(*data.Poll, time.Now())
+		if !isPass {
+			ctl.ResponseError(ctx, ctl.CodeParamsError)
+			return
+		}
+	}
+
+	// 转发的转发 指向原作 保留中间经过的动态
+	forwardType := data.ForwardInfo.Type
+	var forwardChain []string
+	if ForwardId != 0 && forwardType == "tr" {
+		parent, mErr := mongo.GetTrendForwardInfo(ForwardId)
+		if mErr != nil {
+			ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, mErr)
+			return
+		}
+		if parent.Id != 0 {
+			forwardChain = append(parent.Chain, strconv.FormatInt(ForwardId, 10))
+			// 转发链太长时只保留最近经过的动态 更早的动态不再计入这次转发
+			if len(forwardChain) > forwardChainMax {
+				forwardChain = forwardChain[len(forwardChain)-forwardChainMax:]
+			}
+			ForwardId = parent.Id
+			forwardType = parent.Type
+		}
+	}
+
 	// -----------------3.cos验证文件是否存在 ---------------
 	// 存放循环查询后的 文件信息
 	var fileList []models.PicsType
 	for _, file := range data.FileList {
 		key := "trends/" + userInfo.Id + "/" + file.FileName
-		mErr := oss.MoveTempToOriginal(key)
+		//验证图片 文件信息以图片内容为准
+		info, mErr := moveTempImage(key, ImageMaxSize)
 		if mErr != nil {
-			mErr = errors.Wrap(mErr, "MoveTempToOriginal fail")
-			ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, mErr)
+			responseImageError(ctx, mErr)
 			return
 		}
-		//到cos中查询文件信息
-		fInfo, fErr := oss.SelectOssFileInfo(settings.Conf.OriginalBucket, key)
-		if fErr != nil {
-			fErr = errors.Wrap(fErr, "cos 查询错误")
-			ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, fErr)
-			return
-		}
-		// 文件类型
-		contentType := fInfo.Get("Content-Type")
-		// 文件大小
-		contentLength := fInfo.Get("Content-Length")
-		//字符串 -> int64
-		size, _ := strconv.ParseInt(contentLength, 10, 64)
 
 		var fileInfo = models.PicsType{
 			FileName: file.FileName,
-			Mimetype: contentType,
-			Size:     size,
+			Mimetype: info.Mimetype,
+			Size:     info.Size,
 			Sort:     file.Sort,
-			Width:    file.Width,
-			Height:   file.Height,
+			Width:    uint16(info.Width),
+			Height:   uint16(info.Height),
 		}
 		fileList = append(fileList, fileInfo)
 	}
@@ -340,6 +351,7 @@ func HandleTrendInfo(ctx *gin.Context) {
 		UserId:   userInfo.Id,
 		Text:     data.Text,
 		Topic:    data.Topic,
+		Hashtags: parseHashtags(data.Text),
 		Pics:     fileList,
 		Comment:  data.Comment,
 		WhoSee:   data.WhoSee,
@@ -349,11 +361,144 @@ func HandleTrendInfo(ctx *gin.Context) {
 		State:    0,
 		IsDelete: false,
 		ForwardInfo: models.ForwardInfo{
-			Id:   ForwardId,
-			Type: data.ForwardInfo.Type,
+			Id:    ForwardId,
+			Type:  forwardType,
+			Chain: forwardChain,
 		},
+		Poll: poll,
 	}
 
 	// 把它传递到上下文
 	ctx.Set("trendInfo", trendInfo)
 }
+
+// parseHashtags 解析正文中的 #话题# 话题不能为空 不能换行 长度不超过 25
+// 位置按 UTF-16 计算 和前端 js 字符串的下标一致 emoji 等占两个
+func parseHashtags(text string) (tags []models.Hashtag) {
+	runes := []rune(text)
+	// offsets[i] 第 i 个字符在 UTF-16 中的位置
+	offsets := make([]int, len(runes)+1)
+	for i, r := range runes {
+		offsets[i+1] = offsets[i] + 1
+		// 超出基本平面的字符在 UTF-16 中是两个代理项
+		if r > 0xFFFF {
+			offsets[i+1]++
+		}
+	}
+	start := -1
+	for i, r := range runes {
+		if r == '\n' {
+			start = -1
+			continue
+		}
+		if r != '#' {
+			continue
+		}
+		if start == -1 {
+			start = i
+			continue
+		}
+		topic := strings.TrimSpace(string(runes[start+1 : i]))
+		// 不是合法的话题 当前的 # 作为下一个话题的开头
+		if topic == "" || utf8.RuneCountInString(topic) > 25 {
+			start = i
+			continue
+		}
+		tags = append(tags, models.Hashtag{
+			TopicType: models.TopicType{Text: topic},
+			Start:     offsets[start],
+			End:       offsets[i+1],
+		})
+		if len(tags) == models.TrendHashtagMax {
+			return
+		}
+		start = -1
+	}
+	return
+}
+
+// verifyTrendPoll 验证动态的投票 选项不能为空或重复 结束时间在 5 分钟到 30 天之间
+func verifyTrendPoll(data models.PostPoll, now time.Time) (poll *models.TrendPoll, ok bool) {
+	if len(data.Options) < models.PollMinOptions || len(data.Options) > models.PollMaxOptions {
+		return
+	}
+	if data.EndAt.Before(now.Add(models.PollMinMinutes*time.Minute)) || data.EndAt.After(now.AddDate(0, 0, models.PollMaxDays)) {
+		return
+	}
+
+	seen := make(map[string]struct{}, len(data.Options))
+	options := make([]models.PollOption, 0, len(data.Options))
+	for _, text := range data.Options {
+		text = strings.TrimSpace(text)
+		if _, exists := seen[text]; text == "" || exists {
+			return
+		}
+		seen[text] = struct{}{}
+		options = append(options, models.PollOption{Text: text})
+	}
+
+	poll = &models.TrendPoll{
+		Options:  options,
+		Multiple: data.Multiple,
+		EndAt:    data.EndAt,
+	}
+	return poll, true
+}
+
+// 上传图片的大小限制
+const (
+	AvatarMaxSize = 5 * 1024 * 1024
+	BannerMaxSize = 15 * 1024 * 1024
+	ImageMaxSize  = 50 * 1024 * 1024
+)
+
+// forwardChainMax 转发链最多保存的动态数
+const forwardChainMax = 20
+
+// cleanTempImage 读取临时桶中的图片 验证后去掉元数据写回临时桶
+// 浏览器直传的文件 类型 大小 尺寸都以文件内容为准
+func cleanTempImage(key string, maxSize int64) (info imgcheck.Info, err error) {
+	// 只读取到超过限制的第一个字节 过大的文件由 Clean 拒绝
+	data, err := oss.GetOssObjectLimit(settings.Conf.TempBucket, key, maxSize)
+	if err != nil {
+		err = errors.Wrap(err, "cleanTempImage GetOssObjectLimit fail: "+key)
+		return
+	}
+
+	clean, info, err := imgcheck.Clean(data, maxSize)
+	if err != nil {
+		return
+	}
+
+	err = oss.PutOssObject(settings.Conf.TempBucket, key, clean, info.Mimetype)
+	if err != nil {
+		err = errors.Wrap(err, "cleanTempImage PutOssObject fail: "+key)
+	}
+	return
+}
+
+// moveTempImage 验证临时桶中的图片 再移动到原始桶
+func moveTempImage(key string, maxSize int64) (info imgcheck.Info, err error) {
+	info, err = cleanTempImage(key, maxSize)
+	if err != nil {
+		return
+	}
+
+	err = oss.MoveTempToOriginal(key)
+	if err != nil {
+		err = errors.Wrap(err, "MoveTempToOriginal fail: "+key)
+	}
+	return
+}
+
+// responseImageError 图片验证失败的返回
+func responseImageError(ctx *gin.Context, err error) {
+	switch errors.Cause(err) {
+	case imgcheck.ErrNotImage:
+		ctl.ResponseError(ctx, ctl.CodeUploadNotAImg)
+	case imgcheck.ErrTooLarge:
+		ctl.ResponseError(ctx, ctl.CodeUploadFilesTooLarge)
+	default:
+		ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, err)
+	}
+}
@@ -0,0 +1,212 @@
+package handleMiddle
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	mongodb "go.mongodb.org/mongo-driver/mongo"
+	ctl "onpaper-api-go/controller"
+	"onpaper-api-go/dao/mongo"
+	m "onpaper-api-go/models"
+)
+
+// FolderMaxCount 每个用户最多可以创建的收藏夹数
+const FolderMaxCount = 50
+
+// VerifyPostFolder 验证创建收藏夹
+func VerifyPostFolder(ctx *gin.Context) {
+	var data m.PostCollectFolder
+	err := ctx.ShouldBindJSON(&data)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeJsonFormatError)
+		return
+	}
+
+	ctxData, _ := ctx.Get("userInfo")
+	loginUser := ctxData.(m.UserTokenPayload)
+
+	// 先创建默认收藏夹 保证默认收藏夹一直存在
+	_, err = mongo.EnsureDefaultFolder(loginUser.Id)
+	if err != nil {
+		ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, err)
+		return
+	}
+	folders, err := mongo.GetUserFolders(loginUser.Id, false)
+	if err != nil {
+		ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, err)
+		return
+	}
+	if len(folders) >= FolderMaxCount {
+		ctl.ResponseError(ctx, ctl.CodeFolderLimit)
+		return
+	}
+
+	ctx.Set("folder", data)
+}
+
+// VerifyUpdateFolder 验证编辑收藏夹
+func VerifyUpdateFolder(ctx *gin.Context) {
+	var data m.PostCollectFolder
+	err := ctx.ShouldBindJSON(&data)
+	if err != nil || data.FolderId == 0 {
+		ctl.ResponseError(ctx, ctl.CodeJsonFormatError)
+		return
+	}
+
+	ctxData, _ := ctx.Get("userInfo")
+	loginUser := ctxData.(m.UserTokenPayload)
+
+	folder, ok := getOwnerFolder(ctx, loginUser.Id, data.FolderId)
+	if !ok {
+		return
+	}
+	// 默认收藏夹不能设为私密 否则别人看不到新收藏的作品会以为收藏失败
+	if folder.IsDefault {
+		data.IsPublic = folder.IsPublic
+	}
+	// 封面必须是收藏夹中的作品
+	if data.CoverArt != "" {
+		items, mErr := mongo.GetFolderItemsByIds(folder.FolderId, []string{data.CoverArt})
+		if mErr != nil {
+			ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, mErr)
+			return
+		}
+		if len(items) == 0 {
+			ctl.ResponseError(ctx, ctl.CodeParamsError)
+			return
+		}
+	}
+
+	ctx.Set("folder", data)
+}
+
+// VerifyDeleteFolder 验证删除收藏夹 默认收藏夹不能删除
+func VerifyDeleteFolder(ctx *gin.Context) {
+	var data m.DeleteFolder
+	err := ctx.ShouldBindJSON(&data)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeJsonFormatError)
+		return
+	}
+
+	ctxData, _ := ctx.Get("userInfo")
+	loginUser := ctxData.(m.UserTokenPayload)
+
+	folder, ok := getOwnerFolder(ctx, loginUser.Id, data.FolderId)
+	if !ok {
+		return
+	}
+	if folder.IsDefault {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+
+	ctx.Set("folder", folder)
+}
+
+// VerifyFolderQuery 验证查看收藏夹的作品 私密收藏夹只有自己能看
+func VerifyFolderQuery(ctx *gin.Context) {
+	var query m.FolderQuery
+	err := ctx.ShouldBindQuery(&query)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+
+	ctxData, _ := ctx.Get("userInfo")
+	loginUser := ctxData.(m.UserTokenPayload)
+
+	folder, err := mongo.GetFolder(query.FolderId)
+	if err != nil {
+		if err == mongodb.ErrNoDocuments {
+			ctl.ResponseError(ctx, ctl.CodeParamsError)
+			return
+		}
+		err = errors.Wrap(err, "GetFolder mongodb fail")
+		ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, err)
+		return
+	}
+	if !folder.IsPublic && folder.UserId != loginUser.Id {
+		ctl.ResponseError(ctx, ctl.CodeUnPermission)
+		return
+	}
+
+	ctx.Set("query", query)
+	ctx.Set("folder", folder)
+}
+
+// VerifyMoveFolderArtwork 验证在收藏夹之间移动或复制作品
+func VerifyMoveFolderArtwork(ctx *gin.Context) {
+	var data m.MoveFolderArtwork
+	err := ctx.ShouldBindJSON(&data)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeJsonFormatError)
+		return
+	}
+
+	ctxData, _ := ctx.Get("userInfo")
+	loginUser := ctxData.(m.UserTokenPayload)
+
+	from, ok := getOwnerFolder(ctx, loginUser.Id, data.FromId)
+	if !ok {
+		return
+	}
+	to, ok := getOwnerFolder(ctx, loginUser.Id, data.ToId)
+	if !ok {
+		return
+	}
+
+	// 只能移动来源收藏夹中已有的作品
+	items, err := mongo.GetFolderItemsByIds(from.FolderId, data.MsgIds)
+	if err != nil {
+		ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, err)
+		return
+	}
+	if len(items) == 0 {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+
+	ctx.Set("move", data)
+	ctx.Set("folderItems", items)
+	ctx.Set("folder", to)
+}
+
+// VerifySortFolderArtwork 验证调整收藏夹作品顺序
+func VerifySortFolderArtwork(ctx *gin.Context) {
+	var data m.SortFolderArtwork
+	err := ctx.ShouldBindJSON(&data)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeJsonFormatError)
+		return
+	}
+
+	ctxData, _ := ctx.Get("userInfo")
+	loginUser := ctxData.(m.UserTokenPayload)
+
+	_, ok := getOwnerFolder(ctx, loginUser.Id, data.FolderId)
+	if !ok {
+		return
+	}
+
+	ctx.Set("sort", data)
+}
+
+// getOwnerFolder 查询收藏夹 并验证是否是登录用户的
+func getOwnerFolder(ctx *gin.Context, userId string, folderId int64) (folder m.CollectFolder, ok bool) {
+	folder, err := mongo.GetFolder(folderId)
+	if err != nil {
+		if err == mongodb.ErrNoDocuments {
+			ctl.ResponseError(ctx, ctl.CodeParamsError)
+			return
+		}
+		err = errors.Wrap(err, "GetFolder mongodb fail")
+		ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, err)
+		return
+	}
+	if folder.UserId != userId {
+		ctl.ResponseError(ctx, ctl.CodeUnPermission)
+		return
+	}
+	ok = true
+	return
+}
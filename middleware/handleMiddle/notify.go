@@ -19,7 +19,7 @@ func NotifyQuery(ctx *gin.Context) {
 
 // UpdateNotifyConfig 修改通知设置
 func UpdateNotifyConfig(ctx *gin.Context) {
-	var data models.NotifyConfig
+	var data models.UpdateNotifyConfig
 	err := ctx.ShouldBindJSON(&data)
 	if err != nil {
 		ctl.ResponseErrorAndLog(ctx, ctl.CodeParamsError, err)
@@ -0,0 +1,46 @@
+package handleMiddle
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	mongodb "go.mongodb.org/mongo-driver/mongo"
+	ctl "onpaper-api-go/controller"
+	"onpaper-api-go/dao/mongo"
+	m "onpaper-api-go/models"
+)
+
+// VerifyReviewListQuery 验证管理员查询审核列表
+func VerifyReviewListQuery(ctx *gin.Context) {
+	var query m.ReviewListQuery
+	err := ctx.ShouldBindQuery(&query)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+
+	ctx.Set("query", query)
+}
+
+// VerifyReviewDecision 验证管理员的审核结果
+func VerifyReviewDecision(ctx *gin.Context) {
+	var data m.ReviewDecision
+	err := ctx.ShouldBindJSON(&data)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeJsonFormatError)
+		return
+	}
+
+	review, err := mongo.GetArtworkReview(data.ArtworkId)
+	if err != nil {
+		if err == mongodb.ErrNoDocuments {
+			ctl.ResponseError(ctx, ctl.CodeParamsError)
+			return
+		}
+		err = errors.Wrap(err, "GetArtworkReview mongodb fail")
+		ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, err)
+		return
+	}
+
+	ctx.Set("decision", data)
+	ctx.Set("review", review)
+}
@@ -0,0 +1,147 @@
+package handleMiddle
+
+import (
+	"database/sql"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	ctl "onpaper-api-go/controller"
+	"onpaper-api-go/dao/mysql"
+	m "onpaper-api-go/models"
+	"onpaper-api-go/settings"
+	"onpaper-api-go/utils/oss"
+)
+
+// HandleReplaceArtPicture 验证替换 添加 调整作品图片
+func HandleReplaceArtPicture(ctx *gin.Context) {
+	var data m.ReplaceArtPicture
+	err := ctx.ShouldBindJSON(&data)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeJsonFormatError)
+		return
+	}
+
+	ctxData, _ := ctx.Get("userInfo")
+	userInfo := ctxData.(m.UserTokenPayload)
+
+	if !verifyArtOwner(ctx, userInfo.Id, data.ArtworkId) {
+		return
+	}
+
+	snapshot, err := mysql.GetArtworkSnapshot(data.ArtworkId)
+	if err != nil {
+		ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, err)
+		return
+	}
+
+	// 作品现有的图片 不需要再从临时桶移动
+	saved := make(map[string]m.ArtworkPicture, len(snapshot.Pictures))
+	for _, pic := range snapshot.Pictures {
+		saved[pic.FileName] = pic
+	}
+
+	// 排序必须是 0 到 n-1 且不重复
+	sorts := make(map[uint8]bool, len(data.FileList))
+	names := make(map[string]bool, len(data.FileList))
+	for _, file := range data.FileList {
+		if int(file.Sort) >= len(data.FileList) || sorts[file.Sort] || names[file.FileName] {
+			ctl.ResponseError(ctx, ctl.CodeParamsError)
+			return
+		}
+		sorts[file.Sort] = true
+		names[file.FileName] = true
+	}
+
+	//封面修改了 需要移动新的封面
+	if data.Cover != snapshot.Cover {
+		key := "artworks/" + userInfo.Id + "/" + data.Cover
+		_, err = moveTempImage(key, ImageMaxSize)
+		if err != nil {
+			responseImageError(ctx, err)
+			return
+		}
+	}
+
+	// 新图片验证后从临时桶移动 图片信息都以图片内容为准
+	var fileList []*m.PicsType
+	var firstPic string
+	for _, file := range data.FileList {
+		if file.Sort == 0 {
+			firstPic = file.FileName
+		}
+
+		key := "artworks/" + userInfo.Id + "/" + file.FileName
+		if pic, isSaved := saved[file.FileName]; isSaved {
+			fInfo, fErr := oss.SelectOssFileInfo(settings.Conf.OriginalBucket, key)
+			if fErr != nil {
+				fErr = errors.Wrap(fErr, "cos 查询错误")
+				ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, fErr)
+				return
+			}
+			fileList = append(fileList, &m.PicsType{
+				FileName: file.FileName,
+				Mimetype: fInfo.Get("Content-Type"),
+				Size:     int64(pic.Size),
+				Sort:     file.Sort,
+				Width:    pic.Width,
+				Height:   pic.Height,
+			})
+			continue
+		}
+
+		info, mErr := moveTempImage(key, ImageMaxSize)
+		if mErr != nil {
+			responseImageError(ctx, mErr)
+			return
+		}
+		fileList = append(fileList, &m.PicsType{
+			FileName: file.FileName,
+			Mimetype: info.Mimetype,
+			Size:     info.Size,
+			Sort:     file.Sort,
+			Width:    uint16(info.Width),
+			Height:   uint16(info.Height),
+		})
+	}
+
+	ctx.Set("picture", data)
+	ctx.Set("fileList", fileList)
+	ctx.Set("firstPic", firstPic)
+	ctx.Set("artSnapshot", snapshot)
+}
+
+// VerifyArtworkRevision 验证查询作品修改记录 只有作者可以查看
+func VerifyArtworkRevision(ctx *gin.Context) {
+	var query m.RevisionQuery
+	err := ctx.ShouldBindQuery(&query)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+
+	ctxData, _ := ctx.Get("userInfo")
+	userInfo := ctxData.(m.UserTokenPayload)
+
+	if !verifyArtOwner(ctx, userInfo.Id, query.ArtworkId) {
+		return
+	}
+
+	ctx.Set("query", query)
+}
+
+// verifyArtOwner 验证作品是否是登录用户的
+func verifyArtOwner(ctx *gin.Context, userId, artworkId string) bool {
+	isOwner, err := mysql.VerifyArtOwner(userId, artworkId)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			ctl.ResponseError(ctx, ctl.CodeParamsError)
+			return false
+		}
+		ctl.ResponseErrorAndLog(ctx, ctl.CodeServerBusy, err)
+		return false
+	}
+	if !isOwner {
+		ctl.ResponseError(ctx, ctl.CodeUnPermission)
+		return false
+	}
+	return true
+}
@@ -2,6 +2,7 @@ package handleMiddle
 
 import (
 	"net/http"
+	ctl "onpaper-api-go/controller"
 	"strings"
 	"time"
 
@@ -15,7 +16,7 @@ func Cors() gin.HandlerFunc {
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD"},
 		AllowHeaders:     []string{"Content-Type", "Authorization", "Accept"},
-		ExposeHeaders:    []string{"Content-Length", "text/plain", "Authorization", "Content-Type"},
+		ExposeHeaders:    []string{"Content-Length", "text/plain", "Authorization", "Content-Type", ctl.NextIdHeader},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour, //指定本次预检请求的有效期
 	})
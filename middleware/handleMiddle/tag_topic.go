@@ -2,8 +2,11 @@ package handleMiddle
 
 import (
 	"github.com/gin-gonic/gin"
+	"net/http"
 	ctl "onpaper-api-go/controller"
 	m "onpaper-api-go/models"
+	"onpaper-api-go/utils/formatTools"
+	"onpaper-api-go/utils/verify"
 )
 
 // HandleQueryTagArt 验证查询tag对应作品的 参数
@@ -13,6 +16,16 @@ func HandleQueryTagArt(ctx *gin.Context) {
 	if err != nil {
 		ctl.ResponseError(ctx, ctl.CodeParamsError)
 	}
+	// 筛选的授权协议
+	if data.License != "" && !verify.License(data.License) {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+	// 筛选的颜色
+	if !normalizeColorFilter(&data.ColorFilter) {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
 
 	ctx.Set("queryData", data)
 }
@@ -49,3 +62,42 @@ func HandleQueryTopicTrend(ctx *gin.Context) {
 
 	ctx.Set("queryData", data)
 }
+
+// VerifyTagAlias 验证标签别名参数
+func VerifyTagAlias(ctx *gin.Context) {
+	var data m.TagAliasParam
+	err := ctx.ShouldBindJSON(&data)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+	data.Alias = formatTools.CleanTagName(data.Alias)
+	// 添加别名时必须指定标签
+	if data.Alias == "" || ctx.Request.Method == http.MethodPost && data.TagId == "" {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+	ctx.Set("tagAlias", data)
+}
+
+// VerifyTagParent 验证设置父标签参数
+func VerifyTagParent(ctx *gin.Context) {
+	var data m.TagParentParam
+	err := ctx.ShouldBindJSON(&data)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+	ctx.Set("tagParent", data)
+}
+
+// VerifyTagMerge 验证合并标签参数
+func VerifyTagMerge(ctx *gin.Context) {
+	var data m.TagMergeParam
+	err := ctx.ShouldBindJSON(&data)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+	ctx.Set("tagMerge", data)
+}
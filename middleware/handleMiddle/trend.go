@@ -67,3 +67,25 @@ func VerifyTrendOwner(ctx *gin.Context) {
 		return
 	}
 }
+
+// VerifyPollVote 验证投票参数
+func VerifyPollVote(ctx *gin.Context) {
+	var data m.PostPollVote
+	err := ctx.ShouldBindJSON(&data)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+	ctx.Set("pollVote", data)
+}
+
+// VerifyForwardListQuery 验证查询转发列表的参数
+func VerifyForwardListQuery(ctx *gin.Context) {
+	var query m.ForwardListQuery
+	err := ctx.ShouldBindQuery(&query)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+	ctx.Set("query", query)
+}
@@ -4,6 +4,7 @@ import (
 	ctl "onpaper-api-go/controller"
 	m "onpaper-api-go/models"
 	"onpaper-api-go/utils/verify"
+	"strings"
 	"unicode/utf8"
 
 	"github.com/pkg/errors"
@@ -244,3 +245,63 @@ func VerifyAllUserShow(ctx *gin.Context) {
 
 	ctx.Set("query", query)
 }
+
+// VerifyAdultPref 验证修改敏感内容偏好的参数
+func VerifyAdultPref(ctx *gin.Context) {
+	var data m.UpdateAdultPref
+	err := ctx.ShouldBindJSON(&data)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+	ctx.Set("adultPref", data)
+}
+
+// VerifyPostInterest 验证关注标签 话题的参数
+func VerifyPostInterest(ctx *gin.Context) {
+	var data m.PostInterest
+	err := ctx.ShouldBindJSON(&data)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+	ctx.Set("interest", data)
+}
+
+// VerifyInterestQuery 验证查询关注的标签 话题的参数
+func VerifyInterestQuery(ctx *gin.Context) {
+	var query m.InterestQuery
+	err := ctx.ShouldBindQuery(&query)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+	ctx.Set("query", query)
+}
+
+// VerifyFocusMute 验证设置关注用户屏蔽的参数
+func VerifyFocusMute(ctx *gin.Context) {
+	var data m.PostFocusMute
+	err := ctx.ShouldBindJSON(&data)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+	ctx.Set("focusMute", data)
+}
+
+// VerifyMuteWord 验证屏蔽关键词的参数
+func VerifyMuteWord(ctx *gin.Context) {
+	var data m.PostMuteWord
+	err := ctx.ShouldBindJSON(&data)
+	if err != nil {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+	data.Word = strings.TrimSpace(data.Word)
+	if data.Word == "" {
+		ctl.ResponseError(ctx, ctl.CodeParamsError)
+		return
+	}
+	ctx.Set("muteWord", data)
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// 敏感内容的展示偏好
+const (
+	AdultPrefHide = "hide" // 不返回图片地址
+	AdultPrefBlur = "blur" // 返回图片 由客户端模糊处理
+	AdultPrefShow = "show" // 直接展示
+)
+
+// AdultAge 可以查看敏感内容的年龄
+const AdultAge = 18
+
+// AdultSetting 用户的敏感内容设置
+type AdultSetting struct {
+	Birthday sql.NullTime `json:"-" db:"birthday"`
+	Pref     string       `json:"pref" db:"adult_pref"`
+}
+
+// IsAdult 按用户自行填写的生日判断是否成年 没有填写生日的视为未成年
+// 生日只能填写一次 但没有实名验证
+func (s AdultSetting) IsAdult(now time.Time) bool {
+	if !s.Birthday.Valid {
+		return false
+	}
+	return !s.Birthday.Time.AddDate(AdultAge, 0, 0).After(now)
+}
+
+// Effective 实际生效的偏好 未成年一律隐藏
+func (s AdultSetting) Effective(now time.Time) string {
+	if !s.IsAdult(now) || s.Pref == "" {
+		return AdultPrefHide
+	}
+	return s.Pref
+}
+
+// UpdateAdultPref 修改敏感内容偏好
+type UpdateAdultPref struct {
+	Pref string `json:"pref" binding:"required,oneof=hide blur show"`
+}
@@ -4,23 +4,27 @@ import "time"
 
 // Artwork 作品信息
 type Artwork struct {
-	ArtworkId  string    `json:"artworkId"  db:"artwork_id"`
-	Title      string    `json:"title"  db:"title"`
-	UserId     string    `json:"userId"  db:"user_id"`
-	PicCount   uint8     `json:"picCount" db:"pic_count"`
-	Cover      string    `json:"cover" db:"cover"`
-	Zone       string    `json:"zone"  db:"zone"`
-	WhoSee     string    `json:"whoSee"  db:"whoSee"`
-	Adults     bool      `json:"adults"  db:"adults"`
-	ComSetting string    `json:"comSetting" db:"comment"`
-	Copyright  string    `json:"copyright" db:"copyright"`
-	Likes      int       `json:"likes" db:"likes"`
-	Views      int       `json:"views" db:"views"`
-	Comments   int       `json:"comments" db:"comments"`
-	Collects   int       `json:"collects" db:"collects"`
-	Forwards   int       `json:"forwards" db:"forwards"`
-	IsDelete   bool      `json:"-"  db:"is_delete"`
-	CreateAT   time.Time `json:"createAT"  db:"createAT"`
+	ArtworkId  string `json:"artworkId"  db:"artwork_id"`
+	Title      string `json:"title"  db:"title"`
+	UserId     string `json:"userId"  db:"user_id"`
+	PicCount   uint8  `json:"picCount" db:"pic_count"`
+	Cover      string `json:"cover" db:"cover"`
+	Zone       string `json:"zone"  db:"zone"`
+	WhoSee     string `json:"whoSee"  db:"whoSee"`
+	Adults     bool   `json:"adults"  db:"adults"`
+	ComSetting string `json:"comSetting" db:"comment"`
+	Copyright  string `json:"copyright" db:"-"` // 和 license.copyRight 相同 兼容旧版本客户端
+	ArtLicense `json:"license"`
+	Likes      int        `json:"likes" db:"likes"`
+	Views      int        `json:"views" db:"views"`
+	Comments   int        `json:"comments" db:"comments"`
+	Collects   int        `json:"collects" db:"collects"`
+	Forwards   int        `json:"forwards" db:"forwards"`
+	Downloads  int        `json:"downloads" db:"downloads"`
+	IsDelete   bool       `json:"-"  db:"is_delete"`
+	State      uint8      `json:"state"  db:"state"`
+	EditAt     *time.Time `json:"editAt" db:"edit_at"` // 作者选择显示的最近更新时间
+	CreateAT   time.Time  `json:"createAT"  db:"createAT"`
 }
 
 // ArtworkCount 作品统计信息
@@ -126,17 +130,19 @@ type ShowArtworkInfo struct {
 
 // ArtworkPicture 作品对应的图片信息
 type ArtworkPicture struct {
-	FileName string `json:"fileName"  db:"filename"`
-	Sort     uint8  `json:"sort"  db:"sort"`
-	Size     uint   `json:"size" db:"size"`
-	Width    uint16 `json:"width" db:"width"`
-	Height   uint16 `json:"height" db:"height"`
+	FileName string         `json:"fileName"  db:"filename" bson:"fileName"`
+	Sort     uint8          `json:"sort"  db:"sort" bson:"sort"`
+	Size     uint           `json:"size" db:"size" bson:"size"`
+	Width    uint16         `json:"width" db:"width" bson:"width"`
+	Height   uint16         `json:"height" db:"height" bson:"height"`
+	Palette  []PictureColor `json:"palette" db:"-" bson:"palette,omitempty"` // 主色 按占比从大到小
 }
 
 // AuthorOtherArtwork 作者的其他作品信息
 type AuthorOtherArtwork struct {
 	ArtworkId string `json:"artworkId"  db:"artwork_id"`
 	Cover     string `json:"cover" db:"cover"`
+	Adults    bool   `json:"adults" db:"adults"`
 }
 
 // UserArtworkInteract 用户与作品的互动信息
@@ -157,6 +163,8 @@ type QueryChanelType struct {
 	Zone   string `form:"zone" binding:"required"`
 	Sort   string `form:"sort" binding:"oneof=new hot"`
 	Page   int    `form:"page" binding:"gt=0"`
+	LicenseFilter
+	ColorFilter
 }
 
 type ArtIdAndUid struct {
@@ -183,9 +191,57 @@ type HotArtworkData struct {
 	FirstPic  string `json:"firstPic" db:"first_pic"`
 	Width     uint16 `json:"width" db:"width"`
 	Height    uint16 `json:"height" db:"height"`
+	Adults    bool   `json:"adults" db:"adults"`
 	IsLike    bool   `json:"isLike"`
 }
 
+// 作品可以选择的授权协议
+const (
+	LicenseOwner  = "OWNER" // 保留所有权利
+	LicenseBY     = "BY"
+	LicenseBYSA   = "BY-SA"
+	LicenseBYND   = "BY-ND"
+	LicenseBYNC   = "BY-NC"
+	LicenseBYNCSA = "BY-NC-SA"
+	LicenseBYNCND = "BY-NC-ND"
+	LicenseCC0    = "CC0" // 放弃所有权利
+)
+
+// ArtLicense 作品的授权协议和额外的使用许可
+type ArtLicense struct {
+	CopyRight      string `json:"copyRight" db:"copyright" bson:"copyright" binding:"required"`
+	AllowReference bool   `json:"allowReference" db:"allow_reference" bson:"allow_reference"` // 允许作为商业约稿的参考
+	AllowRepost    bool   `json:"allowRepost" db:"allow_repost" bson:"allow_repost"`          // 允许注明出处后转载
+	AllowAITrain   bool   `json:"allowAiTrain" db:"allow_ai_train" bson:"allow_ai_train"`     // 允许用于AI训练
+}
+
+// LicenseFilter 浏览和搜索时按授权筛选作品
+type LicenseFilter struct {
+	License string `form:"license"`
+	Allow   string `form:"allow" binding:"omitempty,oneof=reference repost aiTrain"`
+}
+
+// CacheKey 筛选条件拼接到缓存key 没有筛选时为空
+func (f LicenseFilter) CacheKey() string {
+	if f.License == "" && f.Allow == "" {
+		return ""
+	}
+	return "&" + f.License + "&" + f.Allow
+}
+
+// ColorFilter 浏览时按颜色筛选作品 颜色为十六进制
+type ColorFilter struct {
+	Color string `form:"color"`
+}
+
+// ColorKey 筛选颜色拼接到缓存key 没有筛选时为空
+func (f ColorFilter) ColorKey() string {
+	if f.Color == "" {
+		return ""
+	}
+	return "&c" + f.Color
+}
+
 // UpdateArtInfo 更新作品的信息
 type UpdateArtInfo struct {
 	ArtworkId   string   `json:"artworkId" binding:"required"`
@@ -196,7 +252,8 @@ type UpdateArtInfo struct {
 	WhoSee      string   `json:"whoSee" binding:"required"`
 	Adult       bool     `json:"adult"`
 	Comment     string   `json:"comment" binding:"oneof=close public onlyFans"`
-	CopyRight   string   `json:"copyRight" binding:"required"`
+	ArtLicense
+	ShowUpdate bool `json:"showUpdate"` // 是否向观看者显示已更新
 }
 
 // ArtIntro 作品介绍
@@ -225,3 +282,31 @@ type ArtworkCover struct {
 type ZoneIndex struct {
 	Zone int `form:"type" binding:"min=0,max=8"`
 }
+
+// DownloadQuery 下载作品原图
+type DownloadQuery struct {
+	ArtworkId string `form:"artid" binding:"required,numeric,gt=0"`
+	FileName  string `form:"pic" binding:"required"`
+	Watermark bool   `form:"watermark"`
+}
+
+// DownloadTokenQuery 通过下载链接下载原图
+type DownloadTokenQuery struct {
+	Token string `form:"token" binding:"required,len=32,alphanum"`
+}
+
+// DownloadToken 下载链接中 token 对应的文件 只能使用一次
+type DownloadToken struct {
+	ArtworkId string `json:"artworkId"`
+	Key       string `json:"key"`
+}
+
+// ArtworkDownloadInfo 下载时需要验证的作品信息
+type ArtworkDownloadInfo struct {
+	ArtworkId string `db:"artwork_id"`
+	UserId    string `db:"user_id"`
+	UserName  string `db:"username"`
+	WhoSee    string `db:"whoSee"`
+	Adults    bool   `db:"adults"`
+	ArtLicense
+}
@@ -25,9 +25,17 @@ type Comment struct {
 	Sore          int64     `json:"sore"`
 	IsLike        bool      `json:"isLike"`
 	IsDelete      bool      `json:"isDelete"  bson:"is_delete"`
+	IsTop         bool      `json:"isTop"  bson:"is_top"`   // 是否被作者置顶
+	IsEdit        bool      `json:"isEdit"  bson:"is_edit"` // 是否修改过
+	EditAt        time.Time `json:"-"  bson:"edit_at"`      // 最后修改时间
 	CreateAT      time.Time `json:"createAT"  bson:"createAt"`
 }
 
+// HotScore 评论热度 点赞数和回复数加权
+func (c Comment) HotScore() int {
+	return c.Likes + CommentHotReplyWeight*c.RootCount
+}
+
 type Comments []Comment
 
 func (comments Comments) GetAllCId() []string {
@@ -44,6 +52,7 @@ type VerifyGetCommentQuery struct {
 	LastCid string `form:"cid" binding:"required"`
 	Sore    string `form:"sore" binding:"required"`
 	Type    string `form:"type" binding:"oneof=aw tr"`
+	Sort    string `form:"sort" binding:"omitempty,oneof=new hot"` // 默认按时间 hot 按热度
 }
 
 // VerifyGetCommentReply 验证获取单评论里子评论的参数
@@ -104,3 +113,38 @@ type PostCommentLike struct {
 type DeleteComment struct {
 	CId int64 `json:"cid,string" binding:"required"` // 删除哪个评论
 }
+
+// CommentEditTime 发布评论后可以修改的时间
+const CommentEditTime = 15 * time.Minute
+
+// EditComment 修改评论
+type EditComment struct {
+	CId  int64  `json:"cid,string" binding:"required"` // 修改哪个评论
+	Text string `json:"text" binding:"required"`
+}
+
+// CommentHistory 评论修改前的版本
+type CommentHistory struct {
+	CId      int64     `json:"cId,string" bson:"cid"`
+	Text     string    `json:"text" bson:"content"`
+	CreateAT time.Time `json:"createAT" bson:"createAt"` // 这个版本的发布时间
+}
+
+type QueryCommentHistory struct {
+	CId int64 `form:"cid" binding:"required,gt=0"`
+}
+
+// CommentHotReplyWeight 计算评论热度时 一条回复相当于的点赞数
+const CommentHotReplyWeight = 2
+
+// CommentHotRank 根评论的热度排名
+type CommentHotRank struct {
+	CId int64 `bson:"cid"`
+	Hot int   `bson:"hot"`
+}
+
+// PostCommentTop 置顶或取消置顶评论
+type PostCommentTop struct {
+	CId      int64 `json:"cid,string" binding:"required"`
+	IsCancel bool  `json:"isCancel"`
+}
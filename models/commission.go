@@ -17,46 +17,110 @@ type AcceptPlan struct {
 	Payment     string    `json:"payment" bson:"payment" binding:"oneof=1 2 3 4 5"`                          // 支付方式
 	Finish      int       `json:"finish"  bson:"finish" binding:"required,max=100"`                          // 完成时间天数
 	FileType    []string  `json:"fileType"  bson:"file_type" binding:"required"`                             // 提供文件类型
+	MaxQueue    int       `json:"maxQueue" bson:"max_queue" binding:"min=0,max=20"`                          // 同时进行的约稿上限 0 不限制
+	WaitList    bool      `json:"waitList" bson:"wait_list"`                                                 // 满额时是否允许排队
+	Blackout    []Period  `json:"blackout" bson:"blackout" binding:"max=10"`                                 // 不接稿的日期
 	Status      bool      `json:"status" bson:"-"`                                                           // 0 正常 1 不接单
 	IsDelete    bool      `json:"isDelete" bson:"is_delete"`
 	UpdateAt    time.Time `json:"updateAt" bson:"updateAt"`
 	CreateAt    time.Time `json:"createAt" bson:"createAt"`
 }
 
+// Period 日期区间 格式 2006-01-02
+type Period struct {
+	Start string `json:"start" bson:"start"`
+	End   string `json:"end" bson:"end"`
+}
+
+// InBlackout 某天是否在不接稿日期内
+func (p AcceptPlan) InBlackout(day time.Time) bool {
+	date := day.Format("2006-01-02")
+	for _, b := range p.Blackout {
+		if date >= b.Start && date <= b.End {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFull 当前进行中的约稿数是否已满
+func (p AcceptPlan) IsFull(queue int) bool {
+	return p.MaxQueue > 0 && queue >= p.MaxQueue
+}
+
+// EstimateStart 根据进行中的约稿数和不接稿日期 估计新约稿的开始日期
+func (p AcceptPlan) EstimateStart(queue int) time.Time {
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	// 满额时 每过 Finish 天空出 MaxQueue 个位置
+	if p.IsFull(queue) {
+		rounds := (queue-p.MaxQueue)/p.MaxQueue + 1
+		start = start.AddDate(0, 0, rounds*p.Finish)
+	}
+	// 跳过不接稿日期 区间可能首尾相连 最多跳过全部区间
+	for i := 0; i <= len(p.Blackout); i++ {
+		moved := false
+		date := start.Format("2006-01-02")
+		for _, b := range p.Blackout {
+			if date >= b.Start && date <= b.End {
+				end, err := time.ParseInLocation("2006-01-02", b.End, now.Location())
+				if err != nil {
+					continue
+				}
+				start = end.AddDate(0, 0, 1)
+				moved = true
+				break
+			}
+		}
+		if !moved {
+			break
+		}
+	}
+	return start
+}
+
 // UserAcceptPlan 某一用户接稿方案
 type UserAcceptPlan struct {
-	UserName string `json:"userName"`
-	Avatar   string `json:"avatar"`
-	VTag     string `json:"vTag"`
-	VStatus  int8   `json:"vStatus"`
+	UserName  string `json:"userName"`
+	Avatar    string `json:"avatar"`
+	VTag      string `json:"vTag"`
+	VStatus   int8   `json:"vStatus"`
+	Queue     int    `json:"queue"`     // 进行中的约稿数
+	StartDate string `json:"startDate"` // 估计开始日期
 	AcceptPlan
 }
 
 // InvitePlan 约稿邀请计划
 type InvitePlan struct {
-	InviteId    int64      `json:"inviteId,string" bson:"invite_id"`
-	UserId      string     `json:"userId"  bson:"user_id"`
-	PlanId      int64      `json:"planId,string"  bson:"plan_id" binding:"required"`
-	ArtistId    string     `json:"artistId"  bson:"artist_id" binding:"numeric,max=10"`
-	Category    string     `json:"category" bson:"category" binding:"min=2,max=6"`
-	Name        string     `json:"name" bson:"name" binding:"required,max=25"`
-	Intro       string     `json:"intro" bson:"intro" binding:"required,max=650,min=10"`
-	FileList    []PicsType `json:"fileList" bson:"file_list"`
-	Purpose     string     `json:"purpose" bson:"purpose" binding:"required,max=20"`
-	FileSize    string     `json:"fileSize" bson:"file_size" binding:"oneof=game weibo pc a4 diy square"`
-	Color       string     `json:"color" bson:"color" binding:"oneof=RGB CMYK"`
-	FileType    []string   `json:"fileType" bson:"file_type" binding:"required"`
-	Date        string     `json:"date" bson:"date" binding:"required"`
-	Money       string     `json:"money" bson:"money" binding:"required,max=20"`
-	Payment     string     `json:"payment" bson:"payment" binding:"oneof=1 2 3 4 5"`
-	OpenOption  string     `json:"openOption" bson:"open_option" binding:"oneof=open appoint privacy"`
-	ContactType string     `json:"contactType,omitempty" bson:"contact_type" binding:"oneof=QQ Phone WeChat"`
-	Contact     string     `json:"contact,omitempty" bson:"contact" binding:"required,max=25"`
-	Status      int8       `json:"status" bson:"status"` // 0 未接受 1 沟通中  2 创作中 3 已完成  -1 画师/约稿人关闭(待接稿阶段和沟通阶段关闭) -2 退出（创作中散伙））
-	FeedBack    uint8      `json:"feedBack" bson:"feedBack " binding:"oneof=0 3 5 7 15"`
-	IsDelete    bool       `json:"isDelete" bson:"is_delete"`
-	UpdateAt    time.Time  `json:"updateAt" bson:"updateAt"`
-	CreateAt    time.Time  `json:"createAt" bson:"createAt"`
+	InviteId    int64            `json:"inviteId,string" bson:"invite_id"`
+	UserId      string           `json:"userId"  bson:"user_id"`
+	PlanId      int64            `json:"planId,string"  bson:"plan_id" binding:"required"`
+	ArtistId    string           `json:"artistId"  bson:"artist_id" binding:"numeric,max=10"`
+	Category    string           `json:"category" bson:"category" binding:"min=2,max=6"`
+	Name        string           `json:"name" bson:"name" binding:"required,max=25"`
+	Intro       string           `json:"intro" bson:"intro" binding:"required,max=650,min=10"`
+	FileList    []PicsType       `json:"fileList" bson:"file_list"`
+	Purpose     string           `json:"purpose" bson:"purpose" binding:"required,max=20"`
+	FileSize    string           `json:"fileSize" bson:"file_size" binding:"oneof=game weibo pc a4 diy square"`
+	Color       string           `json:"color" bson:"color" binding:"oneof=RGB CMYK"`
+	FileType    []string         `json:"fileType" bson:"file_type" binding:"required"`
+	Date        string           `json:"date" bson:"date" binding:"required"`
+	Money       string           `json:"money" bson:"money" binding:"required,max=20"`
+	Payment     string           `json:"payment" bson:"payment" binding:"oneof=1 2 3 4 5"`
+	OpenOption  string           `json:"openOption" bson:"open_option" binding:"oneof=open appoint privacy"`
+	ContactType string           `json:"contactType,omitempty" bson:"contact_type" binding:"oneof=QQ Phone WeChat"`
+	Contact     string           `json:"contact,omitempty" bson:"contact" binding:"required,max=25"`
+	DisputeId   int64            `json:"disputeId,string" bson:"dispute_id,omitempty"` // 最近一次纠纷id
+	Waitlist    bool             `json:"waitlist" bson:"waitlist"`                     // 画师满额时进入排队
+	Quote       *CommissionQuote `json:"quote" bson:"quote,omitempty"`                 // 双方接受的报价快照
+	NeedQuote   bool             `json:"-" bson:"need_quote"`                          // 报价功能上线后创建的约稿 开始创作前必须接受报价
+	Agreement   *PlanAgreement   `json:"agreement" bson:"agreement,omitempty"`         // 开始创作时生成的约稿协议
+	PlanTerms   *PlanTerms       `json:"-" bson:"plan_terms,omitempty"`                // 发出邀请时画师接稿方案的条款快照
+	Status      int8             `json:"status" bson:"status"`                         // 0 未接受 1 沟通中  2 创作中 3 已完成  -1 画师/约稿人关闭(待接稿阶段和沟通阶段关闭) -2 退出（创作中散伙））
+	FeedBack    uint8            `json:"feedBack" bson:"feedBack " binding:"oneof=0 3 5 7 15"`
+	IsDelete    bool             `json:"isDelete" bson:"is_delete"`
+	UpdateAt    time.Time        `json:"updateAt" bson:"updateAt"`
+	CreateAt    time.Time        `json:"createAt" bson:"createAt"`
 }
 
 // InvitePlanCard 简略的显示约稿计划
@@ -69,6 +133,7 @@ type InvitePlanCard struct {
 	Date         string     `json:"date" bson:"date"`
 	Status       int8       `json:"status" bson:"status"` // 0 未接受 1 沟通中  2 创作中 3 已完成  -1 画师/约稿人关闭(待接稿阶段和沟通阶段关闭) -2 退出（创作中中散伙）
 	NeedEvaluate bool       `json:"needEvaluate"`
+	Waitlist     bool       `json:"waitlist" bson:"waitlist"` // 排队中
 	Money        string     `json:"money" bson:"money"`
 	Category     string     `json:"category" bson:"category"`
 	UpdateAt     time.Time  `json:"updateAt" bson:"updateAt"`
@@ -100,6 +165,7 @@ type UserScoreInfo struct {
 	Avatar   string  `json:"avatar" db:"avatar_name" `
 	Score    float64 `json:"score" db:"rating"`
 	Finish   uint16  `json:"finish" db:"finish"`
+	Dispute  uint16  `json:"dispute" db:"dispute_lose"` // 纠纷中被判定为过错方的次数
 }
 
 // Evaluate 评价数据结构
@@ -116,6 +182,7 @@ type Evaluate struct {
 	Status     int8      `json:"status"  binding:"oneof=3 -2"`                 // 修改状态
 	Only       bool      `json:"only,omitempty"`
 	Score      float64   `json:"score" db:"total_rating" db:"score"`
+	Reply      string    `json:"reply" db:"reply"` // 被评价人的回复
 	IsDelete   bool      `json:"isDelete" db:"is_delete"`
 	CreateAt   time.Time `json:"createAt" db:"createAT"`
 }
@@ -140,12 +207,91 @@ type EvaluateShow struct {
 	Text       string  `json:"text" db:"text"`
 	CreateAt   string  `json:"createAt" db:"createAT"`
 	Score      float64 `json:"score" db:"total_rating"`
+	Reply      string  `json:"reply" db:"reply"`      // 被评价人的回复
+	ReplyAt    *string `json:"replyAt" db:"reply_at"` // 回复时间 没有回复为 null
+}
+
+// EvaluateReply 被评价人回复评价
+type EvaluateReply struct {
+	EvaluateId int64  `json:"evaluateId,string" binding:"required"`
+	Text       string `json:"text" binding:"required,max=150"`
+}
+
+// EvaluateReplyInfo 回复评价时需要校验的评价信息
+type EvaluateReplyInfo struct {
+	InviteId int64      `db:"invite_id"`
+	Receiver string     `db:"receiver"`
+	IsDelete bool       `db:"is_delete"`
+	ReplyAt  *time.Time `db:"reply_at"`
 }
 
 type PlanUserInfo struct {
-	ArtistId  string `bson:"artist_id" `
-	Sender    string `bson:"user_id"`
-	NowStatus int8   `bson:"status"`
+	ArtistId  string           `bson:"artist_id" `
+	Sender    string           `bson:"user_id"`
+	NowStatus int8             `bson:"status"`
+	Quote     *CommissionQuote `bson:"quote"`      // 已接受的报价
+	NeedQuote bool             `bson:"need_quote"` // 上线前的约稿没有这个字段 不需要报价
+}
+
+// CommissionQuote 约稿报价
+type CommissionQuote struct {
+	QuoteId  int64     `json:"quoteId,string" bson:"quote_id"`
+	InviteId int64     `json:"inviteId,string" bson:"invite_id" binding:"required"`
+	Proposer string    `json:"proposer" bson:"proposer"`                     // 报价人
+	Money    string    `json:"money" bson:"money" binding:"required,max=20"` // 价格
+	Deadline string    `json:"deadline" bson:"deadline" binding:"required"`  // 截稿日期
+	Change   int       `json:"change" bson:"change" binding:"min=0,max=5"`   // 可修改次数
+	Remark   string    `json:"remark" bson:"remark" binding:"max=200"`       // 报价说明
+	Status   int8      `json:"status" bson:"status"`                         // 0 等待回应 1 已接受 -1 已拒绝/被新报价取代
+	UpdateAt time.Time `json:"updateAt" bson:"updateAt"`
+	CreateAt time.Time `json:"createAt" bson:"createAt"`
+}
+
+// PlanTerms 接稿方案中写进约稿协议的条款 画师之后修改接稿方案不影响已经发出的邀请
+type PlanTerms struct {
+	Change int    `bson:"change"` // 可修改次数
+	Refuse string `bson:"refuse"` // 不接类型
+}
+
+// PlanAgreement 约稿协议文件信息
+type PlanAgreement struct {
+	FileName string         `json:"fileName" bson:"file_name"`
+	Hash     string         `json:"hash" bson:"hash"`          // 协议条款的 sha256 打印在协议中
+	FileHash string         `json:"fileHash" bson:"file_hash"` // pdf 文件的 sha256
+	Terms    AgreementTerms `json:"-" bson:"terms"`            // 生成协议时的条款 用于校验
+	CreateAt time.Time      `json:"createAt" bson:"createAt"`
+}
+
+// AgreementVerifyQuery 校验约稿协议
+type AgreementVerifyQuery struct {
+	InviteId int64  `form:"id" binding:"required"`
+	Hash     string `form:"hash" binding:"required,len=64,hexadecimal"` // 协议页脚的 sha256 或 pdf 文件的 sha256
+}
+
+// AgreementTerms 约稿协议条款 用于生成协议和计算hash
+type AgreementTerms struct {
+	InviteId   int64    `json:"inviteId,string"`
+	Name       string   `json:"name"`
+	SenderId   string   `json:"senderId"`
+	SenderName string   `json:"senderName"`
+	ArtistId   string   `json:"artistId"`
+	ArtistName string   `json:"artistName"`
+	Category   string   `json:"category"`
+	Purpose    string   `json:"purpose"`  // 用途
+	FileSize   string   `json:"fileSize"` // 尺寸
+	Color      string   `json:"color"`    // 颜色模式
+	FileType   []string `json:"fileType"` // 源文件类型
+	Deadline   string   `json:"deadline"` // 截稿日期
+	Money      string   `json:"money"`    // 价格
+	Change     int      `json:"change"`   // 可修改次数
+	Refuse     string   `json:"refuse"`   // 画师不接的类型
+	SignDate   string   `json:"signDate"` // 协议生成日期
+}
+
+// QuoteReply 回应报价
+type QuoteReply struct {
+	QuoteId int64 `json:"quoteId,string" binding:"required"`
+	Accept  bool  `json:"accept"` // true 接受 false 拒绝
 }
 
 type PlanContact struct {
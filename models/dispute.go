@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// CommissionDispute 约稿纠纷
+type CommissionDispute struct {
+	DisputeId  int64            `json:"disputeId,string" bson:"dispute_id"`
+	InviteId   int64            `json:"inviteId,string" bson:"invite_id"`
+	Sponsor    string           `json:"sponsor" bson:"sponsor"`       // 发起纠纷的用户
+	Respondent string           `json:"respondent" bson:"respondent"` // 被申诉的用户
+	Evidence   DisputeEvidence  `json:"evidence" bson:"evidence"`     // 发起人提供的证据
+	Response   *DisputeEvidence `json:"response" bson:"response"`     // 被申诉人的回应
+	Decision   *DisputeDecision `json:"decision" bson:"decision"`     // 管理员裁决
+	Status     int8             `json:"status" bson:"status"`         // 0 等待回应 1 已回应等待裁决 2 已裁决
+	UpdateAt   time.Time        `json:"updateAt" bson:"updateAt"`
+	CreateAt   time.Time        `json:"createAt" bson:"createAt"`
+}
+
+// DisputeEvidence 纠纷证据 文字和上传的文件
+type DisputeEvidence struct {
+	Text     string     `json:"text" bson:"text"`
+	FileList []PicsType `json:"fileList" bson:"file_list"`
+	CreateAt time.Time  `json:"createAt" bson:"createAt"`
+}
+
+// DisputePost 发起纠纷或者回应纠纷上传的数据
+type DisputePost struct {
+	InviteId  int64      `json:"inviteId,string"`  // 发起纠纷时必填
+	DisputeId int64      `json:"disputeId,string"` // 回应纠纷时必填
+	Text      string     `json:"text" binding:"required,max=500,min=10"`
+	FileList  []PicsType `json:"fileList" binding:"max=9"`
+}
+
+// DisputeDecision 管理员对纠纷的裁决
+type DisputeDecision struct {
+	DisputeId    int64      `json:"disputeId,string" bson:"-" binding:"required"`
+	Fault        string     `json:"fault" bson:"fault" binding:"oneof=artist sender both none"` // 过错方
+	Refund       uint32     `json:"refund" bson:"refund" binding:"max=100000000"`               // 退款金额 单位分 0 为不退款
+	RefundStatus int8       `json:"refundStatus" bson:"refund_status"`                          // 退款状态 平台没有账本 由管理员线下退款后标记
+	RefundAt     *time.Time `json:"refundAt" bson:"refund_at,omitempty"`                        // 标记退款完成的时间
+	Remark       string     `json:"remark" bson:"remark" binding:"required,max=300"`            // 裁决说明
+	AdminId      string     `json:"-" bson:"admin_id"`
+	CreateAt     time.Time  `json:"createAt" bson:"createAt"`
+}
+
+// 裁决的退款状态
+const (
+	RefundNone int8 = iota // 不需要退款
+	RefundWait             // 等待退款
+	RefundDone             // 已退款
+)
+
+// DisputeRefund 管理员标记纠纷退款完成
+type DisputeRefund struct {
+	DisputeId int64 `json:"disputeId,string" binding:"required"`
+}
+
+// DisputeQuery 查询纠纷
+type DisputeQuery struct {
+	DisputeId int64 `form:"id" binding:"required"`
+}
+
+// DisputeListQuery 管理员查询纠纷列表
+type DisputeListQuery struct {
+	NextId *int64 `form:"next" binding:"required"`
+	Status int8   `form:"status" binding:"oneof=0 1 2"`
+}
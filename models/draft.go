@@ -0,0 +1,109 @@
+package models
+
+import "time"
+
+// 草稿状态
+const (
+	DraftStatusSave       = 0 // 草稿
+	DraftStatusSchedule   = 1 // 等待定时发布
+	DraftStatusPublishing = 2 // 发布中
+)
+
+// ArtworkDraft 作品草稿 发布时用草稿id作为作品id
+type ArtworkDraft struct {
+	DraftId     int64      `json:"draftId,string" bson:"draft_id"`
+	UserId      string     `json:"userId" bson:"user_id"`
+	Title       string     `json:"title" bson:"title"`
+	Description string     `json:"description" bson:"description"`
+	FileList    []PicsType `json:"fileList" bson:"file_list"`
+	FirstPic    string     `json:"firstPic" bson:"first_pic"`
+	Tags        []string   `json:"tags" bson:"tags"`
+	Zone        string     `json:"zone" bson:"zone"`
+	WhoSee      string     `json:"whoSee" bson:"whoSee"`
+	Adults      bool       `json:"adult" bson:"adults"`
+	Cover       string     `json:"cover" bson:"cover"`
+	Comment     string     `json:"comment" bson:"comment"`
+	ArtLicense  `bson:",inline"`
+	Device      string     `json:"device" bson:"device"`
+	PublishAt   *time.Time `json:"publishAt" bson:"publish_at"` // 定时发布时间 为空是普通草稿
+	Status      uint8      `json:"status" bson:"status"`
+	FailMsg     string     `json:"failMsg" bson:"fail_msg"` // 定时发布失败的原因
+	UpdateAt    time.Time  `json:"updateAt" bson:"updateAt"`
+	CreateAt    time.Time  `json:"createAt" bson:"createAt"`
+}
+
+// CallBackArtworkDraft 保存草稿时上传的数据
+type CallBackArtworkDraft struct {
+	CallBackArtworkInfo
+	DraftId   int64      `json:"draftId,string"` // 为0时新建草稿
+	PublishAt *time.Time `json:"publishAt"`
+}
+
+// DraftQuery 查询一个草稿
+type DraftQuery struct {
+	DraftId int64 `form:"id" json:"draftId,string" binding:"required"`
+}
+
+// DraftListQuery 查询草稿列表
+type DraftListQuery struct {
+	Page int `form:"page" binding:"gt=0"`
+}
+
+// NewArtworkDraft 用验证后的作品信息生成草稿
+func NewArtworkDraft(info *SaveArtworkInfo, publishAt *time.Time) ArtworkDraft {
+	fileList := make([]PicsType, 0, len(info.FileList))
+	for _, file := range info.FileList {
+		fileList = append(fileList, *file)
+	}
+
+	var status uint8 = DraftStatusSave
+	if publishAt != nil {
+		status = DraftStatusSchedule
+	}
+
+	return ArtworkDraft{
+		DraftId:     info.ArtworkId,
+		UserId:      info.UserId,
+		Title:       info.Title,
+		Description: info.Description,
+		FileList:    fileList,
+		FirstPic:    info.FirstPic,
+		Tags:        info.Tags,
+		Zone:        info.Zone,
+		WhoSee:      info.WhoSee,
+		Adults:      info.Adults,
+		Cover:       info.Cover,
+		Comment:     info.Comment,
+		ArtLicense:  info.ArtLicense,
+		Device:      info.Device,
+		PublishAt:   publishAt,
+		Status:      status,
+		UpdateAt:    time.Now(),
+		CreateAt:    time.Now(),
+	}
+}
+
+// ArtworkInfo 草稿转换成需要保存的作品信息
+func (d ArtworkDraft) ArtworkInfo() *SaveArtworkInfo {
+	fileList := make([]*PicsType, 0, len(d.FileList))
+	for i := range d.FileList {
+		fileList = append(fileList, &d.FileList[i])
+	}
+
+	return &SaveArtworkInfo{
+		ArtworkId:   d.DraftId,
+		UserId:      d.UserId,
+		Title:       d.Title,
+		Description: d.Description,
+		FileList:    fileList,
+		FirstPic:    d.FirstPic,
+		Tags:        d.Tags,
+		Zone:        d.Zone,
+		WhoSee:      d.WhoSee,
+		Adults:      d.Adults,
+		Cover:       d.Cover,
+		Comment:     d.Comment,
+		ArtLicense:  d.ArtLicense,
+		Device:      d.Device,
+	}
+}
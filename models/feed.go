@@ -2,10 +2,10 @@ package models
 
 // MongoFeed 的数据结构
 type MongoFeed struct {
-	AcceptId string `bson:"accept_id"`
-	MsgID    int64  `bson:"msg_id"`
-	SendId   string `bson:"send_id"`
-	Type     string `bson:"type"`
+	AcceptId string `bson:"accept_id" db:"accept_id"`
+	MsgID    int64  `bson:"msg_id" db:"msg_id"`
+	SendId   string `bson:"send_id" db:"send_id"`
+	Type     string `bson:"type" db:"type"`
 }
 
 // VerifyNextId 翻页需要携带的 msgId
@@ -7,4 +7,5 @@ type PostReport struct {
 	Describe   string `json:"describe"`                     // 其他描述
 	PostUser   string `json:"postUser"`                     // 提交人
 	Defendant  string `json:"defendant" binding:"required"` // 被告人
+	IsReply    bool   `json:"isReply"`                      // 举报的是评价的回复 仅 ev 类型有效
 }
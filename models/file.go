@@ -7,12 +7,20 @@ import (
 
 // PicsType 上传的文件信息
 type PicsType struct {
-	FileName string `json:"fileName" bson:"fileName"`
-	Sort     uint8  `json:"sort" bson:"sort"`
-	Width    uint16 `json:"width" bson:"width"`
-	Height   uint16 `json:"height" bson:"height"`
-	Mimetype string `json:"-" bson:"type"`
-	Size     int64  `json:"-" bson:"size"`
+	FileName string         `json:"fileName" bson:"fileName"`
+	Sort     uint8          `json:"sort" bson:"sort"`
+	Width    uint16         `json:"width" bson:"width"`
+	Height   uint16         `json:"height" bson:"height"`
+	Mimetype string         `json:"-" bson:"type"`
+	Size     int64          `json:"-" bson:"size"`
+	Palette  []PictureColor `json:"-" bson:"-"` // 保存作品时提取的主色
+}
+
+// PictureColor 图片的主色
+type PictureColor struct {
+	FileName string `json:"-" db:"filename" bson:"-"`
+	Color    string `json:"color" db:"color" bson:"color"`    // 十六进制 不带 #
+	Weight   uint8  `json:"weight" db:"weight" bson:"weight"` // 在图片中的占比 0-100
 }
 
 // FileTableInfo  数据库中图片的文件信息
@@ -46,8 +54,8 @@ type CallBackArtworkInfo struct {
 	Adult       bool         `json:"adult" `
 	Cover       string       `json:"cover" binding:"required"`
 	Comment     string       `json:"comment" binding:"oneof=public onlyFans close"` //评论权限
-	CopyRight   string       `json:"copyRight" binding:"required"`
-	Device      string       `json:"device" binding:"oneof=PC WeChat"`
+	ArtLicense
+	Device string `json:"device" binding:"oneof=PC WeChat"`
 }
 
 // CallBackTrendInfo 上传动态时上传的数据格式
@@ -58,6 +66,7 @@ type CallBackTrendInfo struct {
 	Topic       TopicType           `json:"topic" binding:"required"`
 	Comment     string              `json:"comment" binding:"oneof=public onlyFans close"` //评论权限
 	ForwardInfo CallBackForwardInfo `json:"forwardInfo" binding:"required"`
+	Poll        *PostPoll           `json:"poll"`
 }
 
 type CallBackForwardInfo struct {
@@ -86,8 +95,10 @@ type SaveArtworkInfo struct {
 	Adults      bool
 	Cover       string
 	Comment     string
-	CopyRight   string
-	Device      string
+	ArtLicense
+	Device  string
+	State   uint8            // 与他人作品重复时进入审核
+	Similar []SimilarPicture // 相似的他人作品图片
 }
 
 // SaveTrendInfo 保存trend需要的信息
@@ -97,6 +108,7 @@ type SaveTrendInfo struct {
 	Text        string      `json:"text" bson:"text"`
 	ForwardInfo ForwardInfo `json:"ForwardInfo" bson:"forward_info"`
 	Topic       TopicType   `json:"topic" bson:"topic"`
+	Hashtags    []Hashtag   `json:"hashtags" bson:"hashtags,omitempty"` // 正文中的 #话题#
 	Pics        []PicsType  `json:"pics" bson:"pics"`
 	Comment     string      `json:"comment" bson:"comment"` //评论权限
 	Count       TrendCount  `json:"count" bson:"count"`
@@ -106,4 +118,5 @@ type SaveTrendInfo struct {
 	CreateAt    time.Time   `json:"createAt" bson:"createAt"`
 	State       uint8       `json:"state" bson:"state"`
 	IsDelete    bool        `json:"isDelete" bson:"is_delete"`
+	Poll        *TrendPoll  `json:"poll" bson:"poll,omitempty"`
 }
@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// CollectFolder 收藏夹
+type CollectFolder struct {
+	FolderId  int64     `json:"folderId,string" bson:"folder_id"`
+	UserId    string    `json:"userId" bson:"user_id"`
+	Name      string    `json:"name" bson:"name"`
+	Intro     string    `json:"intro" bson:"intro"`
+	CoverArt  string    `json:"coverArt" bson:"cover_art"` // 作为封面的作品id 为空时用最新收藏的作品
+	Cover     string    `json:"cover" bson:"-"`            // 封面图片
+	IsPublic  bool      `json:"isPublic" bson:"is_public"`
+	IsDefault bool      `json:"isDefault" bson:"is_default"` // 默认收藏夹 新收藏的作品都放在这里
+	Count     int       `json:"count" bson:"count"`
+	UpdateAt  time.Time `json:"updateAt" bson:"updateAt"`
+	CreateAt  time.Time `json:"createAt" bson:"createAt"`
+}
+
+// CollectFolderItem 收藏夹里的作品
+type CollectFolderItem struct {
+	FolderId int64     `bson:"folder_id"`
+	UserId   string    `bson:"user_id"`
+	MsgId    string    `bson:"msg_id"`
+	AuthorId string    `bson:"author_id"`
+	Sort     int64     `bson:"sort"` // 排序值 越大越靠前 默认是加入时间
+	CreateAt time.Time `bson:"createAt"`
+}
+
+// PostCollectFolder 创建/编辑收藏夹上传的数据
+type PostCollectFolder struct {
+	FolderId int64  `json:"folderId,string"`
+	Name     string `json:"name" binding:"required,max=20"`
+	Intro    string `json:"intro" binding:"max=200"`
+	CoverArt string `json:"coverArt" binding:"omitempty,numeric"`
+	IsPublic bool   `json:"isPublic"`
+}
+
+// MoveFolderArtwork 在收藏夹之间移动或复制作品
+type MoveFolderArtwork struct {
+	FromId int64    `json:"fromId,string" binding:"required"`
+	ToId   int64    `json:"toId,string" binding:"required,nefield=FromId"`
+	MsgIds []string `json:"msgIds" binding:"required,min=1,max=100,dive,numeric"`
+	IsCopy bool     `json:"isCopy"` // true 复制 false 移动
+}
+
+// SortFolderArtwork 调整收藏夹中作品的顺序
+type SortFolderArtwork struct {
+	FolderId int64    `json:"folderId,string" binding:"required"`
+	MsgIds   []string `json:"msgIds" binding:"required,min=2,max=100,dive,numeric"` // 按新的顺序排列
+}
+
+// FolderQuery 查询收藏夹中的作品
+type FolderQuery struct {
+	FolderId int64 `form:"id" binding:"required"`
+	Page     int   `form:"page" binding:"gt=0"`
+}
+
+// DeleteFolder 删除收藏夹
+type DeleteFolder struct {
+	FolderId int64 `json:"folderId,string" binding:"required"`
+}
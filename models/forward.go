@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// ForwardPageSize 转发列表每页数量
+const ForwardPageSize = 20
+
+// ForwardListQuery 查询作品或动态的转发列表
+type ForwardListQuery struct {
+	MsgId  int64  `form:"id" binding:"required"`
+	Type   string `form:"type" binding:"oneof=aw tr"`
+	NextId int64  `form:"next"`
+}
+
+// ForwardItem 转发列表中的一条转发 Text 为转发时说的话
+type ForwardItem struct {
+	TrendId     int64       `json:"trendId,string" bson:"trend_id"`
+	UserId      string      `json:"userId" bson:"user_id"`
+	UserName    string      `json:"userName" bson:"-"`
+	Avatar      string      `json:"avatar" bson:"-"`
+	VTag        string      `json:"vTag" bson:"-"`
+	VStatus     int8        `json:"vStatus" bson:"-"`
+	Text        string      `json:"text" bson:"text"`
+	ForwardInfo ForwardInfo `json:"forwardInfo" bson:"forward_info"`
+	CreateAt    time.Time   `json:"createAt" bson:"createAt"`
+}
+
+// ForwardNotify 被转发的提醒 通知的 targetId 为被转发的作品或动态
+type ForwardNotify struct {
+	TrendId  int64                `json:"trendId,string" bson:"trend_id"` // 转发产生的动态id
+	Text     string               `json:"text" bson:"-"`                  // 转发时说的话
+	IsDelete bool                 `json:"isDelete" bson:"-"`              // 转发的动态是否删除
+	Target   NotifyArtOrTrendInfo `json:"target" bson:"-"`                // 被转发的作品或动态
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// HomeZoneList 首页展示的分区 下标对应 hot:zone:%s
+var HomeZoneList = []string{"插画", "同人", "古风", "日系", "场景", "原画", "头像", "Q版", "自设/OC"}
+
+// HotInteract 用于计算热度的点赞或收藏
+type HotInteract struct {
+	UserId   string `bson:"user_id"`
+	MsgId    string `bson:"msg_id"`
+	Type     string `bson:"type"`
+	AuthorId string `bson:"author_id"`
+}
+
+// HotCandidate 参与计算热度的作品或动态
+type HotCandidate struct {
+	MsgId    string    `db:"artwork_id"`
+	Type     string    `db:"-"`
+	UserId   string    `db:"user_id"`
+	Zone     string    `db:"zone"`
+	Comments int       `db:"comments"`
+	Forwards int       `db:"forwards"`
+	Views    int       `db:"views"`
+	CreateAt time.Time `db:"createAT"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// InterestMaxCount 每种类型最多关注的数量
+const InterestMaxCount = 200
+
+// InterestFeedLimit 关注标签和话题的 feed 每页数量
+const InterestFeedLimit = 30
+
+// PostInterest 关注或取消关注标签 话题
+type PostInterest struct {
+	Type     string `json:"type" binding:"required,oneof=tag topic"`
+	TargetId string `json:"id" binding:"required,numeric,gt=0"`
+	IsCancel bool   `json:"isCancel"`
+}
+
+// InterestQuery 查询用户关注的标签或话题
+type InterestQuery struct {
+	UId  string `form:"uid" binding:"required,numeric,gt=0"`
+	Type string `form:"type" binding:"required,oneof=tag topic"`
+}
+
+// InterestItem 关注的标签或话题
+type InterestItem struct {
+	TargetId string    `json:"id" db:"target_id"`
+	Name     string    `json:"name" db:"name"`
+	CreateAt time.Time `json:"createAt" db:"createAt"`
+}
@@ -0,0 +1,50 @@
+package models
+
+// 关注的用户在feed中屏蔽的内容
+const (
+	MuteNone    = "none"    // 不屏蔽
+	MuteTrend   = "trend"   // 屏蔽动态和转发 保留作品
+	MuteForward = "forward" // 只屏蔽转发
+	MuteAll     = "all"     // 屏蔽全部
+)
+
+// MuteWordMaxCount 最多屏蔽的关键词数量
+const MuteWordMaxCount = 100
+
+// PostFocusMute 设置关注用户的屏蔽
+type PostFocusMute struct {
+	FocusId string `json:"focusId" binding:"required,numeric,gt=0"`
+	Mute    string `json:"mute" binding:"required,oneof=none trend forward all"`
+}
+
+// PostMuteWord 添加或删除屏蔽关键词
+type PostMuteWord struct {
+	Word     string `json:"word" binding:"required,max=20"`
+	IsCancel bool   `json:"isCancel"`
+}
+
+// FocusMute 关注用户的屏蔽设置
+type FocusMute struct {
+	FocusId string `json:"focusId" db:"focus_id"`
+	Mute    string `json:"mute" db:"mute"`
+}
+
+// UserMute 用户所有的屏蔽设置
+type UserMute struct {
+	Users []FocusMute `json:"users"`
+	Words []string    `json:"words"`
+}
+
+// IsEmpty 没有任何屏蔽
+func (u UserMute) IsEmpty() bool {
+	return len(u.Users) == 0 && len(u.Words) == 0
+}
+
+// UserMap 屏蔽的用户id 对应屏蔽类型
+func (u UserMute) UserMap() map[string]string {
+	res := make(map[string]string, len(u.Users))
+	for _, user := range u.Users {
+		res[user.FocusId] = user.Mute
+	}
+	return res
+}
@@ -27,10 +27,11 @@ type NotifyUnreadCount struct {
 	Comment    int `json:"comment" db:"comment"`
 	Commission int `json:"commission" db:"commission"`
 	At         int `json:"at" db:"at"`
+	Forward    int `json:"forward" db:"forward"`
 }
 
 func (n NotifyUnreadCount) Count() int {
-	return n.At + n.Comment + n.Follow + n.Collect + n.Like + n.Commission
+	return n.At + n.Comment + n.Follow + n.Collect + n.Like + n.Commission + n.Forward
 }
 
 // NotifyArtOrTrendInfo 通知中展示的作品和动态需要的信息
@@ -88,6 +89,18 @@ type NotifyConfig struct {
 	Follow  uint8 `json:"follow" db:"follow" binding:"oneof=0 1 2"`
 	Message uint8 `json:"message" db:"message" binding:"oneof=0 1 2"`
 	At      uint8 `json:"at" db:"at" binding:"oneof=0 1 2"`
+	Forward uint8 `json:"forward" db:"forward" binding:"oneof=0 1 2"`
+}
+
+// UpdateNotifyConfig 修改通知配置 forward 是后来加的 旧客户端不传时保留原来的设置
+type UpdateNotifyConfig struct {
+	Comment uint8  `json:"comment" binding:"oneof=0 1 2"`
+	Like    uint8  `json:"like" binding:"oneof=0 1 2"`
+	Collect uint8  `json:"collect" binding:"oneof=0 1 2"`
+	Follow  uint8  `json:"follow" binding:"oneof=0 1 2"`
+	Message uint8  `json:"message" binding:"oneof=0 1 2"`
+	At      uint8  `json:"at" binding:"oneof=0 1 2"`
+	Forward *uint8 `json:"forward" binding:"omitempty,oneof=0 1 2"`
 }
 
 // CommissionNotify 约稿通知
@@ -96,6 +109,14 @@ type CommissionNotify struct {
 	Content    NotifyCommissionInfo `json:"content" bson:"content"`
 }
 
+// CommissionRemind 约稿纠纷 报价等提醒需要的数据
+type CommissionRemind struct {
+	InviteId  int64
+	Action    string   // 提醒类型 dispute 纠纷 quote 报价
+	Status    int8     // 纠纷或报价的状态
+	Receivers []string // 接收通知的用户
+}
+
 type NotifyCommissionInfo struct {
 	InviteId int64  `json:"inviteId,string" bson:"invite_id,omitempty"`
 	Owner    string `json:"owner" bson:"-"`
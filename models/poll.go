@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// 投票的限制
+const (
+	PollMinOptions = 2  // 最少选项数
+	PollMaxOptions = 6  // 最多选项数
+	PollMinMinutes = 5  // 最短投票时间
+	PollMaxDays    = 30 // 最长投票天数
+)
+
+// PostPoll 发布动态时附带的投票
+type PostPoll struct {
+	Options  []string  `json:"options" binding:"min=2,max=6,dive,max=20"`
+	Multiple bool      `json:"multiple"`
+	EndAt    time.Time `json:"endAt" binding:"required"`
+}
+
+// TrendPoll 动态的投票
+// 票数以缓存为准 定时落盘到 MongoDB
+type TrendPoll struct {
+	Options    []PollOption `json:"options" bson:"options"`
+	Multiple   bool         `json:"multiple" bson:"multiple"`
+	EndAt      time.Time    `json:"endAt" bson:"end_at"`
+	Voters     int          `json:"voters" bson:"voters"`
+	IsEnd      bool         `json:"isEnd" bson:"-"`
+	ShowResult bool         `json:"showResult" bson:"-"` // 投票后或结束后才显示每个选项的票数
+	Choices    []int        `json:"choices" bson:"-"`    // 登录用户选择的选项下标
+}
+
+// PollOption 投票选项
+type PollOption struct {
+	Text  string `json:"text" bson:"text"`
+	Votes int    `json:"votes" bson:"votes"`
+}
+
+// PostPollVote 给动态的投票投票
+type PostPollVote struct {
+	TrendId int64 `json:"trendId,string" binding:"required"`
+	Choices []int `json:"choices" binding:"min=1,max=6,dive,min=0,max=5"`
+}
+
+// PollVote 用户的投票记录 每个用户每个投票只有一条
+type PollVote struct {
+	TrendId  int64     `bson:"trend_id"`
+	UserId   string    `bson:"user_id"`
+	Choices  []int     `bson:"choices"`
+	CreateAt time.Time `bson:"createAt"`
+}
+
+// TrendPollInfo 投票时需要的动态信息
+type TrendPollInfo struct {
+	UserId string     `bson:"user_id"`
+	WhoSee string     `bson:"whoSee"`
+	Poll   *TrendPoll `bson:"poll"`
+}
+
+// PollCount 需要落盘的投票统计
+type PollCount struct {
+	TrendId int64
+	Votes   map[int]int // 选项下标 -> 票数
+	Voters  int
+}
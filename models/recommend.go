@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// RecommendWindowDays 计算推荐时使用最近多少天的点赞收藏
+const RecommendWindowDays = 90
+
+// RecommendUserItemLimit 每个用户参与计算的最近互动作品数
+const RecommendUserItemLimit = 100
+
+// RecommendSimilarLimit 每个作品保留的相似作品数
+const RecommendSimilarLimit = 50
+
+// RecommendArtworkLimit 每个用户保存的推荐作品数
+const RecommendArtworkLimit = 300
+
+// RecommendUserLimit 每个用户保存的推荐用户数
+const RecommendUserLimit = 60
+
+// RecommendPageSize 推荐作品每页数量
+const RecommendPageSize = 30
+
+// RecommendInteract 用户对作品的点赞或收藏
+type RecommendInteract struct {
+	UserId   string    `bson:"user_id"`
+	MsgId    string    `bson:"msg_id"`
+	AuthorId string    `bson:"author_id"`
+	Time     time.Time `bson:"updateAt"`
+}
+
+// FocusEdge 关注关系
+type FocusEdge struct {
+	UserId  string `db:"user_id"`
+	FocusId string `db:"focus_id"`
+}
+
+// RecommendQuery 获取推荐作品的参数
+type RecommendQuery struct {
+	Page int64 `form:"page" binding:"min=0,max=9"`
+}
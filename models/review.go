@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// 作品状态 对应 artwork 表的 state
+const (
+	ArtStateNormal    uint8 = 0 // 正常
+	ArtStateReview    uint8 = 1 // 等待审核 只有作者自己可见
+	ArtStateTakenDown uint8 = 2 // 已下架
+)
+
+// SimilarDistance 图片哈希的汉明距离不超过这个值 认为是同一张图
+const SimilarDistance = 5
+
+// PictureHash 作品图片的感知哈希索引
+type PictureHash struct {
+	ArtworkId int64     `bson:"artwork_id"`
+	UserId    string    `bson:"user_id"`
+	FileName  string    `bson:"filename"`
+	Hash      int64     `bson:"hash"`
+	Bands     []string  `bson:"bands"`
+	CreateAt  time.Time `bson:"createAt"`
+}
+
+// SimilarPicture 上传的图片和已有作品图片相似
+type SimilarPicture struct {
+	FileName  string `json:"fileName" bson:"filename"`
+	ArtworkId string `json:"artworkId" bson:"artwork_id"`
+	UserId    string `json:"userId" bson:"user_id"`
+	MatchFile string `json:"matchFile" bson:"match_file"`
+	Distance  int    `json:"distance" bson:"distance"`
+}
+
+// ArtworkReview 等待管理员审核的作品
+type ArtworkReview struct {
+	ArtworkId int64            `json:"artworkId,string" bson:"artwork_id"`
+	UserId    string           `json:"userId" bson:"user_id"`
+	Reason    string           `json:"reason" bson:"reason"` // duplicate 与他人作品重复
+	Similar   []SimilarPicture `json:"similar" bson:"similar"`
+	Status    int8             `json:"status" bson:"status"` // 0 等待审核 1 通过 2 下架
+	AdminId   string           `json:"-" bson:"admin_id"`
+	Remark    string           `json:"remark" bson:"remark"`
+	UpdateAt  time.Time        `json:"updateAt" bson:"updateAt"`
+	CreateAt  time.Time        `json:"createAt" bson:"createAt"`
+}
+
+// ReviewListQuery 管理员查询审核列表
+type ReviewListQuery struct {
+	NextId *int64 `form:"next" binding:"required"`
+	Status int8   `form:"status" binding:"oneof=0 1 2"`
+}
+
+// ReviewDecision 管理员的审核结果
+type ReviewDecision struct {
+	ArtworkId int64  `json:"artworkId,string" binding:"required"`
+	Decision  string `json:"decision" binding:"oneof=pass takedown"`
+	Remark    string `json:"remark" binding:"max=300"`
+}
+
+// ArtworkState 作品当前状态
+type ArtworkState struct {
+	ArtworkId string `db:"artwork_id"`
+	State     uint8  `db:"state"`
+}
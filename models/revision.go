@@ -0,0 +1,116 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// ArtworkSnapshot 作品某个版本的内容
+type ArtworkSnapshot struct {
+	Title    string           `json:"title" bson:"title"`
+	Intro    string           `json:"intro" bson:"intro"`
+	Zone     string           `json:"zone" bson:"zone"`
+	WhoSee   string           `json:"whoSee" bson:"whoSee"`
+	Adults   bool             `json:"adults" bson:"adults"`
+	Comment  string           `json:"comment" bson:"comment"`
+	License  ArtLicense       `json:"license" bson:"license"`
+	Cover    string           `json:"cover" bson:"cover"`
+	FirstPic string           `json:"firstPic" bson:"first_pic"`
+	Tags     []string         `json:"tags" bson:"tags"`
+	Pictures []ArtworkPicture `json:"pictures" bson:"pictures"`
+}
+
+// ArtworkRevision 作品的修改记录 版本0是第一次修改前的原始内容
+type ArtworkRevision struct {
+	ArtworkId  string           `json:"artworkId" bson:"artwork_id"`
+	UserId     string           `json:"userId" bson:"user_id"`
+	Version    int              `json:"version" bson:"version"`
+	Snapshot   ArtworkSnapshot  `json:"snapshot" bson:"snapshot"`
+	Changes    []RevisionChange `json:"changes" bson:"changes"` // 和上一个版本相比修改的内容
+	ShowUpdate bool             `json:"showUpdate" bson:"show_update"`
+	CreateAt   time.Time        `json:"createAt" bson:"createAt"`
+}
+
+// RevisionChange 一个字段的修改
+type RevisionChange struct {
+	Field string      `json:"field" bson:"field"`
+	Old   interface{} `json:"old" bson:"old"`
+	New   interface{} `json:"new" bson:"new"`
+}
+
+// ReplaceArtPicture 替换 添加 调整作品图片时上传的数据
+type ReplaceArtPicture struct {
+	ArtworkId  string       `json:"artworkId" binding:"required"`
+	FileList   []uploadFile `json:"fileList" binding:"required,min=1,max=15"`
+	Cover      string       `json:"cover" binding:"required"`
+	ShowUpdate bool         `json:"showUpdate"` // 是否向观看者显示已更新
+}
+
+// RevisionQuery 查询作品修改记录
+type RevisionQuery struct {
+	ArtworkId string `form:"id" binding:"required"`
+	From      int    `form:"from" binding:"min=0"`
+	To        int    `form:"to" binding:"min=0"`
+}
+
+// DiffSnapshot 比较两个版本 返回修改过的字段
+func DiffSnapshot(old, new ArtworkSnapshot) (changes []RevisionChange) {
+	changes = make([]RevisionChange, 0)
+	add := func(field string, o, n interface{}) {
+		changes = append(changes, RevisionChange{Field: field, Old: o, New: n})
+	}
+
+	if old.Title != new.Title {
+		add("title", old.Title, new.Title)
+	}
+	if old.Intro != new.Intro {
+		add("intro", old.Intro, new.Intro)
+	}
+	if old.Zone != new.Zone {
+		add("zone", old.Zone, new.Zone)
+	}
+	if old.WhoSee != new.WhoSee {
+		add("whoSee", old.WhoSee, new.WhoSee)
+	}
+	if old.Adults != new.Adults {
+		add("adults", old.Adults, new.Adults)
+	}
+	if old.Comment != new.Comment {
+		add("comment", old.Comment, new.Comment)
+	}
+	if old.License != new.License {
+		add("license", old.License, new.License)
+	}
+	if old.Cover != new.Cover {
+		add("cover", old.Cover, new.Cover)
+	}
+
+	oldTags := append([]string(nil), old.Tags...)
+	newTags := append([]string(nil), new.Tags...)
+	sort.Strings(oldTags)
+	sort.Strings(newTags)
+	if strings.Join(oldTags, ",") != strings.Join(newTags, ",") {
+		add("tags", old.Tags, new.Tags)
+	}
+
+	// 图片按排序比较 替换 增删和调整顺序都算修改
+	oldPics := pictureNames(old.Pictures)
+	newPics := pictureNames(new.Pictures)
+	if strings.Join(oldPics, ",") != strings.Join(newPics, ",") {
+		add("pictures", oldPics, newPics)
+	}
+	return
+}
+
+// pictureNames 按排序返回图片文件名
+func pictureNames(pics []ArtworkPicture) []string {
+	sorted := append([]ArtworkPicture(nil), pics...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Sort < sorted[j].Sort })
+
+	names := make([]string, 0, len(sorted))
+	for _, pic := range sorted {
+		names = append(names, pic.FileName)
+	}
+	return names
+}
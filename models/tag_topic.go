@@ -6,6 +6,8 @@ type TagQueryArtParam struct {
 	TagId   string `form:"query" binding:"required,numeric,gt=0"`
 	Sort    string `form:"sort" binding:"oneof=score time"`
 	Page    uint16 `form:"page" binding:"required,lte=10"`
+	LicenseFilter
+	ColorFilter
 }
 
 // TagQueryParam tag相关查询
@@ -22,9 +24,39 @@ type ArtworkTag struct {
 
 // TagRelevant tag相关tags 数据
 type TagRelevant struct {
-	TagName string       `json:"tagName" db:"tag_name"`
-	Tags    []ArtworkTag `json:"tags"`
-	Total   int          `json:"total" db:"art_count"`
+	TagName  string       `json:"tagName" db:"tag_name"`
+	Tags     []ArtworkTag `json:"tags"`
+	Total    int          `json:"total" db:"art_count"`
+	MergedTo int64        `json:"mergedTo,string,omitempty" db:"merged_to"` // 已合并的标签 客户端跳转到新标签
+	Parent   *ArtworkTag  `json:"parent,omitempty"`
+	Children []ArtworkTag `json:"children"`
+	Aliases  []string     `json:"aliases"`
+}
+
+// TagAliasParam 添加或删除标签别名
+type TagAliasParam struct {
+	TagId string `json:"tagId" binding:"omitempty,numeric,gt=0"`
+	Alias string `json:"alias" binding:"required,max=25"`
+}
+
+// TagParentParam 设置父标签 ParentId 为 0 时取消
+type TagParentParam struct {
+	TagId    string `json:"tagId" binding:"required,numeric,gt=0"`
+	ParentId string `json:"parentId" binding:"required,numeric,nefield=TagId"`
+}
+
+// TagMergeParam 把 FromId 标签合并到 ToId
+type TagMergeParam struct {
+	FromId string `json:"fromId" binding:"required,numeric,gt=0"`
+	ToId   string `json:"toId" binding:"required,numeric,gt=0,nefield=FromId"`
+}
+
+// TagMergeResult 合并标签的结果
+type TagMergeResult struct {
+	From       ArtworkTag `json:"from"`
+	To         ArtworkTag `json:"to"`
+	ArtworkIds []string   `json:"-"` // 受影响的作品 需要删除作品缓存
+	Moved      int        `json:"moved"`
 }
 
 // SearchTagResult 标签搜索的结果
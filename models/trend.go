@@ -16,9 +16,13 @@ type TrendInfo struct {
 	Pics        []PicsType        `json:"pics" bson:"pics"`
 	Count       TrendCount        `json:"count" bson:"count"`
 	Intro       string            `json:"intro" bson:"text" db:"description"`
+	Title       string            `json:"title,omitempty" bson:"-" db:"title"`
+	Adults      bool              `json:"adults" bson:"-" db:"adults"`
 	Type        string            `json:"type"`
 	ForwardInfo ForwardInfo       `json:"forwardInfo" bson:"forward_info"`
 	Topic       TopicType         `json:"topic" bson:"topic"`
+	Hashtags    []Hashtag         `json:"hashtags,omitempty" bson:"hashtags,omitempty"`
+	Poll        *TrendPoll        `json:"poll,omitempty" bson:"poll,omitempty"`
 	Interact    UserTrendInteract `json:"interact"`
 	Comment     string            `json:"comment" bson:"comment" db:"comment"`
 	WhoSee      string            `json:"whoSee" bson:"whoSee" db:"whoSee"`
@@ -29,8 +33,9 @@ type TrendInfo struct {
 }
 
 type ForwardInfo struct {
-	Id   int64  `json:"id,string" bson:"id"`
-	Type string `json:"type" bson:"type"`
+	Id    int64    `json:"id,string" bson:"id"`
+	Type  string   `json:"type" bson:"type"`
+	Chain []string `json:"chain,omitempty" bson:"chain,omitempty"` // 转发的转发 指向原作 按顺序保存中间经过的动态id
 }
 
 // UserTrendInteract 用户与动态的互动信息
@@ -44,6 +49,16 @@ type TopicType struct {
 	Text    string `json:"text" bson:"text" db:"text"`
 }
 
+// TrendHashtagMax 一条动态正文中最多识别的 #话题# 个数
+const TrendHashtagMax = 10
+
+// Hashtag 动态正文中的 #话题# 位置按 UTF-16 计算 和 js 字符串下标一致 包含两侧的 # 用于渲染话题链接
+type Hashtag struct {
+	TopicType `bson:",inline"`
+	Start     int `json:"start" bson:"start"`
+	End       int `json:"end" bson:"end"`
+}
+
 type SearchTopicType struct {
 	TopicId string `json:"topicId"  db:"topic_id"`
 	Text    string `json:"text" db:"text"`
@@ -22,9 +22,30 @@ func artworkRouter(router *gin.Engine) {
 	rNoAuth.GET("/show", hm.HandleQueryArtworkShow, hm.VerifyQuerySign, ctl.GetChannelArtwork, cm.BatchSetBasicArt)
 	//首页下拉加载分区作品
 	rNoAuth.GET("/hot/zone", hm.HandleQueryZone, ctl.GetHomePageZone, cm.BatchSetArtViews)
+	//为用户推荐的作品
+	rNoAuth.GET("/recommend", hm.VerifyRecommendQuery, ctl.GetRecommendArtwork, cm.BatchSetBasicArt)
 
+	// 下载作品原图
+	rMustAuth.GET("/download", hm.VerifyArtworkDownload, ctl.DownloadArtwork)
+	// 打开下载链接 链接本身就是凭证 不需要登录
+	rNoAuth.GET("/download/file", ctl.DownloadArtworkFile)
 	// 更新作品资料
 	rMustAuth.PATCH("/info", hm.HandleUpdateArtInfo, ctl.UpdateArtInfo)
+	// 替换 添加 调整作品图片
+	rMustAuth.PATCH("/picture", hm.HandleReplaceArtPicture, ctl.ReplaceArtPicture)
+	// 作品修改记录
+	rMustAuth.GET("/revision", hm.VerifyArtworkRevision, ctl.GetArtworkRevisions)
+	// 比较作品的两个版本
+	rMustAuth.GET("/revision/diff", hm.VerifyArtworkRevision, ctl.GetRevisionDiff)
+	// 草稿列表
+	rMustAuth.GET("/draft/list", hm.VerifyDraftList, ctl.GetDraftList)
+	// 获取 删除草稿
+	rMustAuth.GET("/draft", hm.VerifyDraftOwner, ctl.GetDraft)
+	rMustAuth.DELETE("/draft", hm.VerifyDraftOwner, ctl.DeleteDraft)
 	//删除作品
 	rMustAuth.DELETE("/delete", hm.HandleOneArtwork, ctl.DeleteArtwork)
+	// 管理员获取疑似重复作品的审核列表
+	rMustAuth.GET("/review/list", hm.VerifyAdmin, hm.VerifyReviewListQuery, ctl.GetArtworkReviewList)
+	// 管理员审核作品 通过后推送给粉丝
+	rMustAuth.PATCH("/review", hm.VerifyAdmin, hm.VerifyReviewDecision, ctl.SaveReviewDecision, ctl.SetFeed)
 }
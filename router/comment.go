@@ -16,6 +16,8 @@ func commentRouter(router *gin.Engine) {
 	rNoAuth.GET("/reply", hm.HandleRootCommentReply, cm.GetChildrenComment, ctl.GetCommentReply, cm.SetChildrenComment)
 	// 获取单条根评论详情
 	rNoAuth.GET("/root/one", hm.HandleOneRootComment, ctl.GetOneRootComment)
+	// 获取评论的修改历史
+	rNoAuth.GET("/history", hm.HandleCommentHistory, ctl.GetCommentHistory)
 
 	//评论点赞接口
 	rMustAuth.POST("/like", hm.HandleCommentLike, ctl.SaveCommentLike, ctl.SetLikeCommentNotify, cm.SetUserNotifyConfig)
@@ -23,4 +25,8 @@ func commentRouter(router *gin.Engine) {
 	rMustAuth.POST("", hm.HandlePostComment, ctl.SaveComment, cm.AddComment, ctl.SetCommentNotify, cm.SetUserNotifyConfig)
 	//删除评论接口
 	rMustAuth.DELETE("", hm.HandleCommentDelete, ctl.DelComment)
+	//修改评论接口
+	rMustAuth.PUT("", hm.HandleCommentEdit, ctl.EditComment)
+	//作者置顶评论接口
+	rMustAuth.POST("/top", hm.HandleCommentTop, ctl.SetCommentTop)
 }
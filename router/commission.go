@@ -21,14 +21,39 @@ func commissionRouter(router *gin.Engine) {
 	// 查看用户发出的邀请
 	rMustAuth.GET("/send", hm.VerifyQueryPlan, ctl.GetSendPlan)
 	// 计划下一步
-	rMustAuth.PATCH("/next", hm.VerifyPlanNext, ctl.HandlePlanNext, ctl.SetCommissionNotify, cm.DelInviteStatus)
+	rMustAuth.PATCH("/next", hm.VerifyPlanNext, ctl.HandlePlanNext, ctl.CreatePlanAgreement, ctl.SetCommissionNotify, cm.DelInviteStatus)
+	// 下载约稿协议
+	rMustAuth.GET("/agreement", hm.VerifyPlanQueryId, ctl.GetPlanAgreement, cm.DelInviteStatus)
+	// 校验约稿协议是否被修改
+	rMustAuth.GET("/agreement/verify", hm.VerifyAgreementQuery, ctl.VerifyPlanAgreement)
 	// 查看双方联系方式
 	rMustAuth.GET("/contact", hm.VerifyPlanQueryId, ctl.GetUserContact)
 	// 发布约稿评价
 	rMustAuth.POST("/evaluate", hm.VerifyEvaluate, ctl.SaveEvaluate, ctl.SetCommissionNotify, cm.DelInviteStatus)
+	// 回复/修改回复收到的评价
+	rMustAuth.PUT("/evaluate/reply", hm.VerifyEvaluateReply, ctl.SaveEvaluateReply, cm.DelInviteStatus)
 	// 更新约稿状态
 	rMustAuth.PATCH("/status", hm.VerifyCommissionStatus, ctl.UpdateCommissionStatus, cm.DelCommissionStatus)
 
+	// 报价/还价
+	rMustAuth.POST("/quote", hm.VerifyPostQuote, ctl.SaveQuote, ctl.SetCommissionRemind)
+	// 接受/拒绝报价
+	rMustAuth.PATCH("/quote", hm.VerifyReplyQuote, ctl.ReplyQuote, ctl.SetCommissionRemind, cm.DelInviteStatus)
+	// 查看约稿报价记录
+	rMustAuth.GET("/quote", hm.VerifyPlanQueryId, ctl.GetInviteQuotes)
+	// 发起约稿纠纷
+	rMustAuth.POST("/dispute", hm.VerifyPostDispute, ctl.SaveDispute, ctl.SetCommissionRemind, cm.DelDisputeCache)
+	// 回应约稿纠纷
+	rMustAuth.PATCH("/dispute/response", hm.VerifyDisputeResponse, ctl.SaveDisputeResponse, ctl.SetCommissionRemind)
+	// 查看纠纷详情
+	rMustAuth.GET("/dispute", hm.VerifyDisputeQuery, ctl.GetDispute)
+	// 管理员查看纠纷列表
+	rMustAuth.GET("/dispute/list", hm.VerifyAdmin, hm.VerifyDisputeListQuery, ctl.GetDisputeList)
+	// 管理员裁决纠纷
+	rMustAuth.PATCH("/dispute/decision", hm.VerifyAdmin, hm.VerifyDisputeDecision, ctl.SaveDisputeDecision, ctl.SetCommissionRemind, cm.DelDisputeCache)
+	// 管理员标记纠纷退款完成
+	rMustAuth.PATCH("/dispute/refund", hm.VerifyAdmin, hm.VerifyDisputeRefund, ctl.SaveDisputeRefund)
+
 	// 查看用户接稿方案
 	rNoAuth.GET("/plan", hm.VerifyQueryUserId, cm.GetAcceptPlan, ctl.GetAcceptPlan, cm.SetAcceptPlan)
 	// 查看用户收到的邀请
@@ -16,4 +16,6 @@ func feedRouter(router *gin.Engine) {
 	r.GET("/art", hm.HandleNextIdQuery, ctl.GetArtFeed, cm.BatchSetBasicArt)
 	// 获取所有类型的 feed
 	r.GET("/all", hm.HandleNextIdQuery, ctl.GetAllFeed, ctl.GetFeedTrend, cm.BatchSetTrend, cm.BatchSetArtViews)
+	// 获取关注的标签和话题的 feed
+	r.GET("/interest", hm.HandleNextIdQuery, ctl.GetInterestFeed, ctl.GetFeedTrend, cm.BatchSetTrend, cm.BatchSetArtViews)
 }
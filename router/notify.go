@@ -19,6 +19,8 @@ func notifyRouter(router *gin.Engine) {
 	rMustAuth.GET("/focus", hm.NotifyQuery, ctl.GetFocusNotify)
 	// 获取评论提醒
 	rMustAuth.GET("/comment", hm.NotifyQuery, ctl.GetCommentNotify, cm.BatchSetBasicArt)
+	// 获取被转发提醒
+	rMustAuth.GET("/forward", hm.NotifyQuery, ctl.GetForwardNotify, cm.BatchSetBasicArt)
 	// 获取约稿提醒
 	rMustAuth.GET("/commission", hm.NotifyQuery, ctl.GetCommission)
 	// 获取消息通知设置
@@ -23,6 +23,13 @@ func tagAndTopicRouter(router *gin.Engine) {
 	//精确搜索标签
 	tag.GET("/search", hm.HandleQueryTag, ctl.GetLikeNameTag)
 
+	// 标签管理 别名 父子关系 合并
+	tagAdmin := router.Group("/tag", hm.VerifyAuthMust, hm.VerifyAdmin)
+	tagAdmin.POST("/alias", hm.VerifyTagAlias, ctl.SaveTagAlias)
+	tagAdmin.DELETE("/alias", hm.VerifyTagAlias, ctl.DeleteTagAlias)
+	tagAdmin.PATCH("/parent", hm.VerifyTagParent, ctl.SetTagParent)
+	tagAdmin.POST("/merge", hm.VerifyTagMerge, ctl.MergeTag)
+
 	// 话题
 	topic := router.Group("/topic", hm.VerifyAuth)
 	//模糊查找 话题
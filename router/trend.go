@@ -21,8 +21,12 @@ func trendRouter(router *gin.Engine) {
 	rNoAuth.GET("/hot", ctl.GetHotTrend, ctl.GetFeedTrend, cm.BatchSetTrend, cm.BatchSetArtViews)
 	// 获取某个用户的动态
 	rNoAuth.GET("/user", hm.HandleUserTrendQuery, ctl.GetUserTrend, ctl.GetFeedTrend, cm.BatchSetTrend)
+	// 获取作品或动态的转发列表
+	rNoAuth.GET("/forwards", hm.VerifyForwardListQuery, ctl.GetForwardList)
 	// 删除一条动态
 	rMustAuth.DELETE("/delete", hm.HandleTrendQuery, hm.VerifyTrendOwner, ctl.DeleteTrend)
 	// 权限设置
 	rMustAuth.PATCH("/permission", hm.HandleTrendPermission, hm.VerifyTrendOwner, ctl.UpdateTrendPermission)
+	// 投票
+	rMustAuth.POST("/poll/vote", hm.VerifyPollVote, ctl.SavePollVote)
 }
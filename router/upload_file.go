@@ -19,8 +19,12 @@ func fileRouter(router *gin.Engine) {
 		saveRouter.POST("/avatar", hm.HandleAvatarInfo, ctl.SaveAvatarInfo, cm.DelUserProfile)
 		//保存artwork信息
 		saveRouter.POST("/artwork", hm.HandleArtworkInfo, ctl.SaveArtworkInfo, cm.SetUserAboutArtCache, ctl.SetFeed)
+		//保存作品草稿 带发布时间时定时发布
+		saveRouter.POST("/draft", hm.HandleArtworkDraft, ctl.SaveArtworkDraft)
+		//立即发布草稿
+		saveRouter.POST("/draft/publish", hm.VerifyDraftOwner, ctl.PublishDraft, cm.SetUserAboutArtCache, ctl.SetFeed)
 		//保存trend 信息
-		saveRouter.POST("/trend", hm.HandleTrendInfo, ctl.SaveTrendInfo, cm.SetAboutTrendCache, ctl.SetFeed)
+		saveRouter.POST("/trend", hm.HandleTrendInfo, ctl.SaveTrendInfo, cm.SetAboutTrendCache, ctl.SetForwardNotify, ctl.SetFeed)
 	}
 
 	// 删除banner 接口
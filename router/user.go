@@ -23,7 +23,7 @@ func userRouter(router *gin.Engine) {
 	rNoAuth.GET("/profile/collect", hm.VerifyUserAndPage, ctl.GetUserHomeCollect, cm.BatchSetBasicArt)
 
 	//推荐关注用户
-	rNoAuth.GET("/recommend", ctl.GetRecommendUser)
+	rNoAuth.GET("/recommend", ctl.GetRecommendUser, cm.SetUserBigCarCache)
 	//用户排名
 	rNoAuth.GET("/rank", hm.VerifyUserRankRequest, cm.GetUserRank, ctl.GetUserRank, cm.SetUserRank)
 	//查询用户的粉丝或关注
@@ -43,6 +43,22 @@ func userRouter(router *gin.Engine) {
 	//精确搜索关注的 用户
 	rMustAuth.GET("/focus/search", ctl.SearchOurFocusUser)
 
+	// 敏感内容展示偏好
+	rMustAuth.GET("/adult", ctl.GetAdultSetting)
+	rMustAuth.PATCH("/adult", hm.VerifyAdultPref, ctl.UpdateAdultSetting)
+
+	// 获取用户关注的标签 话题
+	rNoAuth.GET("/interest", hm.VerifyInterestQuery, ctl.GetUserInterest)
+	// 关注或取消关注标签 话题
+	rMustAuth.POST("/interest", hm.VerifyPostInterest, ctl.SaveUserInterest)
+
+	// feed 屏蔽设置
+	rMustAuth.GET("/mute", ctl.GetMuteSetting)
+	// 屏蔽关注用户的动态 转发或全部
+	rMustAuth.PATCH("/mute/focus", hm.VerifyFocusMute, ctl.UpdateFocusMute)
+	// 添加或删除屏蔽关键词
+	rMustAuth.POST("/mute/word", hm.VerifyMuteWord, ctl.SaveMuteWord)
+
 	// 获取邀请码
 	rMustAuth.GET("/invitation", ctl.GetUserInvitationCode)
 
@@ -51,6 +67,19 @@ func userRouter(router *gin.Engine) {
 	//收藏
 	rMustAuth.POST("/collect", hm.HandlePostInteract, ctl.SaveCollect, cm.SetCollectCount, ctl.SetLikeOrCollectNotify, cm.SetUserNotifyConfig)
 
+	// 收藏夹列表
+	rNoAuth.GET("/folder", hm.VerifyQueryUserId, ctl.GetUserFolders, cm.BatchSetBasicArt)
+	// 收藏夹中的作品
+	rNoAuth.GET("/folder/artwork", hm.VerifyFolderQuery, ctl.GetFolderArtwork, cm.BatchSetBasicArt)
+	// 创建 编辑 删除收藏夹
+	rMustAuth.POST("/folder", hm.VerifyPostFolder, ctl.SaveFolder)
+	rMustAuth.PATCH("/folder", hm.VerifyUpdateFolder, ctl.UpdateFolder)
+	rMustAuth.DELETE("/folder", hm.VerifyDeleteFolder, ctl.DeleteFolder)
+	// 在收藏夹之间移动或复制作品
+	rMustAuth.POST("/folder/artwork", hm.VerifyMoveFolderArtwork, ctl.MoveFolderArtwork)
+	// 调整收藏夹作品顺序
+	rMustAuth.PATCH("/folder/sort", hm.VerifySortFolderArtwork, ctl.SortFolderArtwork)
+
 	//全站用户展示
 	rNoAuth.GET("/show", hm.VerifyAllUserShow, hm.VerifyQuerySign, ctl.GetUserShow, cm.SetUserBigCarCache)
 }
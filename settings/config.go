@@ -30,6 +30,11 @@ type Config struct {
 	*SnowFlake      `mapstructure:"SnowFlake"`
 	*InvitationCode `mapstructure:"InvitationCode"`
 	*MiniProgram    `mapstructure:"MiniProgram"`
+	*Admin          `mapstructure:"Admin"`
+	*Agreement      `mapstructure:"Agreement"`
+	*Download       `mapstructure:"Download"`
+	*Feed           `mapstructure:"Feed"`
+	*HotScore       `mapstructure:"HotScore"`
 }
 
 type MySQLConfig struct {
@@ -98,6 +103,39 @@ type MiniProgram struct {
 	MiniAppSecret string `mapstructure:"AppSecret"`
 }
 
+type Admin struct {
+	AdminUsers []string `mapstructure:"Users"` // 管理员用户id
+}
+
+type Agreement struct {
+	AgreementFontPath string `mapstructure:"FONT_PATH"` // 生成约稿协议使用的中文 TrueType 字体
+}
+
+type Download struct {
+	WatermarkFontPath string `mapstructure:"WATERMARK_FONT_PATH"` // 水印使用的中文 TrueType 字体
+	ArtworkURL        string `mapstructure:"ARTWORK_URL"`         // 水印中的作品地址 %s 替换为作品id
+	DownloadExpire    int64  `mapstructure:"EXPIRE"`              // 下载链接的有效秒数
+}
+
+type Feed struct {
+	CelebrityFans int `mapstructure:"CELEBRITY_FANS"` // 粉丝数达到后不再推送到粉丝feed 读取时拉取 0 为全部推送
+}
+
+type HotScore struct {
+	HotInterval      int     `mapstructure:"INTERVAL"`       // 重新计算热门的间隔分钟
+	HotWindowDays    int     `mapstructure:"WINDOW_DAYS"`    // 只计算最近几天发布的作品和动态
+	HotGravity       float64 `mapstructure:"GRAVITY"`        // 时间衰减系数 越大旧内容下降越快
+	HotLikeWeight    float64 `mapstructure:"LIKE_WEIGHT"`    // 点赞权重
+	HotCollectWeight float64 `mapstructure:"COLLECT_WEIGHT"` // 收藏权重
+	HotCommentWeight float64 `mapstructure:"COMMENT_WEIGHT"` // 评论权重
+	HotForwardWeight float64 `mapstructure:"FORWARD_WEIGHT"` // 转发权重
+	HotViewWeight    float64 `mapstructure:"VIEW_WEIGHT"`    // 浏览量权重 浏览量取对数
+	HotLikerCap      int     `mapstructure:"LIKER_CAP"`      // 同一用户对同一作者的点赞 收藏最多计算几次
+	HotArtworkCount  int     `mapstructure:"ARTWORK_COUNT"`  // 热门作品数量
+	HotTrendCount    int     `mapstructure:"TREND_COUNT"`    // 热门动态数量
+	HotZoneCount     int     `mapstructure:"ZONE_COUNT"`     // 每个分区的热门作品数量
+}
+
 func ConfigInit() (err error) {
 	//viper.SetConfigName("config") // 指定配置文件名称（不需要带后缀）
 	//viper.AddConfigPath(".")   // 指定查找配置文件的路径（这里使用相对可执行文件.exe路径）
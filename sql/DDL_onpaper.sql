@@ -47,10 +47,15 @@ CREATE TABLE `artwork` (
   `whoSee` enum('public','onlyFans','privacy') CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'privacy' COMMENT '查看范围',
   `adults` tinyint unsigned NOT NULL DEFAULT '0' COMMENT '是否敏感内容',
   `comment` enum('public','onlyFans','close') CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'public' COMMENT '作品评论权限',
-  `copyright` enum('BY','BY-ND','BY-NC','BY-NC-ND','OWNER') CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'OWNER' COMMENT '作品版权',
+  `copyright` enum('BY','BY-SA','BY-ND','BY-NC','BY-NC-SA','BY-NC-ND','CC0','OWNER') CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'OWNER' COMMENT '作品授权协议',
+  `allow_reference` tinyint unsigned NOT NULL DEFAULT '0' COMMENT '允许作为商业约稿参考',
+  `allow_repost` tinyint unsigned NOT NULL DEFAULT '0' COMMENT '允许注明出处转载',
+  `allow_ai_train` tinyint unsigned NOT NULL DEFAULT '0' COMMENT '允许用于AI训练',
   `is_delete` tinyint unsigned NOT NULL DEFAULT '0' COMMENT '是否删除',
-  `state` tinyint unsigned NOT NULL DEFAULT '0' COMMENT '状态',
+  `state` tinyint unsigned NOT NULL DEFAULT '0' COMMENT '状态 0正常 1审核中 2已下架',
   `device` varchar(10) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'PC' COMMENT '上传设备',
+  `edit_at` timestamp NULL DEFAULT NULL COMMENT '显示给观看者的更新时间',
+  `publish_at` timestamp NULL DEFAULT NULL COMMENT '第一次公开的时间 为空时审核通过后推送feed',
   `createAT` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
   `updateAt` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
   PRIMARY KEY (`artwork_id`),
@@ -72,6 +77,7 @@ CREATE TABLE `artwork_count` (
   `comments` mediumint unsigned NOT NULL DEFAULT '0' COMMENT '评论个数',
   `forwards` mediumint unsigned NOT NULL DEFAULT '0' COMMENT '转发数',
   `views` int unsigned NOT NULL DEFAULT '0' COMMENT '浏览数',
+  `downloads` int unsigned NOT NULL DEFAULT '0' COMMENT '原图下载数',
   `score` decimal(10,2) NOT NULL DEFAULT '0.00' COMMENT '综合分数',
   `is_delete` tinyint unsigned DEFAULT '0' COMMENT '是否删除',
   `whoSee` enum('public','onlyFans','privacy') CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'privacy' COMMENT '查看范围',
@@ -103,6 +109,27 @@ CREATE TABLE `artwork_picture` (
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci ROW_FORMAT=DYNAMIC COMMENT='作品图片表'
 ;
 
+/******************************************/
+/*   DatabaseName = onpaper   */
+/*   TableName = artwork_color   */
+/******************************************/
+CREATE TABLE `artwork_color` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `artwork_id` bigint unsigned NOT NULL COMMENT '作品ID',
+  `filename` varchar(40) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '图片文件名',
+  `color` char(6) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '主色 十六进制',
+  `r` tinyint unsigned NOT NULL COMMENT '红',
+  `g` tinyint unsigned NOT NULL COMMENT '绿',
+  `b` tinyint unsigned NOT NULL COMMENT '蓝',
+  `bucket` smallint unsigned NOT NULL COMMENT '颜色分桶 每个通道取高3位',
+  `weight` tinyint unsigned NOT NULL COMMENT '在图片中的占比 0-100',
+  `createAt` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `bucket` (`bucket`,`artwork_id`),
+  KEY `artwork_id` (`artwork_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci ROW_FORMAT=DYNAMIC COMMENT='作品图片主色表'
+;
+
 /******************************************/
 /*   DatabaseName = onpaper   */
 /*   TableName = avatar   */
@@ -150,10 +177,25 @@ CREATE TABLE `commission_count` (
   `send_ing` int unsigned NOT NULL DEFAULT '0' COMMENT '发出的约稿_创作中个数',
   `send_finish` int unsigned NOT NULL DEFAULT '0' COMMENT '发出的约稿_完成个数',
   `send_close` int unsigned NOT NULL DEFAULT '0' COMMENT '发出的约稿_关闭个数',
+  `dispute_lose` int unsigned NOT NULL DEFAULT '0' COMMENT '纠纷中被判定为过错方的次数',
   PRIMARY KEY (`user_id`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci
 ;
 
+/******************************************/
+/*   DatabaseName = onpaper   */
+/*   TableName = commission_dispute_decision   */
+/******************************************/
+CREATE TABLE `commission_dispute_decision` (
+  `dispute_id` bigint unsigned NOT NULL COMMENT '纠纷id',
+  `fault` varchar(10) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '裁决的过错方 artist sender both none',
+  `refund_amount` int unsigned NOT NULL DEFAULT '0' COMMENT '退款金额 单位分',
+  `refund_status` tinyint unsigned NOT NULL DEFAULT '0' COMMENT '退款状态 0 不退款 1 等待退款 2 已退款',
+  `createAt` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`dispute_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='纠纷裁决 保证只生效一次'
+;
+
 /******************************************/
 /*   DatabaseName = onpaper   */
 /*   TableName = commission_evaluate   */
@@ -169,11 +211,15 @@ CREATE TABLE `commission_evaluate` (
   `rate_2` tinyint unsigned NOT NULL DEFAULT '5' COMMENT '沟通能力/反馈及时',
   `rate_3` tinyint unsigned NOT NULL DEFAULT '5' COMMENT '作品质量/需求明确',
   `total_rating` decimal(3,2) unsigned NOT NULL DEFAULT '5.00' COMMENT '总得分',
+  `reply` varchar(150) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT '被评价人回复',
+  `reply_at` timestamp NULL DEFAULT NULL COMMENT '首次回复时间',
+  `weight` decimal(3,2) unsigned NOT NULL DEFAULT '1.00' COMMENT '评价权重 纠纷裁决后调整',
   `is_delete` tinyint unsigned NOT NULL DEFAULT '0' COMMENT '是否删除',
   `updateAt` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
   `createAT` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
   PRIMARY KEY (`invite_id`,`sender`,`receiver`) USING BTREE,
-  KEY `sender` (`sender`)
+  KEY `sender` (`sender`),
+  KEY `evaluate_id` (`evaluate_id`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci
 ;
 
@@ -204,6 +250,7 @@ CREATE TABLE `notify_config` (
   `follow` tinyint unsigned NOT NULL DEFAULT '1' COMMENT '收到关注，0 不接受提醒，1 所有人  2 关注的人',
   `message` tinyint unsigned NOT NULL DEFAULT '1' COMMENT '收到关注，0 不接受提醒，1 所有人  2 关注的人',
   `at` tinyint unsigned NOT NULL DEFAULT '1' COMMENT '收到at，0 不接受提醒，1 所有人  2 关注的人',
+  `forward` tinyint unsigned NOT NULL DEFAULT '1' COMMENT '被转发，0 不接受提醒，1 所有人  2 关注的人',
   `createAt` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
   `updateAt` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
   PRIMARY KEY (`user_id`)
@@ -222,6 +269,7 @@ CREATE TABLE `notify_unread_count` (
   `follow` int unsigned NOT NULL DEFAULT '0',
   `at` int unsigned NOT NULL DEFAULT '0',
   `commission` int unsigned DEFAULT '0',
+  `forward` int unsigned NOT NULL DEFAULT '0',
   `createAt` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
   `updateAt` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
   PRIMARY KEY (`user_id`)
@@ -421,13 +469,32 @@ CREATE TABLE `tag` (
   `tag_id` bigint unsigned NOT NULL COMMENT '标签ID',
   `tag_name` varchar(25) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_bin NOT NULL COMMENT '标签名',
   `art_count` int unsigned NOT NULL DEFAULT '1' COMMENT '标签的作品统计',
+  `tag_key` varchar(25) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_bin GENERATED ALWAYS AS (lower(replace(replace(`tag_name`,_utf8mb4' ',_utf8mb4''),_utf8mb4'　',_utf8mb4''))) STORED COMMENT '归一化的标签名',
+  `parent_id` bigint unsigned NOT NULL DEFAULT '0' COMMENT '父标签ID',
+  `merged_to` bigint unsigned NOT NULL DEFAULT '0' COMMENT '被合并到的标签ID',
   `createAt` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
   `updateAt` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
   PRIMARY KEY (`tag_id`),
-  UNIQUE KEY `tag_name` (`tag_name`)
+  UNIQUE KEY `tag_name` (`tag_name`),
+  KEY `tag_key` (`tag_key`),
+  KEY `parent_id` (`parent_id`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci ROW_FORMAT=DYNAMIC COMMENT='标签表'
 ;
 
+/******************************************/
+/*   DatabaseName = onpaper   */
+/*   TableName = tag_alias   */
+/******************************************/
+CREATE TABLE `tag_alias` (
+  `alias_key` varchar(25) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_bin NOT NULL COMMENT '归一化的别名',
+  `alias_name` varchar(25) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_bin NOT NULL COMMENT '别名',
+  `tag_id` bigint unsigned NOT NULL COMMENT '规范标签ID',
+  `createAt` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`alias_key`),
+  KEY `tag_id` (`tag_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci ROW_FORMAT=DYNAMIC COMMENT='标签别名表'
+;
+
 /******************************************/
 /*   DatabaseName = onpaper   */
 /*   TableName = tag_artwork   */
@@ -516,6 +583,7 @@ CREATE TABLE `user_focus` (
   `user_id` bigint unsigned NOT NULL COMMENT '用户id',
   `focus_id` bigint unsigned NOT NULL COMMENT '被关注的用户id',
   `is_cancel` tinyint unsigned DEFAULT '0' COMMENT '是否取消关注',
+  `mute` enum('none','trend','forward','all') CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'none' COMMENT 'feed中屏蔽 trend 动态 forward 转发 all 全部',
   `createAt` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
   `updateAt` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
   PRIMARY KEY (`user_id`,`focus_id`),
@@ -524,6 +592,34 @@ CREATE TABLE `user_focus` (
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci ROW_FORMAT=DYNAMIC
 ;
 
+/******************************************/
+/*   DatabaseName = onpaper   */
+/*   TableName = user_mute_word   */
+/******************************************/
+CREATE TABLE `user_mute_word` (
+  `user_id` bigint unsigned NOT NULL COMMENT '用户id',
+  `word` varchar(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '屏蔽的关键词',
+  `createAt` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`user_id`,`word`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci ROW_FORMAT=DYNAMIC COMMENT='用户feed屏蔽的关键词'
+;
+
+/******************************************/
+/*   DatabaseName = onpaper   */
+/*   TableName = user_interest   */
+/******************************************/
+CREATE TABLE `user_interest` (
+  `user_id` bigint unsigned NOT NULL COMMENT '用户id',
+  `type` enum('tag','topic') CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL COMMENT '关注的类型',
+  `target_id` bigint unsigned NOT NULL COMMENT '关注的标签或话题id',
+  `is_cancel` tinyint unsigned NOT NULL DEFAULT '0' COMMENT '是否取消关注',
+  `createAt` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `updateAt` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`user_id`,`type`,`target_id`),
+  KEY `target` (`type`,`target_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci ROW_FORMAT=DYNAMIC COMMENT='用户关注的标签和话题'
+;
+
 /******************************************/
 /*   DatabaseName = onpaper   */
 /*   TableName = user_intro   */
@@ -563,6 +659,7 @@ CREATE TABLE `user_profile` (
   `v_status` tinyint unsigned NOT NULL DEFAULT '0' COMMENT '1是用户推荐之类，2是官方认证',
   `commission` tinyint unsigned NOT NULL DEFAULT '0' COMMENT '是否开始接稿',
   `have_plan` tinyint unsigned NOT NULL DEFAULT '0' COMMENT '是否有接稿计划',
+  `adult_pref` enum('hide','blur','show') CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT 'hide' COMMENT '敏感内容展示偏好',
   `createAt` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
   `updateAt` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
   PRIMARY KEY (`user_id`),
@@ -0,0 +1,158 @@
+package agreement
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/go-pdf/fpdf"
+	"github.com/pkg/errors"
+	m "onpaper-api-go/models"
+	"onpaper-api-go/settings"
+	"os"
+	"strings"
+	"sync"
+)
+
+const fontName = "cjk"
+
+// 字体文件只读取一次 读取失败下次再重试
+var font struct {
+	sync.Mutex
+	data []byte
+}
+
+// 约稿尺寸的显示名称
+var fileSizeName = map[string]string{
+	"game":   "游戏立绘",
+	"weibo":  "微博头像",
+	"pc":     "电脑壁纸",
+	"a4":     "A4",
+	"diy":    "自定义",
+	"square": "正方形",
+}
+
+func loadFont() (data []byte, err error) {
+	font.Lock()
+	defer font.Unlock()
+
+	if font.data != nil {
+		return font.data, nil
+	}
+	if settings.Conf.Agreement == nil || settings.Conf.AgreementFontPath == "" {
+		err = errors.New("agreement font path not config")
+		return
+	}
+	data, err = os.ReadFile(settings.Conf.AgreementFontPath)
+	if err != nil {
+		err = errors.Wrap(err, "read agreement font fail")
+		return
+	}
+	font.data = data
+	return
+}
+
+// 启动时字体检查通过才生成协议
+var enabled bool
+
+// Init 启动时加载协议字体 字体缺失或不能使用时返回错误并关闭协议生成
+func Init() (err error) {
+	data, err := loadFont()
+	if err != nil {
+		return
+	}
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(fontName, "", data)
+	if pdf.Err() {
+		err = errors.Wrap(pdf.Error(), "agreement font not TrueType")
+		return
+	}
+	enabled = true
+	return
+}
+
+// Enabled 是否可以生成约稿协议
+func Enabled() bool {
+	return enabled
+}
+
+// TermsHash 计算协议条款的 sha256
+func TermsHash(terms m.AgreementTerms) (hash string, err error) {
+	data, err := json.Marshal(terms)
+	if err != nil {
+		return
+	}
+	sum := sha256.Sum256(data)
+	hash = hex.EncodeToString(sum[:])
+	return
+}
+
+// FileHash 计算文件的 sha256
+func FileHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// CreatePDF 生成约稿协议 pdf 并在页脚打印条款 hash
+func CreatePDF(terms m.AgreementTerms, hash string) (data []byte, err error) {
+	fontData, err := loadFont()
+	if err != nil {
+		return
+	}
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(fontName, "", fontData)
+	pdf.SetTitle(fmt.Sprintf("约稿协议 %d", terms.InviteId), true)
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont(fontName, "", 8)
+		pdf.CellFormat(0, 10, "SHA-256: "+hash, "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	pdf.SetFont(fontName, "", 18)
+	pdf.CellFormat(0, 14, "约稿协议", "", 1, "C", false, 0, "")
+	pdf.SetFont(fontName, "", 10)
+	pdf.CellFormat(0, 8, fmt.Sprintf("编号：%d    生成日期：%s", terms.InviteId, terms.SignDate), "", 1, "C", false, 0, "")
+	pdf.Ln(4)
+
+	size := terms.FileSize
+	if name, ok := fileSizeName[size]; ok {
+		size = name
+	}
+	rows := [][2]string{
+		{"约稿名称", terms.Name},
+		{"约稿方", fmt.Sprintf("%s（ID：%s）", terms.SenderName, terms.SenderId)},
+		{"画师", fmt.Sprintf("%s（ID：%s）", terms.ArtistName, terms.ArtistId)},
+		{"约稿类型", terms.Category},
+		{"用途", terms.Purpose},
+		{"尺寸", size},
+		{"颜色模式", terms.Color},
+		{"源文件类型", strings.Join(terms.FileType, "、")},
+		{"截稿日期", terms.Deadline},
+		{"价格", terms.Money},
+		{"可修改次数", fmt.Sprintf("%d 次", terms.Change)},
+		{"画师不接的内容", terms.Refuse},
+	}
+
+	pdf.SetFont(fontName, "", 12)
+	for _, row := range rows {
+		pdf.CellFormat(40, 10, row[0], "1", 0, "L", false, 0, "")
+		pdf.MultiCell(0, 10, row[1], "1", "L", false)
+	}
+
+	pdf.Ln(6)
+	pdf.SetFont(fontName, "", 10)
+	pdf.MultiCell(0, 6, "本协议根据双方在平台上确认的约稿方案和报价自动生成，页脚的 SHA-256 为协议条款的摘要，"+
+		"可以在平台上校验协议内容是否被修改。", "", "L", false)
+
+	var buf bytes.Buffer
+	err = pdf.Output(&buf)
+	if err != nil {
+		err = errors.Wrap(err, "agreement pdf output fail")
+		return
+	}
+	data = buf.Bytes()
+	return
+}
@@ -0,0 +1,18 @@
+package formatTools
+
+import (
+	"strings"
+)
+
+// CleanTagName 去掉标签首尾空白 中间连续的空白合并成一个空格
+func CleanTagName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// TagKey 标签的归一化键 去掉空格后转小写
+// 要和 tag 表 tag_key 生成列的表达式保持一致
+func TagKey(name string) string {
+	name = strings.ReplaceAll(name, " ", "")
+	name = strings.ReplaceAll(name, "　", "")
+	return strings.ToLower(name)
+}
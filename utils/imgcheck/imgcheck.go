@@ -0,0 +1,157 @@
+package imgcheck
+
+import (
+	"bytes"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+
+	"github.com/pkg/errors"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	MaxPixels = 64000000 // 最大像素数 防止解压炸弹
+	MaxSide   = 20000    // 最长边
+	MaxFrames = 1000     // gif 最多帧数
+)
+
+var (
+	ErrNotImage = errors.New("not a supported image")
+	ErrTooLarge = errors.New("image too large")
+)
+
+// Info 从文件内容中读取的图片信息
+type Info struct {
+	Mimetype string
+	Size     int64
+	Width    int
+	Height   int
+}
+
+// Clean 验证图片并去掉元数据
+// 根据文件头判断类型 读取真实尺寸 文件结束后还有数据的视为伪装文件
+// 返回去掉 EXIF/GPS 等元数据后的文件内容
+func Clean(data []byte, maxSize int64) (clean []byte, info Info, err error) {
+	if int64(len(data)) > maxSize {
+		err = ErrTooLarge
+		return
+	}
+
+	format := sniff(data)
+	if format == "" {
+		err = ErrNotImage
+		return
+	}
+
+	// 只读取头部信息 不解码像素
+	cfg, decoded, cErr := image.DecodeConfig(bytes.NewReader(data))
+	if cErr != nil || decoded != format {
+		err = ErrNotImage
+		return
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		err = ErrNotImage
+		return
+	}
+	if cfg.Width > MaxSide || cfg.Height > MaxSide || cfg.Width*cfg.Height > MaxPixels {
+		err = ErrTooLarge
+		return
+	}
+
+	orientation := 1
+	switch format {
+	case "jpeg":
+		clean, orientation, err = stripJPEG(data)
+	case "png":
+		clean, err = stripPNG(data)
+	case "gif":
+		clean, err = stripGIF(data, cfg.Width, cfg.Height)
+	case "webp":
+		clean, err = stripWebP(data)
+	}
+	if err != nil {
+		return
+	}
+
+	info = Info{
+		Mimetype: "image/" + format,
+		Width:    cfg.Width,
+		Height:   cfg.Height,
+	}
+
+	// EXIF 中的方向去掉后 需要把方向应用到像素上
+	if orientation > 1 && orientation <= 8 {
+		clean, info.Width, info.Height, err = applyOrientation(clean, orientation)
+		if err != nil {
+			return
+		}
+	}
+	info.Size = int64(len(clean))
+	return
+}
+
+// sniff 根据文件头判断图片类型
+func sniff(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		return "jpeg"
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return "png"
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return "gif"
+	case len(data) >= 12 && bytes.Equal(data[0:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WEBP")):
+		return "webp"
+	}
+	return ""
+}
+
+// applyOrientation 按 EXIF 方向旋转或翻转图片 重新编码为 jpeg
+func applyOrientation(data []byte, orientation int) (res []byte, width, height int, err error) {
+	src, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		err = ErrNotImage
+		return
+	}
+
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	width, height = w, h
+	if orientation >= 5 {
+		width, height = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			}
+			dst.Set(x, y, src.At(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+
+	var buf bytes.Buffer
+	err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 95})
+	if err != nil {
+		err = errors.Wrap(err, "applyOrientation encode fail")
+		return
+	}
+	res = buf.Bytes()
+	return
+}
@@ -0,0 +1,305 @@
+package imgcheck
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// stripJPEG 去掉 EXIF/XMP/IPTC/注释 等段 保留 JFIF ICC 和 Adobe 段
+// 同时读取 EXIF 中的方向 EOI 之后不允许有数据
+func stripJPEG(data []byte) (res []byte, orientation int, err error) {
+	orientation = 1
+	res = make([]byte, 0, len(data))
+	res = append(res, 0xFF, 0xD8)
+	pos := 2
+
+	for {
+		// 跳过填充的 0xFF
+		if pos >= len(data) || data[pos] != 0xFF {
+			err = ErrNotImage
+			return
+		}
+		for pos < len(data) && data[pos] == 0xFF {
+			pos++
+		}
+		if pos >= len(data) {
+			err = ErrNotImage
+			return
+		}
+		marker := data[pos]
+		pos++
+
+		switch {
+		case marker == 0xD9:
+			// EOI 之后还有数据 可能是伪装成图片的其他文件
+			if pos != len(data) {
+				err = ErrNotImage
+				return
+			}
+			res = append(res, 0xFF, 0xD9)
+			return
+		case marker == 0x01 || marker >= 0xD0 && marker <= 0xD7:
+			res = append(res, 0xFF, marker)
+			continue
+		}
+
+		if pos+2 > len(data) {
+			err = ErrNotImage
+			return
+		}
+		length := int(binary.BigEndian.Uint16(data[pos:]))
+		if length < 2 || pos+length > len(data) {
+			err = ErrNotImage
+			return
+		}
+		segment := data[pos : pos+length]
+		payload := segment[2:]
+		pos += length
+
+		if marker == 0xE1 && bytes.HasPrefix(payload, []byte("Exif\x00\x00")) {
+			orientation = exifOrientation(payload[6:])
+		}
+		if !keepJPEGSegment(marker, payload) {
+			continue
+		}
+		res = append(res, 0xFF, marker)
+		res = append(res, segment...)
+
+		if marker != 0xDA {
+			continue
+		}
+		// SOS 之后是压缩数据 直到遇到不是 RST 的标记
+		start := pos
+		for pos+1 < len(data) {
+			if data[pos] == 0xFF && data[pos+1] != 0x00 && !(data[pos+1] >= 0xD0 && data[pos+1] <= 0xD7) {
+				break
+			}
+			pos++
+		}
+		if pos+1 >= len(data) {
+			err = ErrNotImage
+			return
+		}
+		res = append(res, data[start:pos]...)
+	}
+}
+
+// keepJPEGSegment 需要保留的段
+func keepJPEGSegment(marker byte, payload []byte) bool {
+	switch {
+	case marker == 0xE0 || marker == 0xEE:
+		// JFIF 和 Adobe 颜色信息
+		return true
+	case marker == 0xE2:
+		// 只保留 ICC 颜色配置
+		return bytes.HasPrefix(payload, []byte("ICC_PROFILE\x00"))
+	case marker >= 0xE1 && marker <= 0xEF, marker == 0xFE:
+		return false
+	}
+	return true
+}
+
+// exifOrientation 读取 EXIF IFD0 中的方向 读不到时返回 1
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[0:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	offset := int(order.Uint32(tiff[4:8]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+	return 1
+}
+
+// stripPNG 去掉文本 EXIF 和时间块 IEND 之后不允许有数据
+func stripPNG(data []byte) (res []byte, err error) {
+	res = make([]byte, 0, len(data))
+	res = append(res, data[:8]...)
+	pos := 8
+
+	for pos+12 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		end := pos + 12 + length
+		if length < 0 || end > len(data) {
+			break
+		}
+		chunkType := string(data[pos+4 : pos+8])
+		switch chunkType {
+		case "tEXt", "zTXt", "iTXt", "eXIf", "tIME":
+		default:
+			res = append(res, data[pos:end]...)
+		}
+		pos = end
+
+		if chunkType == "IEND" {
+			if pos != len(data) {
+				break
+			}
+			return
+		}
+	}
+	err = ErrNotImage
+	return
+}
+
+// stripWebP 去掉 EXIF 和 XMP 块 并修改 VP8X 中的标记
+func stripWebP(data []byte) (res []byte, err error) {
+	if int(binary.LittleEndian.Uint32(data[4:8]))+8 != len(data) {
+		err = ErrNotImage
+		return
+	}
+
+	res = make([]byte, 12, len(data))
+	copy(res, data[:12])
+	pos := 12
+
+	for pos < len(data) {
+		if pos+8 > len(data) {
+			err = ErrNotImage
+			return
+		}
+		fourCC := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		end := pos + 8 + size + size%2
+		if size < 0 || end > len(data) {
+			err = ErrNotImage
+			return
+		}
+
+		switch fourCC {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := append([]byte(nil), data[pos:end]...)
+			if size > 0 {
+				// 去掉 EXIF 和 XMP 的标记位
+				chunk[8] &^= 0x08 | 0x04
+			}
+			res = append(res, chunk...)
+		default:
+			res = append(res, data[pos:end]...)
+		}
+		pos = end
+	}
+
+	binary.LittleEndian.PutUint32(res[4:8], uint32(len(res)-8))
+	return
+}
+
+// stripGIF 去掉注释和除动画循环以外的应用扩展 结束符之后不允许有数据
+// 每一帧的尺寸不能超过画布
+func stripGIF(data []byte, width, height int) (res []byte, err error) {
+	if len(data) < 13 {
+		err = ErrNotImage
+		return
+	}
+	pos := 13
+	// 全局颜色表
+	if data[10]&0x80 != 0 {
+		pos += 3 << (uint(data[10]&0x07) + 1)
+	}
+	if pos > len(data) {
+		err = ErrNotImage
+		return
+	}
+	res = make([]byte, 0, len(data))
+	res = append(res, data[:pos]...)
+
+	frames := 0
+	for pos < len(data) {
+		start := pos
+		switch data[pos] {
+		case 0x3B:
+			if pos+1 != len(data) {
+				err = ErrNotImage
+				return
+			}
+			res = append(res, 0x3B)
+			return
+		case 0x2C:
+			frames++
+			if frames > MaxFrames || pos+10 > len(data) {
+				err = ErrTooLarge
+				return
+			}
+			left := int(binary.LittleEndian.Uint16(data[pos+1:]))
+			top := int(binary.LittleEndian.Uint16(data[pos+3:]))
+			w := int(binary.LittleEndian.Uint16(data[pos+5:]))
+			h := int(binary.LittleEndian.Uint16(data[pos+7:]))
+			if left+w > width || top+h > height {
+				err = ErrNotImage
+				return
+			}
+			flags := data[pos+9]
+			pos += 10
+			// 局部颜色表
+			if flags&0x80 != 0 {
+				pos += 3 << (uint(flags&0x07) + 1)
+			}
+			// LZW 最小码长
+			pos++
+			pos, err = skipSubBlocks(data, pos)
+			if err != nil {
+				return
+			}
+			res = append(res, data[start:pos]...)
+		case 0x21:
+			if pos+2 > len(data) {
+				err = ErrNotImage
+				return
+			}
+			label := data[pos+1]
+			keep := label == 0xF9 || label == 0x01
+			if label == 0xFF && pos+14 <= len(data) {
+				appId := string(data[pos+3 : pos+14])
+				keep = appId == "NETSCAPE2.0" || appId == "ANIMEXTS1.0"
+			}
+			pos, err = skipSubBlocks(data, pos+2)
+			if err != nil {
+				return
+			}
+			if keep {
+				res = append(res, data[start:pos]...)
+			}
+		default:
+			err = ErrNotImage
+			return
+		}
+	}
+	err = ErrNotImage
+	return
+}
+
+// skipSubBlocks 跳过 gif 的数据子块 返回结束后的位置
+func skipSubBlocks(data []byte, pos int) (int, error) {
+	for {
+		if pos >= len(data) {
+			return pos, ErrNotImage
+		}
+		size := int(data[pos])
+		pos++
+		if size == 0 {
+			return pos, nil
+		}
+		pos += size
+	}
+}
@@ -1,6 +1,7 @@
 package oss
 
 import (
+	"bytes"
 	"fmt"
 	openapi "github.com/alibabacloud-go/darabonba-openapi/v2/client"
 	sts20150401 "github.com/alibabacloud-go/sts-20150401/v2/client"
@@ -8,6 +9,7 @@ import (
 	"github.com/alibabacloud-go/tea/tea"
 	"github.com/aliyun/aliyun-oss-go-sdk/oss"
 	"github.com/pkg/errors"
+	"io"
 	"net/http"
 	"onpaper-api-go/logger"
 	"onpaper-api-go/settings"
@@ -206,3 +208,94 @@ func BatchDeleteOssObject(bucketName string, dir, exclude string) (err error) {
 
 	return
 }
+
+// DeleteOssObjects 删除指定的多个文件
+func DeleteOssObjects(bucketName string, keys []string) (err error) {
+	if len(keys) == 0 {
+		return
+	}
+	client, err := CreateOssClient()
+	if err != nil {
+		return
+	}
+	bucket, err := client.Bucket(bucketName)
+	if err != nil {
+		return
+	}
+
+	_, err = bucket.DeleteObjects(keys, oss.DeleteObjectsQuiet(true))
+	return
+}
+
+// PutOssObject 上传文件内容到指定桶
+func PutOssObject(bucketName, key string, data []byte, contentType string) (err error) {
+	client, err := CreateOssClient()
+	if err != nil {
+		return
+	}
+	bucket, err := client.Bucket(bucketName)
+	if err != nil {
+		return
+	}
+
+	err = bucket.PutObject(key, bytes.NewReader(data), oss.ContentType(contentType))
+	return
+}
+
+// GetOssObject 读取文件内容
+func GetOssObject(bucketName, key string) (data []byte, err error) {
+	client, err := CreateOssClient()
+	if err != nil {
+		return
+	}
+	bucket, err := client.Bucket(bucketName)
+	if err != nil {
+		return
+	}
+
+	body, err := bucket.GetObject(key)
+	if err != nil {
+		return
+	}
+	defer body.Close()
+
+	data, err = io.ReadAll(body)
+	return
+}
+
+// GetOssObjectLimit 读取文件内容 最多读取 limit+1 个字节
+// 调用方用返回的长度判断文件是否超过 limit 避免把过大的文件整个读进内存
+func GetOssObjectLimit(bucketName, key string, limit int64) (data []byte, err error) {
+	client, err := CreateOssClient()
+	if err != nil {
+		return
+	}
+	bucket, err := client.Bucket(bucketName)
+	if err != nil {
+		return
+	}
+
+	body, err := bucket.GetObject(key)
+	if err != nil {
+		return
+	}
+	defer body.Close()
+
+	data, err = io.ReadAll(io.LimitReader(body, limit+1))
+	return
+}
+
+// SignOssGetURL 生成有过期时间的文件下载链接
+func SignOssGetURL(bucketName, key string, expiredSec int64) (url string, err error) {
+	client, err := CreateOssClient()
+	if err != nil {
+		return
+	}
+	bucket, err := client.Bucket(bucketName)
+	if err != nil {
+		return
+	}
+
+	url, err = bucket.SignURL(key, oss.HTTPGet, expiredSec)
+	return
+}
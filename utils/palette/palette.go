@@ -0,0 +1,208 @@
+package palette
+
+import (
+	"fmt"
+	"image"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	SampleSize = 64  // 提取颜色前把图片缩小到的尺寸
+	Iterations = 12  // k-means 最多迭代次数
+	BucketBits = 3   // 每个通道的分桶位数 用于数据库索引
+	MinAlpha   = 128 // 透明度低于这个值的像素不参与计算
+)
+
+// Color 主色和它在图片中的占比
+type Color struct {
+	R, G, B uint8
+	Weight  uint8 // 占比 0-100
+}
+
+// Hex 颜色的十六进制表示 不带 #
+func (c Color) Hex() string {
+	return fmt.Sprintf("%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// Bucket 颜色所在的分桶 每个通道取高 BucketBits 位
+func (c Color) Bucket() int {
+	shift := 8 - BucketBits
+	return int(c.R>>shift)<<(2*BucketBits) | int(c.G>>shift)<<BucketBits | int(c.B>>shift)
+}
+
+// Extract 用 k-means 提取图片的 k 个主色 按占比从大到小排序
+func Extract(img image.Image, k int) []Color {
+	pixels := sample(img)
+	if len(pixels) == 0 || k <= 0 {
+		return nil
+	}
+	if k > len(pixels) {
+		k = len(pixels)
+	}
+
+	// 按亮度排序后等距取初始中心 结果是确定的
+	sorted := make([][3]float64, len(pixels))
+	copy(sorted, pixels)
+	sort.Slice(sorted, func(i, j int) bool { return luma(sorted[i]) < luma(sorted[j]) })
+	centers := make([][3]float64, k)
+	for i := range centers {
+		centers[i] = sorted[(2*i+1)*len(sorted)/(2*k)]
+	}
+
+	assign := make([]int, len(pixels))
+	counts := make([]int, k)
+	for iter := 0; iter < Iterations; iter++ {
+		changed := false
+		for i, p := range pixels {
+			best, bestDist := 0, -1.0
+			for j, c := range centers {
+				d := dist(p, c)
+				if bestDist < 0 || d < bestDist {
+					best, bestDist = j, d
+				}
+			}
+			if iter == 0 || assign[i] != best {
+				assign[i] = best
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+
+		sums := make([][3]float64, k)
+		for j := range counts {
+			counts[j] = 0
+		}
+		for i, p := range pixels {
+			j := assign[i]
+			sums[j][0] += p[0]
+			sums[j][1] += p[1]
+			sums[j][2] += p[2]
+			counts[j]++
+		}
+		for j := range centers {
+			// 空的中心保持不动
+			if counts[j] == 0 {
+				continue
+			}
+			n := float64(counts[j])
+			centers[j] = [3]float64{sums[j][0] / n, sums[j][1] / n, sums[j][2] / n}
+		}
+	}
+
+	colors := make([]Color, 0, k)
+	for j, c := range centers {
+		if counts[j] == 0 {
+			continue
+		}
+		colors = append(colors, Color{
+			R:      uint8(c[0] + 0.5),
+			G:      uint8(c[1] + 0.5),
+			B:      uint8(c[2] + 0.5),
+			Weight: uint8(counts[j] * 100 / len(pixels)),
+		})
+	}
+	sort.SliceStable(colors, func(i, j int) bool { return colors[i].Weight > colors[j].Weight })
+	return colors
+}
+
+// Parse 解析十六进制颜色 可以带 # 支持 3 位和 6 位
+func Parse(s string) (c Color, ok bool) {
+	s = strings.TrimPrefix(strings.ToLower(s), "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) != 6 {
+		return
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return
+	}
+	return Color{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v)}, true
+}
+
+// Normalize 把查询的颜色对齐到每通道 16 级 减少缓存的数量
+func Normalize(s string) (hex string, ok bool) {
+	c, ok := Parse(s)
+	if !ok {
+		return
+	}
+	snap := func(v uint8) uint8 { return uint8((int(v) + 8) / 17 * 17) }
+	return Color{R: snap(c.R), G: snap(c.G), B: snap(c.B)}.Hex(), true
+}
+
+// Buckets 与颜色的距离在 radius 以内的颜色可能所在的分桶
+func Buckets(c Color, radius int) []int {
+	shift := uint(8 - BucketBits)
+	span := func(v uint8) (int, int) {
+		low, high := int(v)-radius, int(v)+radius
+		if low < 0 {
+			low = 0
+		}
+		if high > 255 {
+			high = 255
+		}
+		return low >> shift, high >> shift
+	}
+	r0, r1 := span(c.R)
+	g0, g1 := span(c.G)
+	b0, b1 := span(c.B)
+
+	buckets := make([]int, 0, (r1-r0+1)*(g1-g0+1)*(b1-b0+1))
+	for r := r0; r <= r1; r++ {
+		for g := g0; g <= g1; g++ {
+			for b := b0; b <= b1; b++ {
+				buckets = append(buckets, r<<(2*BucketBits)|g<<BucketBits|b)
+			}
+		}
+	}
+	return buckets
+}
+
+// sample 把图片缩小后取出不透明的像素
+func sample(img image.Image) [][3]float64 {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w == 0 || h == 0 {
+		return nil
+	}
+	if w > SampleSize || h > SampleSize {
+		if w >= h {
+			w, h = SampleSize, h*SampleSize/w
+		} else {
+			w, h = w*SampleSize/h, SampleSize
+		}
+		if w == 0 {
+			w = 1
+		}
+		if h == 0 {
+			h = 1
+		}
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.ApproxBiLinear.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+
+	pixels := make([][3]float64, 0, w*h)
+	for i := 0; i < len(dst.Pix); i += 4 {
+		if dst.Pix[i+3] < MinAlpha {
+			continue
+		}
+		pixels = append(pixels, [3]float64{float64(dst.Pix[i]), float64(dst.Pix[i+1]), float64(dst.Pix[i+2])})
+	}
+	return pixels
+}
+
+func dist(a, b [3]float64) float64 {
+	dr, dg, db := a[0]-b[0], a[1]-b[1], a[2]-b[2]
+	return dr*dr + dg*dg + db*db
+}
+
+func luma(p [3]float64) float64 {
+	return 0.299*p[0] + 0.587*p[1] + 0.114*p[2]
+}
@@ -0,0 +1,56 @@
+package phash
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math/bits"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// BandCount 把64位哈希分成6段建立索引
+// 两个哈希的汉明距离不超过 BandCount-1 时 至少有一段完全相同
+const BandCount = 6
+
+// DHashImage 计算图片的差值哈希
+// 缩放到 9x8 的灰度图 每行比较相邻像素的亮度 得到64位哈希
+func DHashImage(src image.Image) (hash uint64) {
+	dst := image.NewGray(image.Rect(0, 0, 9, 8))
+	draw.ApproxBiLinear.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if dst.GrayAt(x, y).Y > dst.GrayAt(x+1, y).Y {
+				hash |= 1
+			}
+		}
+	}
+	return
+}
+
+// Distance 两个哈希的汉明距离
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Bands 把哈希切分成索引段 每段带上序号 避免不同位置的段相互匹配
+func Bands(hash uint64) []string {
+	bands := make([]string, 0, BandCount)
+	var offset uint
+	for i := 0; i < BandCount; i++ {
+		// 64 位分成 11,11,11,11,10,10
+		width := uint(64 / BandCount)
+		if i < 64%BandCount {
+			width++
+		}
+		value := (hash >> offset) & (1<<width - 1)
+		bands = append(bands, fmt.Sprintf("%d:%x", i, value))
+		offset += width
+	}
+	return bands
+}
@@ -226,6 +226,20 @@ func WhoSee(text string) (isPass bool) {
 	return
 }
 
+// License 验证作品授权协议
+func License(text string) (isPass bool) {
+	licenseList := []string{
+		models.LicenseOwner, models.LicenseBY, models.LicenseBYSA, models.LicenseBYND,
+		models.LicenseBYNC, models.LicenseBYNCSA, models.LicenseBYNCND, models.LicenseCC0,
+	}
+	for _, s := range licenseList {
+		if text == s {
+			isPass = true
+		}
+	}
+	return
+}
+
 // SixNumCode 六位数字验证码
 func SixNumCode(code string) (isPass bool, err error) {
 	const codeRule = "^\\d{6}$"
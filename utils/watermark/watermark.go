@@ -0,0 +1,128 @@
+package watermark
+
+import (
+	"bytes"
+	"github.com/pkg/errors"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"onpaper-api-go/settings"
+	"os"
+	"sync"
+)
+
+// 字体文件只解析一次 失败下次再重试
+var watermarkFont struct {
+	sync.Mutex
+	font *opentype.Font
+}
+
+func loadFont() (f *opentype.Font, err error) {
+	watermarkFont.Lock()
+	defer watermarkFont.Unlock()
+
+	if watermarkFont.font != nil {
+		return watermarkFont.font, nil
+	}
+	if settings.Conf.Download == nil || settings.Conf.WatermarkFontPath == "" {
+		err = errors.New("watermark font path not config")
+		return
+	}
+	data, err := os.ReadFile(settings.Conf.WatermarkFontPath)
+	if err != nil {
+		err = errors.Wrap(err, "read watermark font fail")
+		return
+	}
+	f, err = opentype.Parse(data)
+	if err != nil {
+		err = errors.Wrap(err, "parse watermark font fail")
+		return
+	}
+	watermarkFont.font = f
+	return
+}
+
+// 启动时字体检查通过才生成水印图
+var enabled bool
+
+// Init 启动时加载水印字体 字体缺失或不能解析时返回错误并关闭水印下载
+func Init() (err error) {
+	_, err = loadFont()
+	enabled = err == nil
+	return
+}
+
+// Enabled 是否可以生成水印图
+func Enabled() bool {
+	return enabled
+}
+
+// Render 在图片右下角绘制文字水印 返回 jpeg 图片
+// 每一行是一段文字 字号按图片宽度缩放
+func Render(src []byte, lines []string) (data []byte, err error) {
+	img, _, err := image.Decode(bytes.NewReader(src))
+	if err != nil {
+		err = errors.Wrap(err, "watermark decode image fail")
+		return
+	}
+
+	f, err := loadFont()
+	if err != nil {
+		return
+	}
+
+	bounds := img.Bounds()
+	size := float64(bounds.Dx()) / 40
+	if size < 12 {
+		size = 12
+	}
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		err = errors.Wrap(err, "watermark new face fail")
+		return
+	}
+	defer face.Close()
+
+	canvas := image.NewRGBA(bounds)
+	draw.Draw(canvas, bounds, img, bounds.Min, draw.Src)
+
+	margin := fixed.I(int(size))
+	lineHeight := face.Metrics().Height
+	y := fixed.I(bounds.Max.Y) - margin - lineHeight.Mul(fixed.I(len(lines)-1))
+	for _, line := range lines {
+		width := font.MeasureString(face, line)
+		x := fixed.I(bounds.Max.X) - margin - width
+		// 先画一层深色阴影 浅色图片上也能看清
+		for _, layer := range []struct {
+			offset fixed.Int26_6
+			color  color.Color
+		}{
+			{fixed.I(1), color.RGBA{A: 160}},
+			{0, color.RGBA{R: 255, G: 255, B: 255, A: 200}},
+		} {
+			d := font.Drawer{
+				Dst:  canvas,
+				Src:  image.NewUniform(layer.color),
+				Face: face,
+				Dot:  fixed.Point26_6{X: x + layer.offset, Y: y + layer.offset},
+			}
+			d.DrawString(line)
+		}
+		y += lineHeight
+	}
+
+	var buf bytes.Buffer
+	err = jpeg.Encode(&buf, canvas, &jpeg.Options{Quality: 95})
+	if err != nil {
+		err = errors.Wrap(err, "watermark encode jpeg fail")
+		return
+	}
+	data = buf.Bytes()
+	return
+}